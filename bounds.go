@@ -0,0 +1,143 @@
+package joejson
+
+import "math"
+
+// bounds is an axis aligned rectangle in lon/lat (x/y) space.
+type bounds struct {
+	minX, minY, maxX, maxY float64
+}
+
+// emptyBounds is the identity value for bounds.extend.
+func emptyBounds() bounds {
+	return bounds{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+}
+
+func (b bounds) isEmpty() bool {
+	return b.minX > b.maxX || b.minY > b.maxY
+}
+
+func (b bounds) extend(o bounds) bounds {
+	return bounds{
+		math.Min(b.minX, o.minX),
+		math.Min(b.minY, o.minY),
+		math.Max(b.maxX, o.maxX),
+		math.Max(b.maxY, o.maxY),
+	}
+}
+
+func (b bounds) extendPosition(p Position) bounds {
+	return bounds{
+		math.Min(b.minX, p.Lon()),
+		math.Min(b.minY, p.Lat()),
+		math.Max(b.maxX, p.Lon()),
+		math.Max(b.maxY, p.Lat()),
+	}
+}
+
+func (b bounds) intersects(o bounds) bool {
+	return b.minX <= o.maxX && o.minX <= b.maxX && b.minY <= o.maxY && o.minY <= b.maxY
+}
+
+func (b bounds) contains(o bounds) bool {
+	return b.minX <= o.minX && o.maxX <= b.maxX && b.minY <= o.minY && o.maxY <= b.maxY
+}
+
+func (b bounds) containsPosition(p Position) bool {
+	return b.minX <= p.Lon() && p.Lon() <= b.maxX && b.minY <= p.Lat() && p.Lat() <= b.maxY
+}
+
+func (b bounds) area() float64 {
+	if b.isEmpty() {
+		return 0
+	}
+	return (b.maxX - b.minX) * (b.maxY - b.minY)
+}
+
+// distance is the planar distance from p to the closest point of b.
+func (b bounds) distance(p Position) float64 {
+	dx := math.Max(0, math.Max(b.minX-p.Lon(), p.Lon()-b.maxX))
+	dy := math.Max(0, math.Max(b.minY-p.Lat(), p.Lat()-b.maxY))
+	return math.Hypot(dx, dy)
+}
+
+// bbox converts b to the south-west, north-east Position pair used by Feature.Bbox.
+func (b bounds) bbox() []Position {
+	return []Position{{b.minX, b.minY}, {b.maxX, b.maxY}}
+}
+
+// polygon is b as a counterclockwise rectangle.
+func (b bounds) polygon() Polygon {
+	return Polygon{{{b.minX, b.minY}, {b.maxX, b.minY}, {b.maxX, b.maxY}, {b.minX, b.maxY}, {b.minX, b.minY}}}
+}
+
+// bboxBounds converts a south-west, north-east Position pair into bounds.
+func bboxBounds(bbox []Position) (bounds, bool) {
+	if len(bbox) < 2 {
+		return bounds{}, false
+	}
+	b := emptyBounds()
+	for _, p := range bbox {
+		b = b.extendPosition(p)
+	}
+	return b, true
+}
+
+// geometryBounds computes the bounds of every Position in g.
+func geometryBounds(g any) (bounds, bool) {
+	b := emptyBounds()
+	eachPosition(g, func(p Position) {
+		b = b.extendPosition(p)
+	})
+	return b, !b.isEmpty()
+}
+
+// featureBounds prefers the Feature's Bbox, falling back to its Geometry.
+func featureBounds(f Feature) (bounds, bool) {
+	if b, ok := bboxBounds(f.Bbox); ok {
+		return b, true
+	}
+	return geometryBounds(f.geometry)
+}
+
+// eachPosition calls fn for every Position in g, which may be any geometry
+// type, a GeometryCollectionMember or a Feature.
+func eachPosition(g any, fn func(Position)) {
+	switch g := g.(type) {
+	case Point:
+		if len(g) > 0 {
+			fn(Position(g))
+		}
+	case MultiPoint:
+		for _, p := range g {
+			fn(p)
+		}
+	case LineString:
+		for _, p := range g {
+			fn(p)
+		}
+	case MultiLineString:
+		for _, ls := range g {
+			eachPosition(ls, fn)
+		}
+	case LinearRing:
+		for _, p := range g {
+			fn(p)
+		}
+	case Polygon:
+		for _, lr := range g {
+			eachPosition(lr, fn)
+		}
+	case MultiPolygon:
+		for _, pl := range g {
+			eachPosition(pl, fn)
+		}
+	case GeometryCollection:
+		for _, m := range g {
+			eachPosition(m.geometry, fn)
+		}
+	case GeometryCollectionMember:
+		eachPosition(g.geometry, fn)
+	case Feature:
+		eachPosition(g.geometry, fn)
+	}
+}
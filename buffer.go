@@ -0,0 +1,316 @@
+package joejson
+
+import "math"
+
+// BufferCap selects how Buffer ends open lines.
+type BufferCap int
+
+const (
+	// RoundCap ends lines with a half circle.
+	RoundCap BufferCap = iota
+	// FlatCap ends lines square at their end positions.
+	FlatCap
+	// SquareCap ends lines square, extended by the buffer distance.
+	SquareCap
+)
+
+// BufferJoin selects how Buffer joins segments at a corner.
+type BufferJoin int
+
+const (
+	// RoundJoin joins segments with an arc.
+	RoundJoin BufferJoin = iota
+	// MiterJoin extends both sides to a point, bevelled beyond MiterLimit.
+	MiterJoin
+	// BevelJoin cuts the corner with a straight line.
+	BevelJoin
+)
+
+const (
+	defaultBufferSegments   = 8
+	defaultBufferMiterLimit = 5.0
+)
+
+// BufferOptions configures Buffer.
+type BufferOptions struct {
+	// Segments is the number of segments approximating a quarter circle.
+	// Zero means 8.
+	Segments int
+	// Cap defaults to RoundCap. Points are buffered as lines of no length,
+	// so a FlatCap leaves them empty.
+	Cap BufferCap
+	// Join defaults to RoundJoin.
+	Join BufferJoin
+	// MiterLimit is how far a MiterJoin may reach from its corner, as a
+	// multiple of the distance, before it is bevelled. Zero means 5.
+	MiterLimit float64
+	// Geodesic treats positions as WGS84 longitude/latitude and the distance
+	// as metres, buffering in the UTM zone of the geometry's centre.
+	// Otherwise the distance is in the units of the positions.
+	Geodesic bool
+}
+
+// Buffer returns the area within distance of the Point.
+// A distance of zero or less leaves it empty.
+func (p Point) Buffer(distance float64, opts BufferOptions) MultiPolygon {
+	return buffer(p, distance, opts)
+}
+
+// Buffer returns the area within distance of any Position.
+func (g MultiPoint) Buffer(distance float64, opts BufferOptions) MultiPolygon {
+	return buffer(g, distance, opts)
+}
+
+// Buffer returns the area within distance of the LineString.
+// A distance of zero or less leaves it empty.
+func (g LineString) Buffer(distance float64, opts BufferOptions) MultiPolygon {
+	return buffer(g, distance, opts)
+}
+
+// Buffer returns the area within distance of any LineString.
+func (g MultiLineString) Buffer(distance float64, opts BufferOptions) MultiPolygon {
+	return buffer(g, distance, opts)
+}
+
+// Buffer returns the Polygon grown by distance, or shrunk if it is negative.
+// The result follows the RFC 7946 winding order.
+func (p Polygon) Buffer(distance float64, opts BufferOptions) MultiPolygon {
+	return buffer(p, distance, opts)
+}
+
+// Buffer returns every Polygon grown by distance, or shrunk if it is
+// negative, merged where they overlap.
+func (p MultiPolygon) Buffer(distance float64, opts BufferOptions) MultiPolygon {
+	return buffer(p, distance, opts)
+}
+
+// Buffer returns the buffers of every member merged where they overlap.
+func (g GeometryCollection) Buffer(distance float64, opts BufferOptions) MultiPolygon {
+	return buffer(g, distance, opts)
+}
+
+func buffer(g any, distance float64, opts BufferOptions) MultiPolygon {
+	if opts.Segments <= 0 {
+		opts.Segments = defaultBufferSegments
+	}
+	if opts.MiterLimit <= 0 {
+		opts.MiterLimit = defaultBufferMiterLimit
+	}
+	if !opts.Geodesic {
+		return bufferGeometry(g, distance, opts)
+	}
+
+	b, ok := geometryBounds(g)
+	if !ok {
+		return nil
+	}
+	zone, north := UTMZone(Position{(b.minX + b.maxX) / 2, (b.minY + b.maxY) / 2})
+	g = mapPositions(g, WGS84ToUTM(zone, north))
+	out := bufferGeometry(g, distance, opts)
+	if out == nil {
+		return nil
+	}
+	return mapPositions(out, UTMToWGS84(zone, north)).(MultiPolygon)
+}
+
+func bufferGeometry(g any, d float64, opts BufferOptions) MultiPolygon {
+	var parts []MultiPolygon
+	switch g := g.(type) {
+	case Point:
+		if len(g) > 0 && d > 0 {
+			parts = bufferLine(parts, []Position{Position(g)}, d, opts)
+		}
+	case MultiPoint:
+		for _, p := range g {
+			if d > 0 {
+				parts = bufferLine(parts, []Position{p}, d, opts)
+			}
+		}
+	case LineString:
+		if d > 0 {
+			parts = bufferLine(parts, g, d, opts)
+		}
+	case MultiLineString:
+		for _, ls := range g {
+			if d > 0 {
+				parts = bufferLine(parts, ls, d, opts)
+			}
+		}
+	case Polygon:
+		parts = append(parts, bufferPolygon(g, d, opts))
+	case MultiPolygon:
+		for _, p := range g {
+			parts = append(parts, bufferPolygon(p, d, opts))
+		}
+	case GeometryCollection:
+		for _, m := range g {
+			parts = append(parts, bufferGeometry(m.geometry, d, opts))
+		}
+	}
+	return unionAll(parts)
+}
+
+// bufferPolygon grows p by the buffer of its rings, or shrinks it by
+// cutting that buffer away when d is negative.
+func bufferPolygon(p Polygon, d float64, opts BufferOptions) MultiPolygon {
+	if len(p) == 0 || d == 0 {
+		return overlay(MultiPolygon{p}, nil, overlayUnion)
+	}
+	var parts []MultiPolygon
+	for _, ring := range p {
+		parts = bufferRing(parts, openRing(ring), math.Abs(d), opts)
+	}
+	if d < 0 {
+		return overlay(MultiPolygon{p}, unionAll(parts), overlayDifference)
+	}
+	return unionAll(append(parts, MultiPolygon{p}))
+}
+
+// bufferLine appends the pieces covering the buffer of an open line: a
+// rectangle along each segment, the joins between them and the end caps.
+func bufferLine(parts []MultiPolygon, ps []Position, d float64, opts BufferOptions) []MultiPolygon {
+	ps = dedupePositions(ps)
+	if len(ps) == 0 {
+		return parts
+	}
+	if len(ps) == 1 {
+		switch opts.Cap {
+		case RoundCap:
+			parts = append(parts, MultiPolygon{{circleRing(ps[0], d, opts.Segments)}})
+		case SquareCap:
+			parts = append(parts, MultiPolygon{bounds{ps[0].Lon() - d, ps[0].Lat() - d, ps[0].Lon() + d, ps[0].Lat() + d}.polygon()})
+		}
+		return parts
+	}
+
+	for i := 0; i+1 < len(ps); i++ {
+		parts = append(parts, segmentRect(ps[i], ps[i+1], d, 0))
+		if i > 0 {
+			parts = appendJoin(parts, ps[i-1], ps[i], ps[i+1], d, opts)
+		}
+	}
+	last := len(ps) - 1
+	switch opts.Cap {
+	case RoundCap:
+		parts = append(parts,
+			MultiPolygon{{circleRing(ps[0], d, opts.Segments)}},
+			MultiPolygon{{circleRing(ps[last], d, opts.Segments)}},
+		)
+	case SquareCap:
+		parts = append(parts,
+			segmentRect(ps[1], ps[0], d, d),
+			segmentRect(ps[last-1], ps[last], d, d),
+		)
+	}
+	return parts
+}
+
+// bufferRing appends the pieces covering the buffer of a closed ring,
+// joined at every position including the closing one.
+func bufferRing(parts []MultiPolygon, ring []Position, d float64, opts BufferOptions) []MultiPolygon {
+	ring = dedupePositions(ring)
+	for len(ring) > 1 && positionsEqual(ring[0], ring[len(ring)-1]) {
+		ring = ring[:len(ring)-1]
+	}
+	if len(ring) < 3 {
+		return bufferLine(parts, ring, d, BufferOptions{Segments: opts.Segments, Cap: RoundCap})
+	}
+	n := len(ring)
+	for i := range ring {
+		next := ring[(i+1)%n]
+		parts = append(parts, segmentRect(ring[i], next, d, 0))
+		parts = appendJoin(parts, ring[i], next, ring[(i+2)%n], d, opts)
+	}
+	return parts
+}
+
+// segmentRect returns the rectangle within d of segment a-b, extended by
+// ext past b.
+func segmentRect(a, b Position, d, ext float64) MultiPolygon {
+	u := unitVector(a, b)
+	l := Position{-u.Lat() * d, u.Lon() * d}
+	end := Position{b.Lon() + u.Lon()*ext, b.Lat() + u.Lat()*ext}
+	return MultiPolygon{{{
+		vecSub(a, l), vecSub(end, l), vecAdd(end, l), vecAdd(a, l), vecSub(a, l),
+	}}}
+}
+
+// appendJoin appends the piece filling the outside of the corner at b.
+func appendJoin(parts []MultiPolygon, a, b, c Position, d float64, opts BufferOptions) []MultiPolygon {
+	u1, u2 := unitVector(a, b), unitVector(b, c)
+	turn := vecCross(u1, u2)
+	if math.Abs(turn) <= overlayEps && vecDot(u1, u2) > 0 {
+		return parts
+	}
+	if opts.Join == RoundJoin {
+		return append(parts, MultiPolygon{{circleRing(b, d, opts.Segments)}})
+	}
+
+	// the outside of a left turn is on the right.
+	side := d
+	if turn > 0 {
+		side = -d
+	}
+	p1 := vecAdd(b, Position{-u1.Lat() * side, u1.Lon() * side})
+	p2 := vecAdd(b, Position{-u2.Lat() * side, u2.Lon() * side})
+	ring := LinearRing{b, p1, p2, b}
+	if opts.Join == MiterJoin {
+		// the miter point lies along the bisector of the two offsets at
+		// d / cos(half the turn).
+		bis := vecAdd(vecSub(p1, b), vecSub(p2, b))
+		if n := vecNorm(bis); n > 0 {
+			if reach := d * d * n / vecDot(vecSub(p1, b), bis); reach <= opts.MiterLimit*d {
+				ring = LinearRing{b, p1, vecAdd(b, vecScale(bis, reach/n)), p2, b}
+			}
+		}
+	}
+	return append(parts, MultiPolygon{{ring}})
+}
+
+// circleRing returns a counterclockwise ring of 4*segments positions
+// around c at radius r.
+func circleRing(c Position, r float64, segments int) LinearRing {
+	n := 4 * segments
+	ring := make(LinearRing, 0, n+1)
+	for i := 0; i < n; i++ {
+		a := 2 * math.Pi * float64(i) / float64(n)
+		ring = append(ring, Position{c.Lon() + r*math.Cos(a), c.Lat() + r*math.Sin(a)})
+	}
+	return append(ring, ring[0])
+}
+
+// unionAll merges parts pairwise so each overlay stays balanced.
+func unionAll(parts []MultiPolygon) MultiPolygon {
+	switch len(parts) {
+	case 0:
+		return nil
+	case 1:
+		return overlay(parts[0], nil, overlayUnion)
+	}
+	mid := len(parts) / 2
+	return overlay(unionAll(parts[:mid]), unionAll(parts[mid:]), overlayUnion)
+}
+
+// dedupePositions drops positions equal to the one before.
+func dedupePositions(ps []Position) []Position {
+	var out []Position
+	for _, p := range ps {
+		if len(out) == 0 || !positionsEqual(out[len(out)-1], p) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func unitVector(a, b Position) Position {
+	d := vecSub(b, a)
+	return vecScale(d, 1/vecNorm(d))
+}
+
+func vecAdd(a, b Position) Position {
+	return Position{a.Lon() + b.Lon(), a.Lat() + b.Lat()}
+}
+
+func vecScale(a Position, k float64) Position {
+	return Position{a.Lon() * k, a.Lat() * k}
+}
@@ -0,0 +1,153 @@
+package joejson
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuffer(t *testing.T) {
+	// a regular polygon of n sides inscribed in a circle of radius r.
+	circle := func(r float64, n int) float64 {
+		return float64(n) * r * r * math.Sin(2*math.Pi/float64(n)) / 2
+	}
+	testCases := map[string]struct {
+		g interface {
+			Buffer(float64, BufferOptions) MultiPolygon
+		}
+		d    float64
+		opts BufferOptions
+		want float64
+		n    int
+	}{
+		"Point": {
+			g: Point{1, 2}, d: 2,
+			want: circle(2, 32), n: 1,
+		},
+		"Point square cap": {
+			g: Point{1, 2}, d: 2, opts: BufferOptions{Cap: SquareCap},
+			want: 16, n: 1,
+		},
+		"Point flat cap": {
+			g: Point{1, 2}, d: 2, opts: BufferOptions{Cap: FlatCap},
+		},
+		"MultiPoint apart": {
+			g: MultiPoint{{0, 0}, {10, 0}}, d: 1, opts: BufferOptions{Segments: 2},
+			want: 2 * circle(1, 8), n: 2,
+		},
+		"LineString flat cap": {
+			g: LineString{{0, 0}, {10, 0}}, d: 1, opts: BufferOptions{Cap: FlatCap},
+			want: 20, n: 1,
+		},
+		"LineString square cap": {
+			g: LineString{{0, 0}, {10, 0}}, d: 1, opts: BufferOptions{Cap: SquareCap},
+			want: 24, n: 1,
+		},
+		"LineString round cap": {
+			g: LineString{{0, 0}, {10, 0}}, d: 1,
+			want: 20 + circle(1, 32), n: 1,
+		},
+		"LineString miter": {
+			g: LineString{{0, 0}, {10, 0}, {10, 10}}, d: 1, opts: BufferOptions{Cap: FlatCap, Join: MiterJoin},
+			want: 40, n: 1,
+		},
+		"LineString bevel": {
+			g: LineString{{0, 0}, {10, 0}, {10, 10}}, d: 1, opts: BufferOptions{Cap: FlatCap, Join: BevelJoin},
+			want: 39.5, n: 1,
+		},
+		"LineString miter limit": {
+			g:    LineString{{0, 0}, {10, 0}, {10, 10}},
+			d:    1,
+			opts: BufferOptions{Cap: FlatCap, Join: MiterJoin, MiterLimit: 1.2},
+			want: 39.5, n: 1,
+		},
+		"LineString back on itself": {
+			g: LineString{{0, 0}, {10, 0}, {5, 0}}, d: 1, opts: BufferOptions{Cap: FlatCap, Join: BevelJoin},
+			want: 20, n: 1,
+		},
+		"LineString negative": {
+			g: LineString{{0, 0}, {10, 0}}, d: -1,
+		},
+		"Polygon miter": {
+			g: square(0, 0, 10), d: 1, opts: BufferOptions{Join: MiterJoin},
+			want: 144, n: 1,
+		},
+		"Polygon bevel": {
+			g: square(0, 0, 10), d: 1, opts: BufferOptions{Join: BevelJoin},
+			want: 142, n: 1,
+		},
+		"Polygon shrunk": {
+			g: square(0, 0, 10), d: -2,
+			want: 36, n: 1,
+		},
+		"Polygon shrunk away": {
+			g: square(0, 0, 10), d: -6,
+		},
+		"Polygon hole closes": {
+			g: Polygon{
+				{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}},
+				{{4, 4}, {4, 6}, {6, 6}, {6, 4}, {4, 4}},
+			},
+			d: 1, opts: BufferOptions{Join: MiterJoin},
+			want: 144, n: 1,
+		},
+		"MultiPolygon merged": {
+			g: MultiPolygon{square(0, 0, 2), square(3, 0, 2)}, d: 1, opts: BufferOptions{Join: MiterJoin},
+			want: 28, n: 1,
+		},
+		"GeometryCollection": {
+			g:    GeometryCollection{{Point{0, 0}}, {LineString{{0, 0}, {10, 0}}}},
+			d:    1,
+			opts: BufferOptions{Cap: SquareCap},
+			want: 24, n: 1,
+		},
+	}
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got := tt.g.Buffer(tt.d, tt.opts)
+			assert.Len(t, got, tt.n)
+			assert.InDelta(t, tt.want, multiPolygonArea(t, got), 1e-9)
+		})
+	}
+
+	t.Run("buffer covers line", func(t *testing.T) {
+		var ls LineString
+		for i := 0; i < 20; i++ {
+			ls = append(ls, Position{float64(i), math.Sin(float64(i)) * 3})
+		}
+		got := ls.Buffer(0.5, BufferOptions{})
+		assert.Len(t, got, 1)
+		for _, p := range ls {
+			assert.True(t, ringContains(got[0][0], p))
+		}
+		for _, r := range got[0] {
+			for _, p := range r {
+				nearest := math.Inf(1)
+				for i := 0; i+1 < len(ls); i++ {
+					nearest = math.Min(nearest, segmentDistance(p, ls[i], ls[i+1]))
+				}
+				assert.InDelta(t, 0.5, nearest, 0.02)
+			}
+		}
+	})
+
+	t.Run("Geodesic", func(t *testing.T) {
+		p := Point{13.4, 52.5, 34}
+		got := p.Buffer(1000, BufferOptions{Geodesic: true})
+		assert.Len(t, got, 1)
+		for _, pos := range got[0][0] {
+			assert.Len(t, pos, 2)
+			assert.InDelta(t, 1000, haversine(Position(p), pos), 2)
+		}
+	})
+}
+
+// haversine is the great circle distance in metres on a sphere of the
+// WGS84 equatorial radius.
+func haversine(a, b Position) float64 {
+	lat1, lat2 := a.Lat()*math.Pi/180, b.Lat()*math.Pi/180
+	dLat, dLon := lat2-lat1, (b.Lon()-a.Lon())*math.Pi/180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * wgs84SemiMajorAxis * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,574 @@
+package joejson
+
+import (
+	"math"
+	"sort"
+)
+
+// ClipToBBox returns the Point if it lies within bbox, a pair of south-west
+// and north-east Positions as used by Feature.Bbox, reporting false otherwise.
+func (p Point) ClipToBBox(bbox []Position) (Point, bool) {
+	g, ok := clipToBBox(p, bbox)
+	out, _ := g.(Point)
+	return out, ok
+}
+
+// ClipToBBox keeps the Positions within bbox, reporting false if none are.
+func (g MultiPoint) ClipToBBox(bbox []Position) (MultiPoint, bool) {
+	c, ok := clipToBBox(g, bbox)
+	out, _ := c.(MultiPoint)
+	return out, ok
+}
+
+// ClipToBBox cuts the LineString to bbox with the Cohen-Sutherland algorithm.
+// The result is a LineString, or a MultiLineString if the line leaves and
+// re-enters bbox; false is reported if nothing remains.
+func (g LineString) ClipToBBox(bbox []Position) (GeometryCollectionMember, bool) {
+	c, ok := clipToBBox(g, bbox)
+	return GeometryCollectionMember{c}, ok
+}
+
+// ClipToBBox cuts every LineString to bbox, reporting false if nothing remains.
+func (g MultiLineString) ClipToBBox(bbox []Position) (MultiLineString, bool) {
+	c, ok := clipToBBox(g, bbox)
+	out, _ := c.(MultiLineString)
+	return out, ok
+}
+
+// ClipToBBox cuts the Polygon, holes included, to bbox. The result is a
+// Polygon, or a MultiPolygon if bbox splits it into several parts; false is
+// reported if nothing remains.
+func (p Polygon) ClipToBBox(bbox []Position) (GeometryCollectionMember, bool) {
+	c, ok := clipToBBox(p, bbox)
+	return GeometryCollectionMember{c}, ok
+}
+
+// ClipToBBox cuts every Polygon to bbox, reporting false if nothing remains.
+func (p MultiPolygon) ClipToBBox(bbox []Position) (MultiPolygon, bool) {
+	c, ok := clipToBBox(p, bbox)
+	out, _ := c.(MultiPolygon)
+	return out, ok
+}
+
+// ClipToBBox cuts every member to bbox, dropping those entirely outside,
+// and reports false if none remain.
+func (g GeometryCollection) ClipToBBox(bbox []Position) (GeometryCollection, bool) {
+	c, ok := clipToBBox(g, bbox)
+	out, _ := c.(GeometryCollection)
+	return out, ok
+}
+
+// ClipToBBox returns a copy of the Feature with its Geometry cut to bbox,
+// reporting false if it has no Geometry or none of it remains.
+// A Bbox is recomputed from the clipped Geometry.
+func (f Feature) ClipToBBox(bbox []Position) (Feature, bool) {
+	g, ok := clipToBBox(f.geometry, bbox)
+	if !ok {
+		return Feature{}, false
+	}
+	f.geometry = g
+	if f.Bbox != nil {
+		b, _ := geometryBounds(g)
+		f.Bbox = b.bbox()
+	}
+	return f, true
+}
+
+// ClipToBBox returns a copy of the FeatureCollection with every Feature cut
+// to bbox, leaving out Features without a Geometry or entirely outside.
+// A Bbox is recomputed from the clipped Features.
+func (f FeatureCollection) ClipToBBox(bbox []Position) FeatureCollection {
+	features := make([]Feature, 0, len(f.Features))
+	geometries := make(GeometryCollection, 0, len(f.Features))
+	for _, ft := range f.Features {
+		if c, ok := ft.ClipToBBox(bbox); ok {
+			features = append(features, c)
+			geometries = append(geometries, GeometryCollectionMember{c.geometry})
+		}
+	}
+	f.Features = features
+	if f.Bbox != nil {
+		f.Bbox = nil
+		if b, ok := geometryBounds(geometries); ok {
+			f.Bbox = b.bbox()
+		}
+	}
+	return f
+}
+
+func clipToBBox(g any, bbox []Position) (any, bool) {
+	b, ok := bboxBounds(bbox)
+	if !ok {
+		return nil, false
+	}
+	c := clipGeometry(g, b)
+	return c, c != nil
+}
+
+// clipGeometry cuts any geometry type to b, returning nil if nothing remains.
+func clipGeometry(g any, b bounds) any {
+	switch g := g.(type) {
+	case Point:
+		if len(g) > 0 && b.containsPosition(Position(g)) {
+			return g
+		}
+	case MultiPoint:
+		var out MultiPoint
+		for _, p := range g {
+			if b.containsPosition(p) {
+				out = append(out, p)
+			}
+		}
+		if len(out) > 0 {
+			return out
+		}
+	case LineString:
+		lines := clipLines([][]Position{g}, b)
+		switch len(lines) {
+		case 0:
+		case 1:
+			return LineString(lines[0])
+		default:
+			return lines
+		}
+	case MultiLineString:
+		parts := make([][]Position, len(g))
+		for i, ls := range g {
+			parts[i] = ls
+		}
+		if lines := clipLines(parts, b); len(lines) > 0 {
+			return lines
+		}
+	case Polygon:
+		polygons := clipPolygon(g, b)
+		switch len(polygons) {
+		case 0:
+		case 1:
+			return polygons[0]
+		default:
+			return MultiPolygon(polygons)
+		}
+	case MultiPolygon:
+		var out MultiPolygon
+		for _, p := range g {
+			out = append(out, clipPolygon(p, b)...)
+		}
+		if len(out) > 0 {
+			return out
+		}
+	case GeometryCollection:
+		var out GeometryCollection
+		for _, m := range g {
+			if c := clipGeometry(m.geometry, b); c != nil {
+				out = append(out, GeometryCollectionMember{c})
+			}
+		}
+		if len(out) > 0 {
+			return out
+		}
+	}
+	return nil
+}
+
+// clipLines clips each line to b, dropping parts shorter than a segment.
+func clipLines(lines [][]Position, b bounds) MultiLineString {
+	var out MultiLineString
+	for _, ls := range lines {
+		for _, part := range clipLine(ls, b) {
+			if len(part) >= 2 {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+// Cohen-Sutherland outcodes.
+const (
+	outLeft = 1 << iota
+	outRight
+	outBottom
+	outTop
+)
+
+func outcode(p Position, b bounds) int {
+	code := 0
+	switch {
+	case p.Lon() < b.minX:
+		code |= outLeft
+	case p.Lon() > b.maxX:
+		code |= outRight
+	}
+	switch {
+	case p.Lat() < b.minY:
+		code |= outBottom
+	case p.Lat() > b.maxY:
+		code |= outTop
+	}
+	return code
+}
+
+// clipSegment clips segment a-c to b with the Cohen-Sutherland algorithm,
+// reporting false if it lies entirely outside.
+func clipSegment(a, c Position, b bounds) (Position, Position, bool) {
+	codeA, codeC := outcode(a, b), outcode(c, b)
+	for {
+		switch {
+		case codeA|codeC == 0:
+			return a, c, true
+		case codeA&codeC != 0:
+			return nil, nil, false
+		}
+
+		code := codeA
+		if code == 0 {
+			code = codeC
+		}
+		var t float64
+		dx, dy := c.Lon()-a.Lon(), c.Lat()-a.Lat()
+		switch {
+		case code&outTop != 0:
+			t = (b.maxY - a.Lat()) / dy
+		case code&outBottom != 0:
+			t = (b.minY - a.Lat()) / dy
+		case code&outRight != 0:
+			t = (b.maxX - a.Lon()) / dx
+		default:
+			t = (b.minX - a.Lon()) / dx
+		}
+		p := lerpPosition(a, c, t)
+		// snap onto the edge to avoid floating point drift.
+		switch {
+		case code&outTop != 0:
+			p[1] = b.maxY
+		case code&outBottom != 0:
+			p[1] = b.minY
+		case code&outRight != 0:
+			p[0] = b.maxX
+		default:
+			p[0] = b.minX
+		}
+
+		if code == codeA {
+			a, codeA = p, outcode(p, b)
+		} else {
+			c, codeC = p, outcode(p, b)
+		}
+	}
+}
+
+// clipLine clips a line to b, splitting it wherever it leaves and re-enters.
+func clipLine(ps []Position, b bounds) [][]Position {
+	if len(ps) == 1 {
+		if b.containsPosition(ps[0]) {
+			return [][]Position{ps}
+		}
+		return nil
+	}
+
+	var out [][]Position
+	var cur []Position
+	for i := 0; i+1 < len(ps); i++ {
+		a, c, ok := clipSegment(ps[i], ps[i+1], b)
+		if !ok {
+			if len(cur) > 0 {
+				out, cur = append(out, cur), nil
+			}
+			continue
+		}
+		if len(cur) > 0 && !positionsEqual(cur[len(cur)-1], a) {
+			out, cur = append(out, cur), nil
+		}
+		if len(cur) == 0 {
+			cur = append(cur, a)
+		}
+		cur = append(cur, c)
+		if !positionsEqual(c, ps[i+1]) {
+			out, cur = append(out, cur), nil
+		}
+	}
+	if len(cur) > 0 {
+		out = append(out, cur)
+	}
+	return out
+}
+
+// clipRing clips a ring to b with the Sutherland-Hodgman algorithm. The
+// result is closed, or nil if nothing remains.
+func clipRing(ring []Position, b bounds) []Position {
+	pts := openRing(ring)
+	edges := []struct {
+		inside    func(Position) bool
+		intersect func(a, c Position) Position
+	}{
+		{
+			func(p Position) bool { return p.Lon() >= b.minX },
+			func(a, c Position) Position { return lerpAt(a, c, 0, b.minX) },
+		},
+		{
+			func(p Position) bool { return p.Lon() <= b.maxX },
+			func(a, c Position) Position { return lerpAt(a, c, 0, b.maxX) },
+		},
+		{
+			func(p Position) bool { return p.Lat() >= b.minY },
+			func(a, c Position) Position { return lerpAt(a, c, 1, b.minY) },
+		},
+		{
+			func(p Position) bool { return p.Lat() <= b.maxY },
+			func(a, c Position) Position { return lerpAt(a, c, 1, b.maxY) },
+		},
+	}
+
+	for _, e := range edges {
+		if len(pts) == 0 {
+			return nil
+		}
+		var out []Position
+		prev := pts[len(pts)-1]
+		for _, p := range pts {
+			switch {
+			case e.inside(p) && !e.inside(prev):
+				out = append(out, e.intersect(prev, p), p)
+			case e.inside(p):
+				out = append(out, p)
+			case e.inside(prev):
+				out = append(out, e.intersect(prev, p))
+			}
+			prev = p
+		}
+		pts = out
+	}
+
+	if len(pts) < 3 {
+		return nil
+	}
+	return append(pts, pts[0])
+}
+
+// lerpAt interpolates along a-c to where axis reaches v.
+func lerpAt(a, c Position, axis int, v float64) Position {
+	t := (v - a[axis]) / (c[axis] - a[axis])
+	p := lerpPosition(a, c, t)
+	p[axis] = v
+	return p
+}
+
+// lerpPosition interpolates every coordinate a and c have in common.
+func lerpPosition(a, c Position, t float64) Position {
+	n := minInt(len(a), len(c))
+	if n < 2 {
+		n = 2
+	}
+	out := make(Position, n)
+	for i := range out {
+		av, cv := positionAt(a, i), positionAt(c, i)
+		out[i] = av + t*(cv-av)
+	}
+	return out
+}
+
+func positionAt(p Position, i int) float64 {
+	if i < len(p) {
+		return p[i]
+	}
+	return 0
+}
+
+// clipPiece is a part of a ring inside the clip bounds, entering and leaving
+// at perimeter positions start and end.
+type clipPiece struct {
+	ps         []Position
+	start, end float64
+}
+
+// clipPolygon clips p to b. Rings crossing b's edges are cut into pieces
+// which are joined back up along the edges, Weiler-Atherton style, so that
+// a polygon split by the clip yields one Polygon per part.
+func clipPolygon(p Polygon, b bounds) []Polygon {
+	if b.area() == 0 || len(p) == 0 {
+		return nil
+	}
+
+	var exteriors, holes [][]Position
+	var pieces []clipPiece
+	// crossing are the rings with positions outside b.
+	var crossing [][]Position
+	for i, lr := range p {
+		ring := openRing(lr)
+		if len(ring) < 3 {
+			if i == 0 {
+				return nil
+			}
+			continue
+		}
+		// exterior rings counterclockwise and holes clockwise keep the
+		// polygon's inside on the left of every piece.
+		if (ringArea(ring) > 0) != (i == 0) {
+			ring = reversePositions(ring)
+		}
+
+		start := -1
+		for j, pos := range ring {
+			if outcode(pos, b) != 0 {
+				start = j
+				break
+			}
+		}
+		if start < 0 {
+			closed := append(append([]Position(nil), ring...), ring[0])
+			if i == 0 {
+				exteriors = append(exteriors, closed)
+			} else {
+				holes = append(holes, closed)
+			}
+			continue
+		}
+
+		// starting outside makes every piece begin and end on an edge.
+		rotated := make([]Position, 0, len(ring)+1)
+		rotated = append(append(append(rotated, ring[start:]...), ring[:start]...), ring[start])
+		crossing = append(crossing, rotated)
+		for _, part := range clipLine(rotated, b) {
+			if alongEdge(part, b) {
+				continue
+			}
+			pieces = append(pieces, clipPiece{
+				ps:    part,
+				start: b.perimeterAt(part[0]),
+				end:   b.perimeterAt(part[len(part)-1]),
+			})
+		}
+	}
+
+	exteriors = append(exteriors, stitchPieces(pieces, b)...)
+	if len(pieces) == 0 && len(exteriors) == 0 && len(crossing) > 0 {
+		// no ring crosses b, so b is either wholly inside or outside.
+		centre := Position{(b.minX + b.maxX) / 2, (b.minY + b.maxY) / 2}
+		inside := ringContains(crossing[0], centre)
+		for _, r := range crossing[1:] {
+			inside = inside && !ringContains(r, centre)
+		}
+		if inside {
+			exteriors = append(exteriors, b.polygon()[0])
+		}
+	}
+
+	var out []Polygon
+	for _, ext := range exteriors {
+		if !collinearOnAxis(ext) {
+			out = append(out, Polygon{ext})
+		}
+	}
+	for _, h := range holes {
+		for i := range out {
+			if ringContains(out[i][0], h[0]) {
+				out[i] = append(out[i], h)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// collinearOnAxis reports whether ps share a longitude or a latitude, as
+// pieces running along a clip edge do.
+func collinearOnAxis(ps []Position) bool {
+	sameX, sameY := true, true
+	for _, p := range ps[1:] {
+		sameX = sameX && p.Lon() == ps[0].Lon()
+		sameY = sameY && p.Lat() == ps[0].Lat()
+	}
+	return sameX || sameY
+}
+
+// alongEdge reports whether ps all lie on one of b's edges.
+func alongEdge(ps []Position, b bounds) bool {
+	if !collinearOnAxis(ps) {
+		return false
+	}
+	x, y := ps[0].Lon(), ps[0].Lat()
+	sameX := ps[len(ps)-1].Lon() == x
+	sameY := ps[len(ps)-1].Lat() == y
+	return sameX && (x == b.minX || x == b.maxX) || sameY && (y == b.minY || y == b.maxY)
+}
+
+// perimeterAt is how far p, which lies on b's edge, is around b
+// counterclockwise from its south-west corner.
+func (b bounds) perimeterAt(p Position) float64 {
+	w, h := b.maxX-b.minX, b.maxY-b.minY
+	switch {
+	case p.Lat() == b.minY && p.Lon() < b.maxX:
+		return p.Lon() - b.minX
+	case p.Lon() == b.maxX && p.Lat() < b.maxY:
+		return w + p.Lat() - b.minY
+	case p.Lat() == b.maxY && p.Lon() > b.minX:
+		return w + h + b.maxX - p.Lon()
+	default:
+		return 2*w + h + b.maxY - p.Lat()
+	}
+}
+
+// stitchPieces joins pieces into closed rings: from where a piece leaves b
+// the ring follows the edge counterclockwise, through any corners, to the
+// nearest piece entering b.
+func stitchPieces(pieces []clipPiece, b bounds) [][]Position {
+	w, h := b.maxX-b.minX, b.maxY-b.minY
+	perimeter := 2 * (w + h)
+	corners := []clipPiece{
+		{ps: []Position{{b.minX, b.minY}}, start: 0},
+		{ps: []Position{{b.maxX, b.minY}}, start: w},
+		{ps: []Position{{b.maxX, b.maxY}}, start: w + h},
+		{ps: []Position{{b.minX, b.maxY}}, start: 2*w + h},
+	}
+	dist := func(from, to float64) float64 {
+		if d := to - from; d >= 0 {
+			return d
+		}
+		return to - from + perimeter
+	}
+
+	used := make([]bool, len(pieces))
+	var rings [][]Position
+	for first := range pieces {
+		if used[first] {
+			continue
+		}
+		used[first] = true
+		ring := append([]Position(nil), pieces[first].ps...)
+		for cur := first; ; {
+			end := pieces[cur].end
+			next, best := -1, math.Inf(1)
+			for j, pc := range pieces {
+				if used[j] && j != first {
+					continue
+				}
+				if d := dist(end, pc.start); d < best {
+					next, best = j, d
+				}
+			}
+
+			sort.Slice(corners, func(i, j int) bool {
+				return dist(end, corners[i].start) < dist(end, corners[j].start)
+			})
+			for _, c := range corners {
+				if d := dist(end, c.start); d > 0 && d < best {
+					ring = append(ring, c.ps[0])
+				}
+			}
+
+			if next == first {
+				ring = append(ring, ring[0])
+				break
+			}
+			used[next] = true
+			ps := pieces[next].ps
+			if positionsEqual(ring[len(ring)-1], ps[0]) {
+				ps = ps[1:]
+			}
+			ring = append(ring, ps...)
+			cur = next
+		}
+		rings = append(rings, ring)
+	}
+	return rings
+}
@@ -0,0 +1,198 @@
+package joejson
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClipToBBox(t *testing.T) {
+	bbox := []Position{{0, 0}, {10, 10}}
+	testCases := map[string]struct {
+		g    any
+		want any
+	}{
+		"Point inside":  {Point{1, 2}, Point{1, 2}},
+		"Point outside": {Point{11, 2}, nil},
+		"MultiPoint":    {MultiPoint{{1, 2}, {-1, 2}, {3, 4}}, MultiPoint{{1, 2}, {3, 4}}},
+		"LineString inside": {
+			LineString{{1, 1}, {2, 2}},
+			LineString{{1, 1}, {2, 2}},
+		},
+		"LineString crossing": {
+			LineString{{-5, 5}, {5, 5, 1}},
+			LineString{{0, 5}, {5, 5, 1}},
+		},
+		"LineString split": {
+			LineString{{2, 5}, {2, 15}, {8, 15}, {8, 5}},
+			MultiLineString{{{2, 5}, {2, 10}}, {{8, 10}, {8, 5}}},
+		},
+		"LineString outside": {LineString{{20, 20}, {30, 30}}, nil},
+		"MultiLineString": {
+			MultiLineString{{{-5, 5}, {5, 5}}, {{20, 20}, {30, 30}}},
+			MultiLineString{{{0, 5}, {5, 5}}},
+		},
+		"Polygon inside": {
+			Polygon{{{1, 1}, {2, 1}, {2, 2}, {1, 1}}},
+			Polygon{{{1, 1}, {2, 1}, {2, 2}, {1, 1}}},
+		},
+		"Polygon over corner": {
+			Polygon{{{-5, -5}, {5, -5}, {5, 5}, {-5, 5}, {-5, -5}}},
+			Polygon{{{5, 0}, {5, 5}, {0, 5}, {0, 0}, {5, 0}}},
+		},
+		"Polygon clockwise": {
+			Polygon{{{-5, -5}, {-5, 5}, {5, 5}, {5, -5}, {-5, -5}}},
+			Polygon{{{5, 0}, {5, 5}, {0, 5}, {0, 0}, {5, 0}}},
+		},
+		"Polygon edge across bbox": {
+			Polygon{{{-10, -10}, {10, -10}, {10, 6}, {-10, 6}, {-10, -10}}},
+			Polygon{{{10, 0}, {10, 6}, {0, 6}, {0, 0}, {10, 0}}},
+		},
+		"Polygon strip across bbox": {
+			Polygon{{{3, -10}, {7, -10}, {7, 20}, {3, 20}, {3, -10}}},
+			Polygon{{{7, 0}, {7, 10}, {3, 10}, {3, 0}, {7, 0}}},
+		},
+		"Polygon on bbox edge": {
+			Polygon{{{0, -5}, {5, -5}, {5, 5}, {0, 5}, {0, -5}}},
+			Polygon{{{5, 0}, {5, 5}, {0, 5}, {0, 0}, {5, 0}}},
+		},
+		"Polygon split": {
+			Polygon{{{2, 5}, {4, 5}, {4, 12}, {6, 12}, {6, 5}, {8, 5}, {8, 15}, {2, 15}, {2, 5}}},
+			MultiPolygon{
+				{{{6, 10}, {6, 5}, {8, 5}, {8, 10}, {6, 10}}},
+				{{{2, 10}, {2, 5}, {4, 5}, {4, 10}, {2, 10}}},
+			},
+		},
+		"Polygon containing bbox": {
+			Polygon{
+				{{-5, -5}, {15, -5}, {15, 15}, {-5, 15}, {-5, -5}},
+				{{4, 4}, {4, 6}, {6, 6}, {6, 4}, {4, 4}},
+			},
+			Polygon{
+				{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}},
+				{{4, 4}, {4, 6}, {6, 6}, {6, 4}, {4, 4}},
+			},
+		},
+		"bbox inside hole": {
+			Polygon{
+				{{-50, -50}, {50, -50}, {50, 50}, {-50, 50}, {-50, -50}},
+				{{-20, -20}, {-20, 20}, {20, 20}, {20, -20}, {-20, -20}},
+			},
+			nil,
+		},
+		"Polygon outside": {
+			Polygon{{{20, 20}, {30, 20}, {30, 30}, {20, 20}}},
+			nil,
+		},
+		"Polygon around bbox": {
+			Polygon{{{-5, -5}, {15, -5}, {15, 5}, {12, 5}, {12, -2}, {-2, -2}, {-2, 12}, {12, 12}, {12, 5}, {15, 5}, {15, 15}, {-5, 15}, {-5, -5}}},
+			nil,
+		},
+		"MultiPolygon": {
+			MultiPolygon{
+				{{{-5, -5}, {5, -5}, {5, 5}, {-5, 5}, {-5, -5}}},
+				{{{20, 20}, {30, 20}, {30, 30}, {20, 20}}},
+			},
+			MultiPolygon{{{{5, 0}, {5, 5}, {0, 5}, {0, 0}, {5, 0}}}},
+		},
+		"GeometryCollection": {
+			GeometryCollection{{Point{1, 1}}, {Point{20, 20}}},
+			GeometryCollection{{Point{1, 1}}},
+		},
+	}
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got := clipGeometry(tt.g, bounds{0, 0, 10, 10})
+			assert.Equal(t, tt.want, got)
+
+			f, ok := Feature{}.WithGeometryCollection(GeometryCollection{{tt.g}}).ClipToBBox(bbox)
+			assert.Equal(t, tt.want != nil, ok)
+			if ok {
+				assert.Equal(t, GeometryCollection{{tt.want}}, f.geometry)
+			}
+		})
+	}
+
+	t.Run("straight edges across a tile", func(t *testing.T) {
+		tile := bounds{0, 0, 1, 1}
+		got := clipPolygon(Polygon{{{-10, -10}, {10, -10}, {10, 0.6}, {-10, 0.6}, {-10, -10}}}, tile)
+		assert.Equal(t, []Polygon{{{{1, 0.6}, {0, 0.6}, {0, 0}, {1, 0}, {1, 0.6}}}}, got)
+		got = clipPolygon(Polygon{{{0.3, -1}, {0.7, -1}, {0.7, 2}, {0.3, 2}, {0.3, -1}}}, tile)
+		assert.Equal(t, []Polygon{{{{0.7, 0}, {0.7, 1}, {0.3, 1}, {0.3, 0}, {0.7, 0}}}}, got)
+	})
+
+	t.Run("Polygon with hole cut", func(t *testing.T) {
+		p := Polygon{
+			{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}},
+			{{4, 4}, {4, 6}, {6, 6}, {6, 4}, {4, 4}},
+		}
+		got, ok := p.ClipToBBox([]Position{{5, -1}, {20, 20}})
+		assert.True(t, ok)
+		pl, ok := got.AsPolygon()
+		assert.True(t, ok)
+		assert.Len(t, pl, 1)
+		assert.Equal(t, 48.0, ringArea(pl[0]))
+	})
+
+	t.Run("typed results", func(t *testing.T) {
+		p, ok := Point{1, 2}.ClipToBBox(bbox)
+		assert.True(t, ok)
+		assert.Equal(t, Point{1, 2}, p)
+
+		_, ok = MultiPoint{{11, 2}}.ClipToBBox(bbox)
+		assert.False(t, ok)
+
+		ls, ok := LineString{{2, 5}, {2, 15}, {8, 15}, {8, 5}}.ClipToBBox(bbox)
+		assert.True(t, ok)
+		assert.Equal(t, GeometryTypeMultiLineString, ls.Type())
+
+		_, ok = Point{1, 2}.ClipToBBox(nil)
+		assert.False(t, ok)
+	})
+
+	t.Run("FeatureCollection", func(t *testing.T) {
+		fc := FeatureCollection{
+			Bbox: []Position{{-5, -5}, {30, 30}},
+			Features: []Feature{
+				Feature{ID: "a", Bbox: []Position{{-5, 5}, {5, 5}}}.WithLineString(LineString{{-5, 5}, {5, 5}}),
+				Feature{ID: "b"}.WithPoint(Point{20, 20}),
+				{ID: "c"},
+				Feature{ID: "d"}.WithPoint(Point{1, 2}),
+			},
+		}
+		got := fc.ClipToBBox(bbox)
+		assert.Equal(t, FeatureCollection{
+			Bbox: []Position{{0, 2}, {5, 5}},
+			Features: []Feature{
+				Feature{ID: "a", Bbox: []Position{{0, 5}, {5, 5}}}.WithLineString(LineString{{0, 5}, {5, 5}}),
+				Feature{ID: "d"}.WithPoint(Point{1, 2}),
+			},
+		}, got)
+	})
+}
+
+func TestClipPolygonArea(t *testing.T) {
+	// a star clipped by boxes of several sizes keeps only positive area
+	// parts which together never exceed the box.
+	var star LinearRing
+	for i := 0; i <= 10; i++ {
+		r := 10.0
+		if i%2 == 1 {
+			r = 4
+		}
+		a := float64(i) * math.Pi / 5
+		star = append(star, Position{r * math.Cos(a), r * math.Sin(a)})
+	}
+	star[10] = star[0]
+	for _, b := range []bounds{{-3, -3, 3, 3}, {0, -20, 20, 20}, {5, -1, 20, 1}, {-20, 3, 20, 20}} {
+		var total float64
+		for _, p := range clipPolygon(Polygon{star}, b) {
+			area := ringArea(p[0])
+			assert.Greater(t, area, 0.0)
+			total += area
+		}
+		assert.LessOrEqual(t, total, b.area())
+		assert.Greater(t, total, 0.0)
+	}
+}
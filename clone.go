@@ -0,0 +1,108 @@
+package joejson
+
+import "reflect"
+
+// Clone returns a deep copy of the Point.
+func (p Point) Clone() Point {
+	return p.Project(clonePosition)
+}
+
+// Clone returns a deep copy of the MultiPoint.
+func (g MultiPoint) Clone() MultiPoint {
+	return g.Project(clonePosition)
+}
+
+// Clone returns a deep copy of the LineString.
+func (g LineString) Clone() LineString {
+	return g.Project(clonePosition)
+}
+
+// Clone returns a deep copy of the MultiLineString.
+func (g MultiLineString) Clone() MultiLineString {
+	return g.Project(clonePosition)
+}
+
+// Clone returns a deep copy of the Polygon.
+func (p Polygon) Clone() Polygon {
+	return p.Project(clonePosition)
+}
+
+// Clone returns a deep copy of the MultiPolygon.
+func (p MultiPolygon) Clone() MultiPolygon {
+	return p.Project(clonePosition)
+}
+
+// Clone returns a deep copy of the GeometryCollection.
+func (g GeometryCollection) Clone() GeometryCollection {
+	return g.Project(clonePosition)
+}
+
+// Clone returns a deep copy of the Feature, including the maps and slices in
+// its Properties.
+func (f Feature) Clone() Feature {
+	f.geometry = mapPositions(f.geometry, clonePosition)
+	f.Bbox = mapPositionSlice(f.Bbox, clonePosition)
+	if f.Properties != nil {
+		f.Properties = cloneValue(f.Properties).(map[string]any)
+	}
+	return f
+}
+
+// Clone returns a deep copy of the FeatureCollection.
+func (f FeatureCollection) Clone() FeatureCollection {
+	if f.Features != nil {
+		features := make([]Feature, len(f.Features))
+		for i, ft := range f.Features {
+			features[i] = ft.Clone()
+		}
+		f.Features = features
+	}
+	f.Bbox = mapPositionSlice(f.Bbox, clonePosition)
+	return f
+}
+
+func clonePosition(p Position) Position {
+	if p == nil {
+		return nil
+	}
+	return append(Position{}, p...)
+}
+
+// cloneValue deep copies maps and slices of any type, including those nested
+// in them. Other values, such as pointers and structs, are shared.
+func cloneValue(v any) any {
+	if v == nil {
+		return nil
+	}
+	return cloneReflectValue(reflect.ValueOf(v)).Interface()
+}
+
+func cloneReflectValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for it := v.MapRange(); it.Next(); {
+			out.SetMapIndex(it.Key(), cloneReflectValue(it.Value()))
+		}
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(cloneReflectValue(v.Index(i)))
+		}
+		return out
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		return cloneReflectValue(v.Elem())
+	default:
+		return v
+	}
+}
@@ -0,0 +1,118 @@
+package joejson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClone(t *testing.T) {
+	testCases := map[string]struct {
+		original func() any
+		clone    func(any) any
+		mutate   func(any)
+	}{
+		"Point": {
+			original: func() any { return Point{1, 2} },
+			clone:    func(g any) any { return g.(Point).Clone() },
+			mutate:   func(g any) { g.(Point)[0] = 99 },
+		},
+		"MultiPoint": {
+			original: func() any { return MultiPoint{{1, 2}} },
+			clone:    func(g any) any { return g.(MultiPoint).Clone() },
+			mutate:   func(g any) { g.(MultiPoint)[0][0] = 99 },
+		},
+		"LineString": {
+			original: func() any { return LineString{{1, 2}, {3, 4}} },
+			clone:    func(g any) any { return g.(LineString).Clone() },
+			mutate:   func(g any) { g.(LineString)[1][1] = 99 },
+		},
+		"MultiLineString": {
+			original: func() any { return MultiLineString{{{1, 2}, {3, 4}}} },
+			clone:    func(g any) any { return g.(MultiLineString).Clone() },
+			mutate:   func(g any) { g.(MultiLineString)[0][1][1] = 99 },
+		},
+		"Polygon": {
+			original: func() any { return Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}} },
+			clone:    func(g any) any { return g.(Polygon).Clone() },
+			mutate:   func(g any) { g.(Polygon)[0][2][0] = 99 },
+		},
+		"MultiPolygon": {
+			original: func() any { return MultiPolygon{{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}}} },
+			clone:    func(g any) any { return g.(MultiPolygon).Clone() },
+			mutate:   func(g any) { g.(MultiPolygon)[0][0][2][0] = 99 },
+		},
+		"GeometryCollection": {
+			original: func() any {
+				return GeometryCollection{}.AppendPoint(Point{1, 2}).AppendLineString(LineString{{1, 2}, {3, 4}})
+			},
+			clone: func(g any) any { return g.(GeometryCollection).Clone() },
+			mutate: func(g any) {
+				ls, _ := g.(GeometryCollection)[1].AsLineString()
+				ls[0][0] = 99
+			},
+		},
+		"Feature": {
+			original: func() any {
+				return Feature{
+					ID:         "a",
+					Properties: map[string]any{"tags": []any{"x", map[string]any{"k": "v"}}},
+					Bbox:       []Position{{0, 0}, {1, 1}},
+				}.WithPoint(Point{1, 2})
+			},
+			clone: func(g any) any { return g.(Feature).Clone() },
+			mutate: func(g any) {
+				ft := g.(Feature)
+				pt, _ := ft.AsPoint()
+				pt[0] = 99
+				ft.Bbox[0][0] = 99
+				ft.Properties["tags"].([]any)[1].(map[string]any)["k"] = "changed"
+				ft.Properties["new"] = true
+			},
+		},
+		"Feature with typed Properties": {
+			original: func() any {
+				return Feature{Properties: map[string]any{
+					"names": []string{"a", "b"},
+					"attrs": map[string]string{"k": "v"},
+					"rows":  [][]int{{1, 2}},
+					"ptr":   &struct{}{},
+				}}
+			},
+			clone: func(g any) any { return g.(Feature).Clone() },
+			mutate: func(g any) {
+				ft := g.(Feature)
+				ft.Properties["names"].([]string)[0] = "changed"
+				ft.Properties["attrs"].(map[string]string)["k"] = "changed"
+				ft.Properties["rows"].([][]int)[0][1] = 99
+			},
+		},
+		"FeatureCollection": {
+			original: func() any {
+				return FeatureCollection{
+					Features: []Feature{Feature{Properties: map[string]any{"a": 1.0}}.WithLineString(LineString{{1, 2}, {3, 4}})},
+					Bbox:     []Position{{1, 2}, {3, 4}},
+				}
+			},
+			clone: func(g any) any { return g.(FeatureCollection).Clone() },
+			mutate: func(g any) {
+				fc := g.(FeatureCollection)
+				ls, _ := fc.Features[0].AsLineString()
+				ls[0][0] = 99
+				fc.Features[0].Properties["a"] = 2.0
+				fc.Bbox[1][1] = 99
+			},
+		},
+	}
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			original := tt.original()
+			clone := tt.clone(original)
+			assert.Equal(t, original, clone)
+
+			tt.mutate(clone)
+			assert.Equal(t, tt.original(), original)
+			assert.NotEqual(t, original, clone)
+		})
+	}
+}
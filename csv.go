@@ -0,0 +1,292 @@
+package joejson
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// CSVOptions configures CSV conversion.
+type CSVOptions struct {
+	// LonColumn and LatColumn name the coordinate columns of Point
+	// geometries, defaulting to "lon" and "lat".
+	LonColumn, LatColumn string
+	// ElevationColumn optionally names a column of Point elevations.
+	ElevationColumn string
+	// WKTColumn names a column of Well-Known Text geometries of any type,
+	// used instead of the coordinate columns when set.
+	WKTColumn string
+	// IDColumn optionally names the column holding Feature IDs.
+	IDColumn string
+	// InferTypes converts values that parse as finite numbers to float64 and
+	// "true" or "false" to bool, and empty values to nil. Otherwise every
+	// value is a string.
+	InferTypes bool
+	// Comma is the field delimiter, ',' if zero.
+	Comma rune
+}
+
+func (o CSVOptions) geometryColumns() []string {
+	if o.WKTColumn != "" {
+		return []string{o.WKTColumn}
+	}
+	cols := []string{o.LonColumn, o.LatColumn}
+	if cols[0] == "" {
+		cols[0] = "lon"
+	}
+	if cols[1] == "" {
+		cols[1] = "lat"
+	}
+	if o.ElevationColumn != "" {
+		cols = append(cols, o.ElevationColumn)
+	}
+	return cols
+}
+
+// UnmarshalCSV decodes CSV with a header row into a FeatureCollection, one
+// Feature per record. Geometry comes from the WKT column or the coordinate
+// columns of opts; records with empty geometry cells have no geometry.
+// Every other column, bar the ID column, becomes a Property.
+func UnmarshalCSV(b []byte, opts CSVOptions) (FeatureCollection, error) {
+	r := csv.NewReader(bytes.NewReader(b))
+	if opts.Comma != 0 {
+		r.Comma = opts.Comma
+	}
+	records, err := r.ReadAll()
+	if err != nil {
+		return FeatureCollection{}, fmt.Errorf("csv: %w", err)
+	}
+	if len(records) == 0 {
+		return FeatureCollection{}, errors.New("csv: missing header")
+	}
+
+	header := records[0]
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[name] = i
+	}
+	geomCols := opts.geometryColumns()
+	geomIdx := make([]int, len(geomCols))
+	for i, name := range geomCols {
+		j, ok := index[name]
+		if !ok {
+			return FeatureCollection{}, fmt.Errorf("csv: missing column %q", name)
+		}
+		geomIdx[i] = j
+	}
+	idIdx := -1
+	if opts.IDColumn != "" {
+		j, ok := index[opts.IDColumn]
+		if !ok {
+			return FeatureCollection{}, fmt.Errorf("csv: missing column %q", opts.IDColumn)
+		}
+		idIdx = j
+	}
+	special := map[int]bool{idIdx: true}
+	for _, j := range geomIdx {
+		special[j] = true
+	}
+
+	out := FeatureCollection{Features: make([]Feature, 0, len(records)-1)}
+	for n, rec := range records[1:] {
+		line := n + 2
+		var ft Feature
+		if opts.WKTColumn != "" {
+			ft.geometry, err = csvWKT(rec[geomIdx[0]])
+		} else {
+			ft.geometry, err = csvPoint(rec, geomIdx)
+		}
+		if err != nil {
+			return FeatureCollection{}, fmt.Errorf("csv: line %d: %w", line, err)
+		}
+
+		if idIdx >= 0 && rec[idIdx] != "" {
+			ft.ID = rec[idIdx]
+			if v, ok := csvValue(rec[idIdx], opts.InferTypes).(float64); ok {
+				ft.ID = v
+			}
+		}
+
+		ft.Properties = map[string]any{}
+		for i, name := range header {
+			if !special[i] {
+				ft.Properties[name] = csvValue(rec[i], opts.InferTypes)
+			}
+		}
+		out.Features = append(out.Features, ft)
+	}
+	return out, nil
+}
+
+// MarshalCSV flattens the FeatureCollection into CSV with a header row: the
+// geometry columns of opts, the ID column if set, then every Property key in
+// sorted order. Geometries are written as Well-Known Text when
+// opts.WKTColumn is set, otherwise they must be Points whose elevations are
+// only written if opts.ElevationColumn is set. A Property named like one of
+// those columns is an error.
+// Property values that are not strings, numbers or bools are JSON encoded.
+func (f FeatureCollection) MarshalCSV(opts CSVOptions) ([]byte, error) {
+	keySet := map[string]bool{}
+	for _, ft := range f.Features {
+		for k := range ft.Properties {
+			keySet[k] = true
+		}
+	}
+	keys := make([]string, 0, len(keySet))
+	for k := range keySet {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	header := opts.geometryColumns()
+	if opts.IDColumn != "" {
+		header = append(header, opts.IDColumn)
+	}
+	columns := make(map[string]bool, len(header))
+	for _, name := range header {
+		if columns[name] {
+			return nil, fmt.Errorf("csv: duplicate column %q", name)
+		}
+		columns[name] = true
+	}
+	for _, k := range keys {
+		if columns[k] {
+			return nil, fmt.Errorf("csv: property %q has the name of a geometry or ID column", k)
+		}
+	}
+	header = append(header, keys...)
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if opts.Comma != 0 {
+		w.Comma = opts.Comma
+	}
+	if err := w.Write(header); err != nil {
+		return nil, fmt.Errorf("csv: %w", err)
+	}
+
+	for i, ft := range f.Features {
+		rec, err := csvGeometry(ft.geometry, opts)
+		if err != nil {
+			return nil, fmt.Errorf("csv: feature %d: %w", i, err)
+		}
+		if opts.IDColumn != "" {
+			s, err := formatCSVValue(ft.ID)
+			if err != nil {
+				return nil, fmt.Errorf("csv: feature %d: id: %w", i, err)
+			}
+			rec = append(rec, s)
+		}
+		for _, k := range keys {
+			s, err := formatCSVValue(ft.Properties[k])
+			if err != nil {
+				return nil, fmt.Errorf("csv: feature %d: property %q: %w", i, k, err)
+			}
+			rec = append(rec, s)
+		}
+		if err := w.Write(rec); err != nil {
+			return nil, fmt.Errorf("csv: %w", err)
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("csv: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func csvWKT(s string) (any, error) {
+	if s == "" {
+		return nil, nil
+	}
+	g, _, err := UnmarshalEWKT([]byte(s))
+	return g.geometry, err
+}
+
+// csvPoint builds a Point from the lon, lat and optional elevation cells at idx.
+func csvPoint(rec []string, idx []int) (any, error) {
+	if rec[idx[0]] == "" && rec[idx[1]] == "" {
+		return nil, nil
+	}
+	p := make(Point, 0, len(idx))
+	for _, i := range idx {
+		if rec[i] == "" && len(p) == 2 {
+			break
+		}
+		v, err := strconv.ParseFloat(rec[i], 64)
+		if err != nil || !isFinite(v) {
+			return nil, fmt.Errorf("invalid coordinate %q", rec[i])
+		}
+		p = append(p, v)
+	}
+	return p, nil
+}
+
+func csvValue(s string, infer bool) any {
+	if !infer {
+		return s
+	}
+	switch s {
+	case "":
+		return nil
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if v, err := strconv.ParseFloat(s, 64); err == nil && isFinite(v) {
+		return v
+	}
+	return s
+}
+
+func csvGeometry(g any, opts CSVOptions) ([]string, error) {
+	cols := opts.geometryColumns()
+	rec := make([]string, len(cols))
+	if g == nil {
+		return rec, nil
+	}
+
+	if opts.WKTColumn != "" {
+		b, err := marshalWKT(g)
+		if err != nil {
+			return nil, err
+		}
+		rec[0] = string(b)
+		return rec, nil
+	}
+
+	p, ok := g.(Point)
+	if !ok {
+		return nil, fmt.Errorf("unsupported geometry type %q without a WKT column", geometryType(g))
+	}
+	for i := range rec {
+		if i < len(p) {
+			rec[i] = strconv.FormatFloat(p[i], 'f', -1, 64)
+		}
+	}
+	return rec, nil
+}
+
+func formatCSVValue(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32:
+		return fmt.Sprint(v), nil
+	default:
+		b, err := json.Marshal(v)
+		return string(b), err
+	}
+}
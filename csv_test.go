@@ -0,0 +1,103 @@
+package joejson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSV(t *testing.T) {
+	testCases := map[string]struct {
+		csv  string
+		opts CSVOptions
+		fc   FeatureCollection
+	}{
+		"coordinates": {
+			csv:  "name,lon,lat\nA,1.5,2\nB,,\n",
+			opts: CSVOptions{},
+			fc: FeatureCollection{Features: []Feature{
+				Feature{Properties: map[string]any{"name": "A"}}.WithPoint(Point{1.5, 2}),
+				{Properties: map[string]any{"name": "B"}},
+			}},
+		},
+		"elevation and inferred types": {
+			csv:  "id,x,y,z,count,ok,note\n7,1,2,3,10,true,\nb,4,5,,x,false,hi\n",
+			opts: CSVOptions{LonColumn: "x", LatColumn: "y", ElevationColumn: "z", IDColumn: "id", InferTypes: true},
+			fc: FeatureCollection{Features: []Feature{
+				Feature{ID: 7.0, Properties: map[string]any{"count": 10.0, "ok": true, "note": nil}}.WithPoint(Point{1, 2, 3}),
+				Feature{ID: "b", Properties: map[string]any{"count": "x", "ok": false, "note": "hi"}}.WithPoint(Point{4, 5}),
+			}},
+		},
+		"non-finite values stay strings": {
+			csv:  "lon,lat,v,w\n1,2,NaN,-inf\n",
+			opts: CSVOptions{InferTypes: true},
+			fc: FeatureCollection{Features: []Feature{
+				Feature{Properties: map[string]any{"v": "NaN", "w": "-inf"}}.WithPoint(Point{1, 2}),
+			}},
+		},
+		"wkt": {
+			csv:  "geom;name\nLINESTRING (0 0,1 1);a\n;b\n",
+			opts: CSVOptions{WKTColumn: "geom", Comma: ';'},
+			fc: FeatureCollection{Features: []Feature{
+				Feature{Properties: map[string]any{"name": "a"}}.WithLineString(LineString{{0, 0}, {1, 1}}),
+				{Properties: map[string]any{"name": "b"}},
+			}},
+		},
+	}
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			fc, err := UnmarshalCSV([]byte(tt.csv), tt.opts)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.fc, fc)
+
+			b, err := fc.MarshalCSV(tt.opts)
+			assert.NoError(t, err)
+			got, err := UnmarshalCSV(b, tt.opts)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.fc, got)
+		})
+	}
+
+	t.Run("writer", func(t *testing.T) {
+		fc := FeatureCollection{Features: []Feature{
+			Feature{ID: 1, Properties: map[string]any{"a": []int{1, 2}}}.WithPoint(Point{1, 2}),
+			Feature{Properties: map[string]any{"b": "x,y"}}.WithPoint(Point{3, 4}),
+		}}
+		b, err := fc.MarshalCSV(CSVOptions{IDColumn: "id"})
+		assert.NoError(t, err)
+		assert.Equal(t, "lon,lat,id,a,b\n1,2,1,\"[1,2]\",\n3,4,,,\"x,y\"\n", string(b))
+
+		b, err = FeatureCollection{Features: []Feature{Feature{}.WithPoint(Point{1, 2, 3})}}.MarshalCSV(CSVOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "lon,lat\n1,2\n", string(b))
+
+		_, err = FeatureCollection{Features: []Feature{{Properties: map[string]any{"lat": 1.0}}}}.MarshalCSV(CSVOptions{})
+		assert.EqualError(t, err, `csv: property "lat" has the name of a geometry or ID column`)
+		_, err = FeatureCollection{Features: []Feature{{Properties: map[string]any{"id": 1.0}}}}.MarshalCSV(CSVOptions{IDColumn: "id"})
+		assert.EqualError(t, err, `csv: property "id" has the name of a geometry or ID column`)
+		_, err = FeatureCollection{Features: []Feature{{Properties: map[string]any{"wkt": "x"}}}}.MarshalCSV(CSVOptions{WKTColumn: "wkt"})
+		assert.EqualError(t, err, `csv: property "wkt" has the name of a geometry or ID column`)
+		_, err = FeatureCollection{}.MarshalCSV(CSVOptions{IDColumn: "lon"})
+		assert.EqualError(t, err, `csv: duplicate column "lon"`)
+
+		_, err = FeatureCollection{Features: []Feature{Feature{}.WithLineString(LineString{})}}.MarshalCSV(CSVOptions{})
+		assert.EqualError(t, err, `csv: feature 0: unsupported geometry type "LineString" without a WKT column`)
+	})
+
+	for name, tt := range map[string]struct {
+		csv  string
+		opts CSVOptions
+		err  string
+	}{
+		"missing column":        {"x,y\n", CSVOptions{}, `csv: missing column "lon"`},
+		"invalid coordinate":    {"lon,lat\n1,a\n", CSVOptions{}, `csv: line 2: invalid coordinate "a"`},
+		"non-finite coordinate": {"lon,lat\nnan,inf\n", CSVOptions{}, `csv: line 2: invalid coordinate "nan"`},
+		"invalid wkt":           {"g\nPOINT (1)\n", CSVOptions{WKTColumn: "g"}, "csv: line 2: wkt: expected 2 to 4 coordinates, found 1 at offset 7"},
+		"empty":                 {"", CSVOptions{}, "csv: missing header"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := UnmarshalCSV([]byte(tt.csv), tt.opts)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package joejson
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Object is any GeoJSON object: a Feature, a FeatureCollection or one of the
+// seven geometry types, held by value. Use a type switch to find which.
+type Object interface {
+	json.Marshaler
+	object()
+}
+
+func (Point) object()              {}
+func (MultiPoint) object()         {}
+func (LineString) object()         {}
+func (MultiLineString) object()    {}
+func (Polygon) object()            {}
+func (MultiPolygon) object()       {}
+func (GeometryCollection) object() {}
+func (Feature) object()            {}
+func (FeatureCollection) object()  {}
+
+// Decode unmarshals a GeoJSON document of any type, choosing the Object
+// from its 'type' member.
+func Decode(b []byte) (Object, error) {
+	var tmp struct {
+		Type string `json:"type"`
+	}
+
+	if err := json.Unmarshal(b, &tmp); err != nil {
+		return nil, err
+	}
+
+	switch tmp.Type {
+	case TypeFeature:
+		var f Feature
+		if err := json.Unmarshal(b, &f); err != nil {
+			return nil, err
+		}
+		return f, nil
+	case TypeFeatureCollection:
+		var f FeatureCollection
+		if err := json.Unmarshal(b, &f); err != nil {
+			return nil, err
+		}
+		return f, nil
+	case GeometryTypePoint, GeometryTypeMultiPoint, GeometryTypeLineString, GeometryTypeMultiLineString,
+		GeometryTypePolygon, GeometryTypeMultiPolygon, GeometryTypeGeometryCollection:
+		g, err := unmarshalGeometry(b)
+		if err != nil {
+			return nil, err
+		}
+		return g.(Object), nil
+	default:
+		return nil, fmt.Errorf("unknown type: %q", tmp.Type)
+	}
+}
@@ -0,0 +1,87 @@
+package joejson
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecode(t *testing.T) {
+	testCases := map[string]struct {
+		in   string
+		want Object
+	}{
+		"Point": {
+			`{"type":"Point","coordinates":[1,2]}`,
+			Point{1, 2},
+		},
+		"MultiPoint": {
+			`{"type":"MultiPoint","coordinates":[[1,2],[3,4]]}`,
+			MultiPoint{{1, 2}, {3, 4}},
+		},
+		"LineString": {
+			`{"type":"LineString","coordinates":[[1,2],[3,4]]}`,
+			LineString{{1, 2}, {3, 4}},
+		},
+		"MultiLineString": {
+			`{"type":"MultiLineString","coordinates":[[[1,2],[3,4]]]}`,
+			MultiLineString{{{1, 2}, {3, 4}}},
+		},
+		"Polygon": {
+			`{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]]]}`,
+			Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}},
+		},
+		"MultiPolygon": {
+			`{"type":"MultiPolygon","coordinates":[[[[0,0],[1,0],[1,1],[0,0]]]]}`,
+			MultiPolygon{{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}}},
+		},
+		"GeometryCollection": {
+			`{"type":"GeometryCollection","geometries":[{"type":"Point","coordinates":[1,2]}]}`,
+			GeometryCollection{{Point{1, 2}}},
+		},
+		"Feature": {
+			`{"type":"Feature","id":"a","geometry":{"type":"Point","coordinates":[1,2]},"properties":{"name":"x"}}`,
+			Feature{ID: "a", Properties: map[string]any{"name": "x"}}.WithPoint(Point{1, 2}),
+		},
+		"FeatureCollection": {
+			`{"type":"FeatureCollection","features":[{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]}}]}`,
+			FeatureCollection{Features: []Feature{Feature{}.WithPoint(Point{1, 2})}},
+		},
+	}
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := Decode([]byte(tt.in))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			b, err := json.Marshal(got)
+			assert.NoError(t, err)
+			again, err := Decode(b)
+			assert.NoError(t, err)
+			assert.Equal(t, got, again)
+		})
+	}
+
+	t.Run("type switch", func(t *testing.T) {
+		got, err := Decode([]byte(`{"type":"LineString","coordinates":[[1,2],[3,4]]}`))
+		assert.NoError(t, err)
+		switch g := got.(type) {
+		case LineString:
+			assert.Len(t, g, 2)
+		default:
+			t.Fatalf("unexpected %T", g)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := Decode([]byte(`{"type":"Circle","radius":3}`))
+		assert.EqualError(t, err, `unknown type: "Circle"`)
+		_, err = Decode([]byte(`{"coordinates":[1,2]}`))
+		assert.EqualError(t, err, `unknown type: ""`)
+		_, err = Decode([]byte(`[1,2]`))
+		assert.Error(t, err)
+		_, err = Decode([]byte(`{"type":"Point","coordinates":"x"}`))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,77 @@
+package joejson
+
+import "math"
+
+// densifyMaxParts bounds the number of parts one segment is split into.
+const densifyMaxParts = 1 << 20
+
+// Densify adds Positions so that no segment is longer than maxSegmentLength,
+// splitting each longer segment into equal parts. With opts.Geodesic the
+// length is in metres and new Positions follow the great circle, otherwise
+// they lie on the straight segment; LineOptions says which meridian joins
+// antipodal ends. Elevations are interpolated when both ends have one. No segment is split
+// into more than 2^20 parts. A maxSegmentLength of zero or less leaves it
+// unchanged.
+func (g LineString) Densify(maxSegmentLength float64, opts LineOptions) LineString {
+	return LineString(densifyPositions(g, maxSegmentLength, opts))
+}
+
+// Densify densifies every LineString. See LineString.Densify.
+func (g MultiLineString) Densify(maxSegmentLength float64, opts LineOptions) MultiLineString {
+	if g == nil {
+		return nil
+	}
+	out := make(MultiLineString, len(g))
+	for i, ls := range g {
+		out[i] = ls.Densify(maxSegmentLength, opts)
+	}
+	return out
+}
+
+// Densify densifies every LinearRing, keeping each closed.
+// See LineString.Densify.
+func (p Polygon) Densify(maxSegmentLength float64, opts LineOptions) Polygon {
+	if p == nil {
+		return nil
+	}
+	out := make(Polygon, len(p))
+	for i, lr := range p {
+		out[i] = LinearRing(densifyPositions(lr, maxSegmentLength, opts))
+	}
+	return out
+}
+
+// Densify densifies every Polygon. See LineString.Densify.
+func (p MultiPolygon) Densify(maxSegmentLength float64, opts LineOptions) MultiPolygon {
+	if p == nil {
+		return nil
+	}
+	out := make(MultiPolygon, len(p))
+	for i, pl := range p {
+		out[i] = pl.Densify(maxSegmentLength, opts)
+	}
+	return out
+}
+
+// densifyPositions keeps every position of ps, so a closed ring stays
+// closed, and adds evenly spaced ones along segments longer than max.
+func densifyPositions(ps []Position, max float64, opts LineOptions) []Position {
+	if ps == nil {
+		return nil
+	}
+	if !(max > 0) || len(ps) < 2 {
+		return append([]Position(nil), ps...)
+	}
+	out := make([]Position, 0, len(ps))
+	for i, p := range ps {
+		if i > 0 {
+			a := ps[i-1]
+			n := math.Min(math.Ceil(segmentLength(a, p, opts)/max), densifyMaxParts)
+			for k := 1.0; k < n; k++ {
+				out = append(out, segmentPosition(a, p, k/n, opts))
+			}
+		}
+		out = append(out, p)
+	}
+	return out
+}
@@ -0,0 +1,102 @@
+package joejson
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDensify(t *testing.T) {
+	testCases := map[string]struct {
+		g    LineString
+		max  float64
+		want LineString
+	}{
+		"split evenly": {
+			LineString{{0, 0}, {3, 0}},
+			1,
+			LineString{{0, 0}, {1, 0}, {2, 0}, {3, 0}},
+		},
+		"rounded up": {
+			LineString{{0, 0}, {0, 6}},
+			4,
+			LineString{{0, 0}, {0, 3}, {0, 6}},
+		},
+		"short enough": {
+			LineString{{0, 0}, {1, 0}, {1, 1}},
+			1,
+			LineString{{0, 0}, {1, 0}, {1, 1}},
+		},
+		"elevation": {
+			LineString{{0, 0, 10}, {2, 0, 20}, {2, 1}},
+			1,
+			LineString{{0, 0, 10}, {1, 0, 15}, {2, 0, 20}, {2, 1}},
+		},
+		"no limit": {
+			LineString{{0, 0}, {3, 0}},
+			0,
+			LineString{{0, 0}, {3, 0}},
+		},
+		"repeated Position": {
+			LineString{{0, 0}, {0, 0}, {2, 0}},
+			1,
+			LineString{{0, 0}, {0, 0}, {1, 0}, {2, 0}},
+		},
+		"empty": {nil, 1, nil},
+	}
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.g.Densify(tt.max, LineOptions{}))
+		})
+	}
+
+	t.Run("MultiLineString", func(t *testing.T) {
+		got := MultiLineString{{{0, 0}, {2, 0}}, {{5, 5}, {5, 6}}}.Densify(1, LineOptions{})
+		assert.Equal(t, MultiLineString{{{0, 0}, {1, 0}, {2, 0}}, {{5, 5}, {5, 6}}}, got)
+	})
+
+	t.Run("Polygon stays closed", func(t *testing.T) {
+		p := Polygon{
+			{{0, 0}, {4, 0}, {4, 4}, {0, 4}, {0, 0}},
+			{{1, 1}, {1, 3}, {3, 3}, {3, 1}, {1, 1}},
+		}
+		got := MultiPolygon{p}.Densify(1.5, LineOptions{})
+		assert.Len(t, got[0][0], 13)
+		assert.Len(t, got[0][1], 9)
+		for _, r := range got[0] {
+			assert.Equal(t, r[0], r[len(r)-1])
+		}
+		assert.Equal(t, ringArea(p[0]), ringArea(got[0][0]))
+	})
+
+	t.Run("Geodesic", func(t *testing.T) {
+		// the great circle from Madrid to New York, in 500 km steps.
+		ls := LineString{{-3.7, 40.4}, {-74, 40.7}}
+		geodesic := LineOptions{Geodesic: true}
+		got := ls.Densify(500000, geodesic)
+		total := ls.Length(geodesic)
+		assert.Len(t, got, int(math.Ceil(total/500000))+1)
+		assert.Equal(t, ls[0], got[0])
+		assert.Equal(t, ls[1], got[len(got)-1])
+		for i := 0; i+1 < len(got); i++ {
+			assert.InDelta(t, total/float64(len(got)-1), greatCircleDistance(got[i], got[i+1]), 1e-3)
+		}
+		// the geodesic passes well north of both ends.
+		assert.Greater(t, got[len(got)/2].Lat(), 45.0)
+		assert.InDelta(t, total, got.Length(geodesic), 1e-3)
+
+		ring := Polygon{{{0, 0}, {20, 0}, {20, 20}, {0, 20}, {0, 0}}}.Densify(1000000, geodesic)
+		assert.Equal(t, ring[0][0], ring[0][len(ring[0])-1])
+		assert.Greater(t, len(ring[0]), 5)
+
+		// antipodal ends are joined along the meridian heading north.
+		degree := 111319.49079327357
+		anti := LineString{{0, 0}, {180, 0}}.Densify(45.001*degree, geodesic)
+		assertPositionsInDelta(t, LineString{{0, 0}, {0, 45}, {0, 90}, {180, 45}, {180, 0}}, anti)
+	})
+
+	t.Run("parts capped", func(t *testing.T) {
+		assert.Equal(t, densifyMaxParts+1, len(LineString{{0, 0}, {1, 0}}.Densify(1e-300, LineOptions{})))
+	})
+}
@@ -0,0 +1,439 @@
+package joejson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	esriGeometryPoint      = "esriGeometryPoint"
+	esriGeometryMultipoint = "esriGeometryMultipoint"
+	esriGeometryPolyline   = "esriGeometryPolyline"
+	esriGeometryPolygon    = "esriGeometryPolygon"
+
+	// esriObjectID is the attribute Feature IDs are written to.
+	esriObjectID = "OBJECTID"
+)
+
+// EsriSpatialReference identifies the coordinate system of Esri JSON.
+type EsriSpatialReference struct {
+	WKID       int    `json:"wkid,omitempty"`
+	LatestWKID int    `json:"latestWkid,omitempty"`
+	WKT        string `json:"wkt,omitempty"`
+}
+
+// EsriMarshaler is implemented by every geometry type with an Esri JSON equivalent.
+type EsriMarshaler interface {
+	MarshalEsriJSON() ([]byte, error)
+}
+
+// MarshalEsriJSON encodes the Point as an Esri JSON point.
+func (p Point) MarshalEsriJSON() ([]byte, error) {
+	return marshalEsriGeometry(p, nil)
+}
+
+// MarshalEsriJSON encodes the MultiPoint as an Esri JSON multipoint.
+func (g MultiPoint) MarshalEsriJSON() ([]byte, error) {
+	return marshalEsriGeometry(g, nil)
+}
+
+// MarshalEsriJSON encodes the LineString as an Esri JSON polyline with one path.
+func (g LineString) MarshalEsriJSON() ([]byte, error) {
+	return marshalEsriGeometry(g, nil)
+}
+
+// MarshalEsriJSON encodes the MultiLineString as an Esri JSON polyline.
+func (g MultiLineString) MarshalEsriJSON() ([]byte, error) {
+	return marshalEsriGeometry(g, nil)
+}
+
+// MarshalEsriJSON encodes the Polygon as an Esri JSON polygon, with the
+// exterior ring clockwise and holes counterclockwise as Esri requires.
+func (p Polygon) MarshalEsriJSON() ([]byte, error) {
+	return marshalEsriGeometry(p, nil)
+}
+
+// MarshalEsriJSON encodes the MultiPolygon as a single Esri JSON polygon
+// holding every ring, with exterior rings clockwise and holes counterclockwise.
+func (p MultiPolygon) MarshalEsriJSON() ([]byte, error) {
+	return marshalEsriGeometry(p, nil)
+}
+
+// MarshalEsriJSON encodes a geometry as Esri JSON with a spatialReference.
+func MarshalEsriJSON(g EsriMarshaler, sr EsriSpatialReference) ([]byte, error) {
+	return marshalEsriGeometry(g, &sr)
+}
+
+// UnmarshalEsriJSON decodes an Esri JSON point, multipoint, polyline, polygon
+// or envelope. Polylines with one path become a LineString and polygons
+// with one exterior ring a Polygon; holes are assigned to the exterior ring
+// containing them and every ring is rewound to RFC 7946 order; rings of
+// zero area are dropped. Envelopes
+// become a Polygon. M values are dropped. sr is nil if the geometry has no
+// spatialReference.
+func UnmarshalEsriJSON(b []byte) (g GeometryCollectionMember, sr *EsriSpatialReference, err error) {
+	var tmp esriGeometry
+	if err := json.Unmarshal(b, &tmp); err != nil {
+		return GeometryCollectionMember{}, nil, err
+	}
+	geom, err := tmp.geometry()
+	if err != nil {
+		return GeometryCollectionMember{}, nil, err
+	}
+	return GeometryCollectionMember{geom}, tmp.SpatialReference, nil
+}
+
+// MarshalEsriJSON encodes the Feature as an Esri JSON feature, with its
+// Properties as attributes. A non-negative integer ID is written as the
+// OBJECTID attribute unless a Property already has that name; other IDs are
+// not written.
+func (f Feature) MarshalEsriJSON() ([]byte, error) {
+	tmp, err := esriFeatureOf(f)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(tmp)
+}
+
+// UnmarshalEsriJSON decodes an Esri JSON feature, taking Properties from its attributes.
+func (f *Feature) UnmarshalEsriJSON(b []byte) error {
+	var tmp esriFeature
+	if err := json.Unmarshal(b, &tmp); err != nil {
+		return err
+	}
+	ft, err := tmp.feature()
+	if err != nil {
+		return err
+	}
+	*f = ft
+	return nil
+}
+
+// MarshalEsriFeatureSet encodes the FeatureCollection as an Esri JSON
+// feature set, as returned by ArcGIS REST queries. Every Feature must map
+// to the same Esri geometry type. IDs are written as by
+// Feature.MarshalEsriJSON.
+func (f FeatureCollection) MarshalEsriFeatureSet(sr EsriSpatialReference) ([]byte, error) {
+	tmp := struct {
+		GeometryType     string               `json:"geometryType,omitempty"`
+		SpatialReference EsriSpatialReference `json:"spatialReference"`
+		Features         []esriFeature        `json:"features"`
+	}{
+		SpatialReference: sr,
+		Features:         make([]esriFeature, len(f.Features)),
+	}
+
+	for i, ft := range f.Features {
+		if ft.geometry != nil {
+			typ := esriGeometryType(ft.geometry)
+			if tmp.GeometryType != "" && typ != tmp.GeometryType {
+				return nil, fmt.Errorf("feature %d: geometry type %q does not match %q", i, typ, tmp.GeometryType)
+			}
+			tmp.GeometryType = typ
+		}
+		ef, err := esriFeatureOf(ft)
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %w", i, err)
+		}
+		tmp.Features[i] = ef
+	}
+	return json.Marshal(tmp)
+}
+
+// UnmarshalEsriFeatureSet decodes an Esri JSON feature set. sr is nil if
+// the feature set has no spatialReference.
+func UnmarshalEsriFeatureSet(b []byte) (fc FeatureCollection, sr *EsriSpatialReference, err error) {
+	var tmp struct {
+		SpatialReference *EsriSpatialReference `json:"spatialReference"`
+		Features         []esriFeature         `json:"features"`
+	}
+	if err := json.Unmarshal(b, &tmp); err != nil {
+		return FeatureCollection{}, nil, err
+	}
+
+	fc.Features = make([]Feature, len(tmp.Features))
+	for i, ef := range tmp.Features {
+		if fc.Features[i], err = ef.feature(); err != nil {
+			return FeatureCollection{}, nil, fmt.Errorf("feature %d: %w", i, err)
+		}
+	}
+	return fc, tmp.SpatialReference, nil
+}
+
+type esriFeature struct {
+	Attributes map[string]any  `json:"attributes"`
+	Geometry   json.RawMessage `json:"geometry,omitempty"`
+}
+
+func esriFeatureOf(f Feature) (esriFeature, error) {
+	out := esriFeature{Attributes: f.Properties}
+	if out.Attributes == nil {
+		out.Attributes = map[string]any{}
+	}
+	if id, ok := mvtID(f.ID); ok {
+		if _, taken := out.Attributes[esriObjectID]; !taken {
+			out.Attributes = make(map[string]any, len(f.Properties)+1)
+			for k, v := range f.Properties {
+				out.Attributes[k] = v
+			}
+			out.Attributes[esriObjectID] = id
+		}
+	}
+	if f.geometry != nil {
+		b, err := marshalEsriGeometry(f.geometry, nil)
+		if err != nil {
+			return esriFeature{}, err
+		}
+		out.Geometry = b
+	}
+	return out, nil
+}
+
+func (ef esriFeature) feature() (Feature, error) {
+	ft := Feature{Properties: ef.Attributes}
+	if len(ef.Geometry) == 0 || bytes.Equal(ef.Geometry, []byte("null")) {
+		return ft, nil
+	}
+	g, _, err := UnmarshalEsriJSON(ef.Geometry)
+	ft.geometry = g.geometry
+	return ft, err
+}
+
+// esriGeometry holds the members of every Esri JSON geometry type.
+type esriGeometry struct {
+	X                json.RawMessage       `json:"x"`
+	Y                json.RawMessage       `json:"y"`
+	Z                *float64              `json:"z"`
+	Points           []Position            `json:"points"`
+	Paths            [][]Position          `json:"paths"`
+	Rings            [][]Position          `json:"rings"`
+	XMin             *float64              `json:"xmin"`
+	YMin             *float64              `json:"ymin"`
+	XMax             *float64              `json:"xmax"`
+	YMax             *float64              `json:"ymax"`
+	HasZ             bool                  `json:"hasZ"`
+	HasM             bool                  `json:"hasM"`
+	SpatialReference *EsriSpatialReference `json:"spatialReference"`
+}
+
+func (g esriGeometry) geometry() (any, error) {
+	switch {
+	case g.Rings != nil:
+		rings := make([][]Position, len(g.Rings))
+		for i, r := range g.Rings {
+			ps, err := g.positions(r)
+			if err != nil {
+				return nil, err
+			}
+			rings[i] = ps
+		}
+		return esriPolygon(rings), nil
+	case g.Paths != nil:
+		mls := make(MultiLineString, len(g.Paths))
+		for i, path := range g.Paths {
+			ps, err := g.positions(path)
+			if err != nil {
+				return nil, err
+			}
+			mls[i] = ps
+		}
+		if len(mls) == 1 {
+			return LineString(mls[0]), nil
+		}
+		return mls, nil
+	case g.Points != nil:
+		ps, err := g.positions(g.Points)
+		return MultiPoint(ps), err
+	case g.XMin != nil && g.YMin != nil && g.XMax != nil && g.YMax != nil:
+		return bounds{*g.XMin, *g.YMin, *g.XMax, *g.YMax}.polygon(), nil
+	case g.X != nil:
+		x, okX := esriNumber(g.X)
+		y, okY := esriNumber(g.Y)
+		if !okX || !okY {
+			return Point{}, nil
+		}
+		if g.Z != nil {
+			return Point{x, y, *g.Z}, nil
+		}
+		return Point{x, y}, nil
+	default:
+		return nil, errors.New("unknown Esri geometry")
+	}
+}
+
+// positions validates ps, dropping M values.
+func (g esriGeometry) positions(ps []Position) ([]Position, error) {
+	n := 3
+	if g.HasM && !g.HasZ {
+		n = 2
+	}
+	out := make([]Position, len(ps))
+	for i, p := range ps {
+		if len(p) < 2 {
+			return nil, fmt.Errorf("invalid coordinate %v", []float64(p))
+		}
+		out[i] = p[:minInt(len(p), n)]
+	}
+	return out, nil
+}
+
+// esriNumber parses a coordinate that may be null or "NaN" for empty points.
+func esriNumber(raw json.RawMessage) (float64, bool) {
+	var v *float64
+	if err := json.Unmarshal(raw, &v); err != nil || v == nil || math.IsNaN(*v) {
+		return 0, false
+	}
+	return *v, true
+}
+
+// esriPolygon groups clockwise exterior rings with the counterclockwise
+// holes inside them, rewinding to RFC 7946 order. Counterclockwise rings
+// outside every exterior ring are treated as exterior rings themselves.
+// Rings of zero area have no winding and are dropped.
+func esriPolygon(rings [][]Position) any {
+	var outers, holes [][]Position
+	for _, r := range rings {
+		switch area := ringArea(r); {
+		case area < 0:
+			outers = append(outers, r)
+		case area > 0:
+			holes = append(holes, r)
+		}
+	}
+
+	mp := make(MultiPolygon, len(outers))
+	for i, r := range outers {
+		mp[i] = Polygon{reversePositions(r)}
+	}
+	for _, h := range holes {
+		best := -1
+		for i, r := range outers {
+			if ringContains(r, h[0]) && (best < 0 || math.Abs(ringArea(r)) < math.Abs(ringArea(outers[best]))) {
+				best = i
+			}
+		}
+		if best < 0 {
+			mp = append(mp, Polygon{h})
+			continue
+		}
+		mp[best] = append(mp[best], reversePositions(h))
+	}
+
+	if len(mp) == 1 {
+		return mp[0]
+	}
+	return mp
+}
+
+// ringContains reports whether p is inside ring by the even-odd rule.
+func ringContains(ring []Position, p Position) bool {
+	in := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Lat() > p.Lat()) != (b.Lat() > p.Lat()) &&
+			p.Lon() < (b.Lon()-a.Lon())*(p.Lat()-a.Lat())/(b.Lat()-a.Lat())+a.Lon() {
+			in = !in
+		}
+	}
+	return in
+}
+
+func esriGeometryType(g any) string {
+	switch g.(type) {
+	case Point:
+		return esriGeometryPoint
+	case MultiPoint:
+		return esriGeometryMultipoint
+	case LineString, MultiLineString:
+		return esriGeometryPolyline
+	case Polygon, MultiPolygon:
+		return esriGeometryPolygon
+	default:
+		return ""
+	}
+}
+
+func marshalEsriGeometry(g any, sr *EsriSpatialReference) ([]byte, error) {
+	hasZ := false
+	eachPosition(g, func(p Position) {
+		hasZ = hasZ || len(p) > 2
+	})
+
+	m := map[string]any{}
+	switch g := g.(type) {
+	case Point:
+		if len(g) == 0 {
+			m["x"], m["y"] = nil, nil
+			break
+		}
+		p := Position(g)
+		m["x"], m["y"] = p.Lon(), p.Lat()
+		if hasZ {
+			m["z"] = p.Elevation()
+		}
+	case MultiPoint:
+		m["points"] = esriPositions(g, hasZ)
+	case LineString:
+		m["paths"] = [][][]float64{esriPositions(g, hasZ)}
+	case MultiLineString:
+		paths := make([][][]float64, len(g))
+		for i, ls := range g {
+			paths[i] = esriPositions(ls, hasZ)
+		}
+		m["paths"] = paths
+	case Polygon:
+		m["rings"] = esriRings(g, hasZ)
+	case MultiPolygon:
+		rings := [][][]float64{}
+		for _, pl := range g {
+			rings = append(rings, esriRings(pl, hasZ)...)
+		}
+		m["rings"] = rings
+	default:
+		return nil, fmt.Errorf("unsupported geometry type %q for Esri JSON", geometryType(g))
+	}
+	if hasZ {
+		m["hasZ"] = true
+	}
+	if sr != nil {
+		m["spatialReference"] = sr
+	}
+	return json.Marshal(m)
+}
+
+func esriRings(p Polygon, hasZ bool) [][][]float64 {
+	rings := windClockwise(p)
+	out := make([][][]float64, len(rings))
+	for i, ring := range rings {
+		out[i] = esriPositions(ring, hasZ)
+	}
+	return out
+}
+
+// windClockwise winds the exterior ring clockwise and holes
+// counterclockwise, the Esri convention shared by shapefiles.
+func windClockwise(p Polygon) [][]Position {
+	out := make([][]Position, len(p))
+	for i, lr := range p {
+		out[i] = lr
+		if area := ringArea(lr); (i == 0) != (area < 0) && area != 0 {
+			out[i] = reversePositions(lr)
+		}
+	}
+	return out
+}
+
+func esriPositions(ps []Position, hasZ bool) [][]float64 {
+	out := make([][]float64, len(ps))
+	for i, p := range ps {
+		if hasZ {
+			out[i] = []float64{p.Lon(), p.Lat(), p.Elevation()}
+		} else {
+			out[i] = []float64{p.Lon(), p.Lat()}
+		}
+	}
+	return out
+}
@@ -0,0 +1,183 @@
+package joejson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEsriJSON(t *testing.T) {
+	testCases := map[string]struct {
+		g    EsriMarshaler
+		esri string
+	}{
+		"Point": {
+			g:    Point{1, 2},
+			esri: `{"x":1,"y":2}`,
+		},
+		"Point Z": {
+			g:    Point{1, 2, 3},
+			esri: `{"hasZ":true,"x":1,"y":2,"z":3}`,
+		},
+		"empty Point": {
+			g:    Point{},
+			esri: `{"x":null,"y":null}`,
+		},
+		"MultiPoint": {
+			g:    MultiPoint{{1, 2}, {3, 4}},
+			esri: `{"points":[[1,2],[3,4]]}`,
+		},
+		"LineString": {
+			g:    LineString{{1, 2}, {3, 4, 5}},
+			esri: `{"hasZ":true,"paths":[[[1,2,0],[3,4,5]]]}`,
+		},
+		"MultiLineString": {
+			g:    MultiLineString{{{1, 2}, {3, 4}}, {{5, 6}, {7, 8}}},
+			esri: `{"paths":[[[1,2],[3,4]],[[5,6],[7,8]]]}`,
+		},
+		"Polygon": {
+			g: Polygon{
+				{{0, 0}, {4, 0}, {4, 4}, {0, 4}, {0, 0}},
+				{{1, 1}, {1, 2}, {2, 2}, {2, 1}, {1, 1}},
+			},
+			// exterior clockwise, hole counterclockwise.
+			esri: `{"rings":[[[0,0],[0,4],[4,4],[4,0],[0,0]],[[1,1],[2,1],[2,2],[1,2],[1,1]]]}`,
+		},
+		"MultiPolygon": {
+			g: MultiPolygon{
+				{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}},
+				{
+					{{10, 10}, {20, 10}, {20, 20}, {10, 20}, {10, 10}},
+					{{11, 11}, {11, 12}, {12, 12}, {11, 11}},
+				},
+			},
+			esri: `{"rings":[[[0,0],[1,1],[1,0],[0,0]],[[10,10],[10,20],[20,20],[20,10],[10,10]],[[11,11],[12,12],[11,12],[11,11]]]}`,
+		},
+	}
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			b, err := tt.g.MarshalEsriJSON()
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.esri, string(b))
+
+			got, sr, err := UnmarshalEsriJSON(b)
+			assert.NoError(t, err)
+			assert.Nil(t, sr)
+			want := any(tt.g)
+			if ls, ok := want.(LineString); ok {
+				want = LineString{{1, 2, 0}, ls[1]}
+			}
+			assert.Equal(t, want, got.geometry)
+		})
+	}
+
+	t.Run("spatial reference", func(t *testing.T) {
+		b, err := MarshalEsriJSON(Point{1, 2}, EsriSpatialReference{WKID: 4326})
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"x":1,"y":2,"spatialReference":{"wkid":4326}}`, string(b))
+
+		_, sr, err := UnmarshalEsriJSON(b)
+		assert.NoError(t, err)
+		assert.Equal(t, &EsriSpatialReference{WKID: 4326}, sr)
+	})
+
+	for name, tt := range map[string]struct {
+		esri string
+		want any
+	}{
+		"NaN point": {`{"x":"NaN","y":"NaN"}`, Point{}},
+		"M values":  {`{"hasM":true,"points":[[1,2,9]]}`, MultiPoint{{1, 2}}},
+		"ZM values": {`{"hasZ":true,"hasM":true,"paths":[[[1,2,3,9],[4,5,6,9]]]}`, LineString{{1, 2, 3}, {4, 5, 6}}},
+		"envelope":  {`{"xmin":0,"ymin":1,"xmax":2,"ymax":3}`, Polygon{{{0, 1}, {2, 1}, {2, 3}, {0, 3}, {0, 1}}}},
+		"counterclockwise exterior": {
+			`{"rings":[[[0,0],[1,0],[1,1],[0,0]]]}`,
+			Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}},
+		},
+		"zero area ring dropped": {
+			`{"rings":[[[0,0],[0,4],[4,4],[4,0],[0,0]],[[1,1],[2,2],[3,3],[1,1]]]}`,
+			Polygon{{{0, 0}, {4, 0}, {4, 4}, {0, 4}, {0, 0}}},
+		},
+		"holes listed last": {
+			`{"rings":[[[0,0],[0,4],[4,4],[4,0],[0,0]],[[10,10],[10,14],[14,14],[14,10],[10,10]],[[11,11],[12,11],[12,12],[11,11]]]}`,
+			MultiPolygon{
+				{{{0, 0}, {4, 0}, {4, 4}, {0, 4}, {0, 0}}},
+				{{{10, 10}, {14, 10}, {14, 14}, {10, 14}, {10, 10}}, {{11, 11}, {12, 12}, {12, 11}, {11, 11}}},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, _, err := UnmarshalEsriJSON([]byte(tt.esri))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.geometry)
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		_, _, err := UnmarshalEsriJSON([]byte(`{"foo":1}`))
+		assert.EqualError(t, err, "unknown Esri geometry")
+
+		_, _, err = UnmarshalEsriJSON([]byte(`{"points":[[1]]}`))
+		assert.EqualError(t, err, "invalid coordinate [1]")
+
+		_, err = marshalEsriGeometry(GeometryCollection{}, nil)
+		assert.EqualError(t, err, `unsupported geometry type "GeometryCollection" for Esri JSON`)
+	})
+}
+
+func TestEsriFeatureSet(t *testing.T) {
+	fc := FeatureCollection{Features: []Feature{
+		Feature{Properties: map[string]any{"OBJECTID": 1.0}}.WithLineString(LineString{{1, 2}, {3, 4}}),
+		Feature{Properties: map[string]any{"OBJECTID": 2.0}}.WithMultiLineString(MultiLineString{{{1, 2}, {3, 4}}, {{5, 6}, {7, 8}}}),
+		{Properties: map[string]any{"OBJECTID": 3.0}},
+	}}
+
+	b, err := fc.MarshalEsriFeatureSet(EsriSpatialReference{WKID: 4326})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"geometryType": "esriGeometryPolyline",
+		"spatialReference": {"wkid": 4326},
+		"features": [
+			{"attributes": {"OBJECTID": 1}, "geometry": {"paths": [[[1,2],[3,4]]]}},
+			{"attributes": {"OBJECTID": 2}, "geometry": {"paths": [[[1,2],[3,4]],[[5,6],[7,8]]]}},
+			{"attributes": {"OBJECTID": 3}}
+		]
+	}`, string(b))
+
+	got, sr, err := UnmarshalEsriFeatureSet(b)
+	assert.NoError(t, err)
+	assert.Equal(t, &EsriSpatialReference{WKID: 4326}, sr)
+	assert.Equal(t, fc, got)
+
+	t.Run("Feature", func(t *testing.T) {
+		b, err := fc.Features[0].MarshalEsriJSON()
+		assert.NoError(t, err)
+		var got Feature
+		assert.NoError(t, got.UnmarshalEsriJSON(b))
+		assert.Equal(t, fc.Features[0], got)
+	})
+
+	t.Run("ID", func(t *testing.T) {
+		props := map[string]any{"name": "a"}
+		b, err := Feature{ID: 7.0, Properties: props}.MarshalEsriJSON()
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"attributes":{"name":"a","OBJECTID":7}}`, string(b))
+		assert.Equal(t, map[string]any{"name": "a"}, props)
+
+		b, err = Feature{ID: 7, Properties: map[string]any{"OBJECTID": 3.0}}.MarshalEsriJSON()
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"attributes":{"OBJECTID":3}}`, string(b))
+
+		b, err = Feature{ID: "a"}.MarshalEsriJSON()
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"attributes":{}}`, string(b))
+	})
+
+	t.Run("mixed geometry types", func(t *testing.T) {
+		fc := FeatureCollection{Features: []Feature{
+			Feature{}.WithPoint(Point{1, 2}),
+			Feature{}.WithLineString(LineString{{1, 2}, {3, 4}}),
+		}}
+		_, err := fc.MarshalEsriFeatureSet(EsriSpatialReference{})
+		assert.EqualError(t, err, `feature 1: geometry type "esriGeometryPolyline" does not match "esriGeometryPoint"`)
+	})
+}
@@ -23,7 +23,12 @@ type Feature struct {
 
 // GeometryType is the type of the Feature's Geometry.
 func (f Feature) GeometryType() string {
-	switch f.geometry.(type) {
+	return geometryType(f.geometry)
+}
+
+// geometryType is the 'type' member value for any geometry type, or empty if g is not a geometry.
+func geometryType(g any) string {
+	switch g.(type) {
 	case Point:
 		return GeometryTypePoint
 	case MultiPoint:
@@ -0,0 +1,350 @@
+package joejson
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// This file holds the small subset of FlatBuffers needed by FlatGeobuf:
+// tables of scalars, strings, scalar vectors and table vectors.
+
+var errFlatBuffersRange = errors.New("flatbuffers: offset out of range")
+
+// fbObject is a FlatBuffers value referenced by offset.
+type fbObject interface {
+	// write appends the object and returns the position offsets should point at.
+	write(b *fbBuilder) int
+}
+
+// fbField is a table field holding either a little-endian scalar or an object.
+type fbField struct {
+	scalar []byte
+	object fbObject
+}
+
+// fbTable lists a table's fields by id; zero fields are absent.
+type fbTable []fbField
+
+type fbString string
+
+// fbScalars is a vector of size byte scalars.
+type fbScalars struct {
+	size int
+	data []byte
+}
+
+type fbTables []fbTable
+
+type fbBuilder struct {
+	buf []byte
+}
+
+// fbFinish serializes a buffer with root as its root table.
+func fbFinish(root fbTable) []byte {
+	b := &fbBuilder{buf: make([]byte, 4)}
+	b.patch(0, root.write(b))
+	return b.buf
+}
+
+func (b *fbBuilder) pad(align int) {
+	for len(b.buf)%align != 0 {
+		b.buf = append(b.buf, 0)
+	}
+}
+
+// patch sets the offset at pos to point at target.
+func (b *fbBuilder) patch(pos, target int) {
+	binary.LittleEndian.PutUint32(b.buf[pos:], uint32(target-pos))
+}
+
+// write lays out the vtable, then the table, then the objects it references,
+// so every offset points forward.
+func (t fbTable) write(b *fbBuilder) int {
+	offsets := make([]int, len(t))
+	size, align := 4, 4
+	for id, f := range t {
+		n := len(f.scalar)
+		if f.object != nil {
+			n = 4
+		}
+		if n == 0 {
+			continue
+		}
+		for size%n != 0 {
+			size++
+		}
+		offsets[id] = size
+		size += n
+		align = maxInt(align, n)
+	}
+
+	b.pad(2)
+	vt := len(b.buf)
+	vtable := make([]byte, 4+2*len(t))
+	binary.LittleEndian.PutUint16(vtable, uint16(len(vtable)))
+	binary.LittleEndian.PutUint16(vtable[2:], uint16(size))
+	for id, off := range offsets {
+		binary.LittleEndian.PutUint16(vtable[4+2*id:], uint16(off))
+	}
+	b.buf = append(b.buf, vtable...)
+
+	b.pad(align)
+	pos := len(b.buf)
+	table := make([]byte, size)
+	binary.LittleEndian.PutUint32(table, uint32(int32(pos-vt)))
+	for id, f := range t {
+		copy(table[offsets[id]:], f.scalar)
+	}
+	b.buf = append(b.buf, table...)
+
+	for id, f := range t {
+		if f.object != nil {
+			b.patch(pos+offsets[id], f.object.write(b))
+		}
+	}
+	return pos
+}
+
+func (s fbString) write(b *fbBuilder) int {
+	b.pad(4)
+	pos := len(b.buf)
+	b.buf = appendUint32(b.buf, uint32(len(s)))
+	b.buf = append(b.buf, s...)
+	b.buf = append(b.buf, 0)
+	return pos
+}
+
+func (v fbScalars) write(b *fbBuilder) int {
+	align := maxInt(4, v.size)
+	for (len(b.buf)+4)%align != 0 {
+		b.buf = append(b.buf, 0)
+	}
+	pos := len(b.buf)
+	b.buf = appendUint32(b.buf, uint32(len(v.data)/v.size))
+	b.buf = append(b.buf, v.data...)
+	return pos
+}
+
+func (v fbTables) write(b *fbBuilder) int {
+	b.pad(4)
+	pos := len(b.buf)
+	b.buf = appendUint32(b.buf, uint32(len(v)))
+	b.buf = append(b.buf, make([]byte, 4*len(v))...)
+	for i, t := range v {
+		b.patch(pos+4+4*i, t.write(b))
+	}
+	return pos
+}
+
+func fbUint8(v uint8) fbField {
+	return fbField{scalar: []byte{v}}
+}
+
+func fbBool(v bool) fbField {
+	if v {
+		return fbUint8(1)
+	}
+	return fbUint8(0)
+}
+
+func fbUint16(v uint16) fbField {
+	return fbField{scalar: appendUint16(nil, v)}
+}
+
+func fbInt32(v int32) fbField {
+	return fbField{scalar: appendUint32(nil, uint32(v))}
+}
+
+func fbUint64(v uint64) fbField {
+	return fbField{scalar: appendUint64(nil, v)}
+}
+
+func fbObjectField(o fbObject) fbField {
+	return fbField{object: o}
+}
+
+func fbDoubles(vs []float64) fbScalars {
+	data := make([]byte, 0, 8*len(vs))
+	for _, v := range vs {
+		data = appendUint64(data, math.Float64bits(v))
+	}
+	return fbScalars{size: 8, data: data}
+}
+
+func fbUint32s(vs []uint32) fbScalars {
+	data := make([]byte, 0, 4*len(vs))
+	for _, v := range vs {
+		data = appendUint32(data, v)
+	}
+	return fbScalars{size: 4, data: data}
+}
+
+// fbReader reads a FlatBuffers buffer, recording the first out of range
+// access in err.
+type fbReader struct {
+	b   []byte
+	err error
+}
+
+// fbTableRef is a table at pos in r.
+type fbTableRef struct {
+	r   *fbReader
+	pos int
+}
+
+func (r *fbReader) check(pos, n int) bool {
+	if r.err != nil {
+		return false
+	}
+	if pos < 0 || n < 0 || pos > len(r.b)-n {
+		r.err = errFlatBuffersRange
+		return false
+	}
+	return true
+}
+
+func (r *fbReader) uint16(pos int) int {
+	if !r.check(pos, 2) {
+		return 0
+	}
+	return int(binary.LittleEndian.Uint16(r.b[pos:]))
+}
+
+func (r *fbReader) uint32(pos int) uint32 {
+	if !r.check(pos, 4) {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(r.b[pos:])
+}
+
+// deref follows the offset at pos.
+func (r *fbReader) deref(pos int) int {
+	return pos + int(r.uint32(pos))
+}
+
+func (r *fbReader) root() fbTableRef {
+	return fbTableRef{r, r.deref(0)}
+}
+
+// field returns the position of field id, or zero if it is absent.
+func (t fbTableRef) field(id int) int {
+	vt := t.pos - int(int32(t.r.uint32(t.pos)))
+	size := t.r.uint16(vt)
+	if 4+2*id >= size {
+		return 0
+	}
+	off := t.r.uint16(vt + 4 + 2*id)
+	if off == 0 {
+		return 0
+	}
+	return t.pos + off
+}
+
+func (t fbTableRef) uint8(id int, def uint8) uint8 {
+	pos := t.field(id)
+	if pos == 0 || !t.r.check(pos, 1) {
+		return def
+	}
+	return t.r.b[pos]
+}
+
+func (t fbTableRef) bool(id int, def bool) bool {
+	var d uint8
+	if def {
+		d = 1
+	}
+	return t.uint8(id, d) != 0
+}
+
+func (t fbTableRef) uint16(id int, def uint16) uint16 {
+	pos := t.field(id)
+	if pos == 0 {
+		return def
+	}
+	return uint16(t.r.uint16(pos))
+}
+
+func (t fbTableRef) uint64(id int, def uint64) uint64 {
+	pos := t.field(id)
+	if pos == 0 || !t.r.check(pos, 8) {
+		return def
+	}
+	return binary.LittleEndian.Uint64(t.r.b[pos:])
+}
+
+func (t fbTableRef) table(id int) (fbTableRef, bool) {
+	pos := t.field(id)
+	if pos == 0 {
+		return fbTableRef{}, false
+	}
+	return fbTableRef{t.r, t.r.deref(pos)}, true
+}
+
+// vector returns the position and length of a vector of size byte elements.
+func (t fbTableRef) vector(id, size int) (int, int) {
+	pos := t.field(id)
+	if pos == 0 {
+		return 0, 0
+	}
+	pos = t.r.deref(pos)
+	n := int(t.r.uint32(pos))
+	if !t.r.check(pos+4, n*size) {
+		return 0, 0
+	}
+	return pos + 4, n
+}
+
+func (t fbTableRef) bytes(id int) []byte {
+	pos, n := t.vector(id, 1)
+	return t.r.b[pos : pos+n]
+}
+
+func (t fbTableRef) string(id int) string {
+	return string(t.bytes(id))
+}
+
+func (t fbTableRef) doubles(id int) []float64 {
+	pos, n := t.vector(id, 8)
+	if n == 0 {
+		return nil
+	}
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = math.Float64frombits(binary.LittleEndian.Uint64(t.r.b[pos+8*i:]))
+	}
+	return out
+}
+
+func (t fbTableRef) uint32s(id int) []uint32 {
+	pos, n := t.vector(id, 4)
+	if n == 0 {
+		return nil
+	}
+	out := make([]uint32, n)
+	for i := range out {
+		out[i] = binary.LittleEndian.Uint32(t.r.b[pos+4*i:])
+	}
+	return out
+}
+
+func (t fbTableRef) tables(id int) []fbTableRef {
+	pos, n := t.vector(id, 4)
+	out := make([]fbTableRef, n)
+	for i := range out {
+		out[i] = fbTableRef{t.r, t.r.deref(pos + 4*i)}
+	}
+	return out
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v), byte(v>>8))
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	return appendUint32(appendUint32(b, uint32(v)), uint32(v>>32))
+}
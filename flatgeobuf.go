@@ -0,0 +1,1001 @@
+package joejson
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+const (
+	// fgbDefaultNodeSize is the index branching factor used when
+	// FlatGeobufOptions.IndexNodeSize is zero.
+	fgbDefaultNodeSize = 16
+	fgbNodeLen         = 40
+	// fgbMaxDepth bounds GeometryCollection nesting when decoding.
+	fgbMaxDepth = 32
+
+	fgbUnknown            = 0
+	fgbPoint              = 1
+	fgbLineString         = 2
+	fgbPolygon            = 3
+	fgbMultiPoint         = 4
+	fgbMultiLineString    = 5
+	fgbMultiPolygon       = 6
+	fgbGeometryCollection = 7
+
+	fgbByte     = 0
+	fgbUByte    = 1
+	fgbBool     = 2
+	fgbShort    = 3
+	fgbUShort   = 4
+	fgbInt      = 5
+	fgbUInt     = 6
+	fgbLong     = 7
+	fgbULong    = 8
+	fgbFloat    = 9
+	fgbDouble   = 10
+	fgbString   = 11
+	fgbJSON     = 12
+	fgbDateTime = 13
+	fgbBinary   = 14
+)
+
+// fgbMagic is the FlatGeobuf signature for format version 3.0.
+var fgbMagic = []byte{'f', 'g', 'b', 3, 'f', 'g', 'b', 0}
+
+var errFGBShort = errors.New("flatgeobuf: unexpected end of input")
+
+// FlatGeobufOptions configures FlatGeobuf encoding.
+type FlatGeobufOptions struct {
+	// Name is the dataset name stored in the header.
+	Name string
+	// IndexNodeSize is the branching factor of the packed Hilbert R-tree.
+	// Zero means 16 and a negative value writes no index.
+	IndexNodeSize int
+}
+
+type fgbColumn struct {
+	name string
+	typ  uint8
+}
+
+// fgbNode is a packed R-tree node. offset is the byte offset of a leaf's
+// feature, or the index of an internal node's first child.
+type fgbNode struct {
+	bounds bounds
+	offset uint64
+}
+
+// MarshalFlatGeobuf encodes the FeatureCollection as FlatGeobuf. See
+// FeatureCollection.WriteFlatGeobuf.
+func (f FeatureCollection) MarshalFlatGeobuf(opts FlatGeobufOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := f.WriteFlatGeobuf(&buf, opts); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteFlatGeobuf writes the FeatureCollection to w as FlatGeobuf (version 3)
+// in WGS84.
+//
+// Unless opts.IndexNodeSize is negative a packed Hilbert R-tree is written,
+// which requires Features to be stored in Hilbert order of their bounds, so
+// they are not written in slice order.
+//
+// Elevation is stored for every Position or none, so if any Position has
+// one, those without are written with an elevation of zero.
+//
+// Properties become columns: bools, numbers and strings map to their column
+// types and mixed or other values are stored as JSON. Properties with nil
+// values are skipped. Feature IDs are not stored.
+func (f FeatureCollection) WriteFlatGeobuf(w io.Writer, opts FlatGeobufOptions) error {
+	nodeSize := opts.IndexNodeSize
+	switch {
+	case nodeSize == 0:
+		nodeSize = fgbDefaultNodeSize
+	case nodeSize < 0:
+		nodeSize = 0
+	case nodeSize == 1 || nodeSize > math.MaxUint16:
+		return fmt.Errorf("flatgeobuf: invalid index node size %d", nodeSize)
+	}
+	if len(f.Features) == 0 {
+		nodeSize = 0
+	}
+
+	columns, err := fgbColumns(f.Features)
+	if err != nil {
+		return err
+	}
+	typ, hasZ := fgbHeaderType(f.Features)
+
+	boxes := make([]bounds, len(f.Features))
+	extent := emptyBounds()
+	for i, ft := range f.Features {
+		boxes[i], _ = geometryBounds(ft.geometry)
+		extent = extent.extend(boxes[i])
+	}
+	order := make([]int, len(f.Features))
+	for i := range order {
+		order[i] = i
+	}
+	if nodeSize > 0 {
+		values := make([]uint32, len(boxes))
+		for i, b := range boxes {
+			values[i] = fgbHilbertValue(b, extent)
+		}
+		sort.SliceStable(order, func(i, j int) bool {
+			return values[order[i]] < values[order[j]]
+		})
+	}
+
+	features := make([][]byte, len(order))
+	nodes := make([]fgbNode, len(order))
+	var offset uint64
+	for i, j := range order {
+		b, err := fgbFeature(f.Features[j], typ, hasZ, columns)
+		if err != nil {
+			return fmt.Errorf("flatgeobuf: feature %d: %w", j, err)
+		}
+		features[i] = b
+		nodes[i] = fgbNode{bounds: boxes[j], offset: offset}
+		offset += uint64(len(b))
+	}
+
+	envelope := extent
+	if envelope.isEmpty() {
+		envelope = bounds{}
+	}
+	header := fgbHeader(opts.Name, envelope, typ, hasZ, columns, len(f.Features), nodeSize)
+	if _, err := w.Write(fgbMagic); err != nil {
+		return err
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if nodeSize > 0 {
+		if _, err := w.Write(fgbIndex(nodes, nodeSize)); err != nil {
+			return err
+		}
+	}
+	for _, b := range features {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fgbColumns chooses a column for every property key, in key order.
+func fgbColumns(features []Feature) ([]fgbColumn, error) {
+	types := map[string]uint8{}
+	for _, ft := range features {
+		for k, v := range ft.Properties {
+			var t uint8
+			switch v.(type) {
+			case nil:
+				continue
+			case bool:
+				t = fgbBool
+			case string:
+				t = fgbString
+			default:
+				t = fgbJSON
+				if _, ok := dbfNumber(v); ok {
+					t = fgbDouble
+				}
+			}
+			if prev, ok := types[k]; ok && prev != t {
+				t = fgbJSON
+			}
+			types[k] = t
+		}
+	}
+	if len(types) > math.MaxUint16+1 {
+		return nil, fmt.Errorf("flatgeobuf: too many properties (%d)", len(types))
+	}
+
+	columns := make([]fgbColumn, 0, len(types))
+	for k, t := range types {
+		columns = append(columns, fgbColumn{name: k, typ: t})
+	}
+	sort.Slice(columns, func(i, j int) bool {
+		return columns[i].name < columns[j].name
+	})
+	return columns, nil
+}
+
+// fgbHeaderType finds the geometry type shared by all features, or
+// fgbUnknown, and whether any Position has an elevation.
+func fgbHeaderType(features []Feature) (uint8, bool) {
+	typ, hasZ := -1, false
+	for _, ft := range features {
+		if ft.geometry == nil {
+			continue
+		}
+		t := int(fgbGeometryType(ft.geometry))
+		if typ >= 0 && t != typ {
+			t = fgbUnknown
+		}
+		typ = t
+		eachPosition(ft.geometry, func(p Position) {
+			hasZ = hasZ || len(p) > 2
+		})
+	}
+	if typ < 0 {
+		typ = fgbUnknown
+	}
+	return uint8(typ), hasZ
+}
+
+func fgbGeometryType(g any) uint8 {
+	switch g.(type) {
+	case Point:
+		return fgbPoint
+	case LineString:
+		return fgbLineString
+	case Polygon:
+		return fgbPolygon
+	case MultiPoint:
+		return fgbMultiPoint
+	case MultiLineString:
+		return fgbMultiLineString
+	case MultiPolygon:
+		return fgbMultiPolygon
+	case GeometryCollection:
+		return fgbGeometryCollection
+	default:
+		return fgbUnknown
+	}
+}
+
+// sizePrefixed prepends the little-endian length of b.
+func sizePrefixed(b []byte) []byte {
+	return append(appendUint32(make([]byte, 0, 4+len(b)), uint32(len(b))), b...)
+}
+
+func fgbHeader(name string, envelope bounds, typ uint8, hasZ bool, columns []fgbColumn, count, nodeSize int) []byte {
+	cols := make(fbTables, len(columns))
+	for i, c := range columns {
+		cols[i] = fbTable{fbObjectField(fbString(c.name)), fbUint8(c.typ)}
+	}
+	crs := fbTable{fbObjectField(fbString("EPSG")), fbInt32(4326)}
+
+	header := make(fbTable, 11)
+	if name != "" {
+		header[0] = fbObjectField(fbString(name))
+	}
+	header[1] = fbObjectField(fbDoubles([]float64{envelope.minX, envelope.minY, envelope.maxX, envelope.maxY}))
+	header[2] = fbUint8(typ)
+	header[3] = fbBool(hasZ)
+	if len(cols) > 0 {
+		header[7] = fbObjectField(cols)
+	}
+	header[8] = fbUint64(uint64(count))
+	header[9] = fbUint16(uint16(nodeSize))
+	header[10] = fbObjectField(crs)
+	return sizePrefixed(fbFinish(header))
+}
+
+func fgbFeature(ft Feature, typ uint8, hasZ bool, columns []fgbColumn) ([]byte, error) {
+	table := make(fbTable, 2)
+	if ft.geometry != nil {
+		table[0] = fbObjectField(fgbGeometry(ft.geometry, hasZ, typ == fgbUnknown))
+	}
+
+	var props []byte
+	for i, c := range columns {
+		v, ok := ft.Properties[c.name]
+		if !ok || v == nil {
+			continue
+		}
+		props = appendUint16(props, uint16(i))
+		switch c.typ {
+		case fgbBool:
+			if v.(bool) {
+				props = append(props, 1)
+			} else {
+				props = append(props, 0)
+			}
+		case fgbDouble:
+			n, _ := dbfNumber(v)
+			props = appendUint64(props, math.Float64bits(n))
+		case fgbString:
+			s := v.(string)
+			props = append(appendUint32(props, uint32(len(s))), s...)
+		default:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("property %q: %w", c.name, err)
+			}
+			props = append(appendUint32(props, uint32(len(b))), b...)
+		}
+	}
+	if len(props) > 0 {
+		table[1] = fbObjectField(fbScalars{size: 1, data: props})
+	}
+	return sizePrefixed(fbFinish(table)), nil
+}
+
+// fgbGeometry builds a Geometry table. Multi-part geometries flatten their
+// coordinates into xy, with ends marking where each part stops, except
+// MultiPolygon and GeometryCollection, which use one Geometry per part.
+func fgbGeometry(g any, hasZ, typed bool) fbTable {
+	table := make(fbTable, 8)
+	if typed {
+		table[6] = fbUint8(fgbGeometryType(g))
+	}
+
+	var ps []Position
+	var ends []uint32
+	var parts fbTables
+	switch g := g.(type) {
+	case Point:
+		if len(g) > 0 {
+			ps = []Position{Position(g)}
+		}
+	case MultiPoint:
+		ps = g
+	case LineString:
+		ps = g
+	case MultiLineString:
+		for _, ls := range g {
+			ps = append(ps, ls...)
+			ends = append(ends, uint32(len(ps)))
+		}
+	case Polygon:
+		for _, ring := range g {
+			ps = append(ps, ring...)
+			ends = append(ends, uint32(len(ps)))
+		}
+	case MultiPolygon:
+		for _, p := range g {
+			parts = append(parts, fgbGeometry(p, hasZ, true))
+		}
+	case GeometryCollection:
+		for _, m := range g {
+			parts = append(parts, fgbGeometry(m.geometry, hasZ, true))
+		}
+	}
+
+	if len(ends) > 1 {
+		table[0] = fbObjectField(fbUint32s(ends))
+	}
+	if len(ps) > 0 {
+		xy := make([]float64, 0, 2*len(ps))
+		for _, p := range ps {
+			xy = append(xy, p.Lon(), p.Lat())
+		}
+		table[1] = fbObjectField(fbDoubles(xy))
+	}
+	if len(ps) > 0 && hasZ {
+		z := make([]float64, len(ps))
+		for i, p := range ps {
+			if len(p) > 2 {
+				z[i] = p[2]
+			}
+		}
+		table[2] = fbObjectField(fbDoubles(z))
+	}
+	if len(parts) > 0 {
+		table[7] = fbObjectField(parts)
+	}
+	return table
+}
+
+// fgbHilbertValue is the position of the center of b on a Hilbert curve
+// filling extent.
+func fgbHilbertValue(b, extent bounds) uint32 {
+	if b.isEmpty() {
+		return 0
+	}
+	const max = 1<<16 - 1
+	var x, y uint32
+	if w := extent.maxX - extent.minX; w > 0 {
+		x = uint32(max * ((b.minX+b.maxX)/2 - extent.minX) / w)
+	}
+	if h := extent.maxY - extent.minY; h > 0 {
+		y = uint32(max * ((b.minY+b.maxY)/2 - extent.minY) / h)
+	}
+	return hilbert(x, y)
+}
+
+// hilbert maps x and y, each less than 1<<16, to their distance along a
+// Hilbert curve.
+func hilbert(x, y uint32) uint32 {
+	a := x ^ y
+	b := 0xFFFF ^ a
+	c := 0xFFFF ^ (x | y)
+	d := x & (y ^ 0xFFFF)
+
+	A := a | (b >> 1)
+	B := (a >> 1) ^ a
+	C := ((c >> 1) ^ (b & (d >> 1))) ^ c
+	D := ((a & (c >> 1)) ^ (d >> 1)) ^ d
+
+	a, b, c, d = A, B, C, D
+	A = (a & (a >> 2)) ^ (b & (b >> 2))
+	B = (a & (b >> 2)) ^ (b & ((a ^ b) >> 2))
+	C ^= (a & (c >> 2)) ^ (b & (d >> 2))
+	D ^= (b & (c >> 2)) ^ ((a ^ b) & (d >> 2))
+
+	a, b, c, d = A, B, C, D
+	A = (a & (a >> 4)) ^ (b & (b >> 4))
+	B = (a & (b >> 4)) ^ (b & ((a ^ b) >> 4))
+	C ^= (a & (c >> 4)) ^ (b & (d >> 4))
+	D ^= (b & (c >> 4)) ^ ((a ^ b) & (d >> 4))
+
+	a, b, c, d = A, B, C, D
+	C ^= (a & (c >> 8)) ^ (b & (d >> 8))
+	D ^= (b & (c >> 8)) ^ ((a ^ b) & (d >> 8))
+
+	a = C ^ (C >> 1)
+	b = D ^ (D >> 1)
+
+	i0 := x ^ y
+	i1 := b | (0xFFFF ^ (i0 | a))
+
+	i0 = (i0 | (i0 << 8)) & 0x00FF00FF
+	i0 = (i0 | (i0 << 4)) & 0x0F0F0F0F
+	i0 = (i0 | (i0 << 2)) & 0x33333333
+	i0 = (i0 | (i0 << 1)) & 0x55555555
+
+	i1 = (i1 | (i1 << 8)) & 0x00FF00FF
+	i1 = (i1 | (i1 << 4)) & 0x0F0F0F0F
+	i1 = (i1 | (i1 << 2)) & 0x33333333
+	i1 = (i1 | (i1 << 1)) & 0x55555555
+
+	return (i1 << 1) | i0
+}
+
+// fgbLevels returns the node index range of each level of a packed R-tree
+// over n items, leaves first. The root is node zero and the leaves come last.
+func fgbLevels(n, nodeSize int) [][2]int {
+	counts := []int{n}
+	total := n
+	for c := n; c > 1; {
+		c = (c + nodeSize - 1) / nodeSize
+		counts = append(counts, c)
+		total += c
+	}
+	levels := make([][2]int, len(counts))
+	end := total
+	for i, c := range counts {
+		levels[i] = [2]int{end - c, end}
+		end -= c
+	}
+	return levels
+}
+
+// fgbIndex packs the R-tree whose leaves, in file order, are leaves.
+func fgbIndex(leaves []fgbNode, nodeSize int) []byte {
+	levels := fgbLevels(len(leaves), nodeSize)
+	nodes := make([]fgbNode, levels[0][1])
+	copy(nodes[levels[0][0]:], leaves)
+	for i := 0; i < len(levels)-1; i++ {
+		parent := levels[i+1][0]
+		for pos := levels[i][0]; pos < levels[i][1]; parent++ {
+			n := fgbNode{bounds: emptyBounds(), offset: uint64(pos)}
+			for end := minInt(pos+nodeSize, levels[i][1]); pos < end; pos++ {
+				n.bounds = n.bounds.extend(nodes[pos].bounds)
+			}
+			nodes[parent] = n
+		}
+	}
+
+	out := make([]byte, 0, fgbNodeLen*len(nodes))
+	for _, n := range nodes {
+		for _, v := range []float64{n.bounds.minX, n.bounds.minY, n.bounds.maxX, n.bounds.maxY} {
+			out = appendUint64(out, math.Float64bits(v))
+		}
+		out = appendUint64(out, n.offset)
+	}
+	return out
+}
+
+// FlatGeobufReader reads Features one at a time from a FlatGeobuf file,
+// optionally limited to a bounding box using the file's spatial index.
+type FlatGeobufReader struct {
+	r             io.ReadSeeker
+	size          int64
+	name          string
+	envelope      []float64
+	typ           uint8
+	hasZ          bool
+	columns       []fgbColumn
+	count         int
+	nodeSize      int
+	indexStart    int64
+	featuresStart int64
+
+	// next is the offset of the next feature when reading sequentially.
+	next uint64
+	// hits are the offsets of the features left from an indexed Search.
+	hits    []uint64
+	indexed bool
+	// filter is the Search bounds when the file has no index.
+	filter *bounds
+}
+
+// NewFlatGeobufReader reads the header of a FlatGeobuf file.
+func NewFlatGeobufReader(r io.ReadSeeker) (*FlatGeobufReader, error) {
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	fr := &FlatGeobufReader{r: r, size: size}
+
+	prefix, err := fr.readAt(0, len(fgbMagic)+4)
+	if err != nil {
+		return nil, err
+	}
+	if string(prefix[:3]) != "fgb" || prefix[3] != fgbMagic[3] || string(prefix[4:7]) != "fgb" {
+		return nil, errors.New("flatgeobuf: invalid magic bytes")
+	}
+	headerLen := int(binary.LittleEndian.Uint32(prefix[8:]))
+	b, err := fr.readAt(int64(len(prefix)), headerLen)
+	if err != nil {
+		return nil, err
+	}
+	if err := fr.decodeHeader(b); err != nil {
+		return nil, err
+	}
+
+	fr.indexStart = int64(len(prefix) + headerLen)
+	fr.featuresStart = fr.indexStart
+	if fr.count > 0 && fr.nodeSize > 0 {
+		levels := fgbLevels(fr.count, fr.nodeSize)
+		fr.featuresStart += int64(levels[0][1]) * fgbNodeLen
+	}
+	if fr.featuresStart > size {
+		return nil, errFGBShort
+	}
+	return fr, nil
+}
+
+func (fr *FlatGeobufReader) decodeHeader(b []byte) error {
+	r := &fbReader{b: b}
+	h := r.root()
+	fr.name = h.string(0)
+	fr.envelope = h.doubles(1)
+	fr.typ = h.uint8(2, fgbUnknown)
+	fr.hasZ = h.bool(3, false)
+	if h.bool(5, false) || h.bool(6, false) {
+		return errors.New("flatgeobuf: t and tm dimensions are not supported")
+	}
+	cols := h.tables(7)
+	fr.columns = make([]fgbColumn, len(cols))
+	for i, c := range cols {
+		fr.columns[i] = fgbColumn{name: c.string(0), typ: c.uint8(1, fgbByte)}
+	}
+	count := h.uint64(8, 0)
+	fr.nodeSize = int(h.uint16(9, fgbDefaultNodeSize))
+	if r.err != nil {
+		return fmt.Errorf("flatgeobuf: header: %w", r.err)
+	}
+	if fr.typ > fgbGeometryCollection {
+		return fmt.Errorf("flatgeobuf: unsupported geometry type %d", fr.typ)
+	}
+	if count > math.MaxInt32 {
+		return fmt.Errorf("flatgeobuf: invalid feature count %d", count)
+	}
+	if fr.nodeSize == 1 {
+		return errors.New("flatgeobuf: invalid index node size 1")
+	}
+	fr.count = int(count)
+	return nil
+}
+
+// readAt reads n bytes at off.
+func (fr *FlatGeobufReader) readAt(off int64, n int) ([]byte, error) {
+	if n < 0 || off+int64(n) > fr.size {
+		return nil, errFGBShort
+	}
+	if _, err := fr.r.Seek(off, io.SeekStart); err != nil {
+		return nil, err
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(fr.r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Name is the dataset name from the header.
+func (fr *FlatGeobufReader) Name() string {
+	return fr.name
+}
+
+// Len is the number of Features in the file, or zero if the header does
+// not record it.
+func (fr *FlatGeobufReader) Len() int {
+	return fr.count
+}
+
+// Bbox is the south-west, north-east extent of the file's Features, or
+// nil if the header does not record it.
+func (fr *FlatGeobufReader) Bbox() []Position {
+	if len(fr.envelope) < 4 {
+		return nil
+	}
+	return []Position{{fr.envelope[0], fr.envelope[1]}, {fr.envelope[2], fr.envelope[3]}}
+}
+
+// Search restarts reading so that Next only returns Features whose bounds
+// intersect bbox, a pair of south-west and north-east Positions as used by
+// Feature.Bbox. The spatial index is used when the file has one; otherwise
+// every Feature is read and tested.
+func (fr *FlatGeobufReader) Search(bbox []Position) error {
+	b, ok := bboxBounds(bbox)
+	if !ok {
+		return errors.New("flatgeobuf: invalid bbox")
+	}
+	fr.next = 0
+	if fr.featuresStart == fr.indexStart {
+		fr.filter = &b
+		return nil
+	}
+
+	levels := fgbLevels(fr.count, fr.nodeSize)
+	type item struct{ node, level int }
+	var hits []uint64
+	stack := []item{{0, len(levels) - 1}}
+	for len(stack) > 0 {
+		it := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		end := minInt(it.node+fr.nodeSize, levels[it.level][1])
+		nodes, err := fr.readNodes(it.node, end)
+		if err != nil {
+			return err
+		}
+		for _, n := range nodes {
+			if !b.intersects(n.bounds) {
+				continue
+			}
+			if it.level == 0 {
+				hits = append(hits, n.offset)
+				continue
+			}
+			child := levels[it.level-1]
+			if n.offset < uint64(child[0]) || n.offset >= uint64(child[1]) {
+				return fmt.Errorf("flatgeobuf: invalid index node offset %d", n.offset)
+			}
+			stack = append(stack, item{int(n.offset), it.level - 1})
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		return hits[i] < hits[j]
+	})
+	fr.hits, fr.indexed = hits, true
+	return nil
+}
+
+// readNodes reads index nodes start to end.
+func (fr *FlatGeobufReader) readNodes(start, end int) ([]fgbNode, error) {
+	b, err := fr.readAt(fr.indexStart+int64(start)*fgbNodeLen, (end-start)*fgbNodeLen)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]fgbNode, end-start)
+	for i := range nodes {
+		var v [5]uint64
+		for j := range v {
+			v[j] = binary.LittleEndian.Uint64(b[fgbNodeLen*i+8*j:])
+		}
+		nodes[i] = fgbNode{
+			bounds: bounds{
+				math.Float64frombits(v[0]),
+				math.Float64frombits(v[1]),
+				math.Float64frombits(v[2]),
+				math.Float64frombits(v[3]),
+			},
+			offset: v[4],
+		}
+	}
+	return nodes, nil
+}
+
+// Next returns the next Feature, or io.EOF when there are no more.
+func (fr *FlatGeobufReader) Next() (Feature, error) {
+	for {
+		off := fr.next
+		if fr.indexed {
+			if len(fr.hits) == 0 {
+				return Feature{}, io.EOF
+			}
+			off, fr.hits = fr.hits[0], fr.hits[1:]
+		}
+
+		start := fr.featuresStart + int64(off)
+		if start == fr.size && !fr.indexed {
+			return Feature{}, io.EOF
+		}
+		prefix, err := fr.readAt(start, 4)
+		if err != nil {
+			return Feature{}, err
+		}
+		n := binary.LittleEndian.Uint32(prefix)
+		b, err := fr.readAt(start+4, int(n))
+		if err != nil {
+			return Feature{}, err
+		}
+		fr.next = off + 4 + uint64(n)
+
+		ft, err := fr.decodeFeature(b)
+		if err != nil {
+			return Feature{}, err
+		}
+		if fr.filter != nil {
+			if b, ok := geometryBounds(ft.geometry); !ok || !b.intersects(*fr.filter) {
+				continue
+			}
+		}
+		return ft, nil
+	}
+}
+
+// UnmarshalFlatGeobuf decodes every Feature of a FlatGeobuf file.
+func UnmarshalFlatGeobuf(b []byte) (FeatureCollection, error) {
+	fr, err := NewFlatGeobufReader(bytes.NewReader(b))
+	if err != nil {
+		return FeatureCollection{}, err
+	}
+	fc := FeatureCollection{Features: make([]Feature, 0, fr.Len())}
+	for {
+		ft, err := fr.Next()
+		if err == io.EOF {
+			return fc, nil
+		}
+		if err != nil {
+			return FeatureCollection{}, err
+		}
+		fc.Features = append(fc.Features, ft)
+	}
+}
+
+func (fr *FlatGeobufReader) decodeFeature(b []byte) (Feature, error) {
+	r := &fbReader{b: b}
+	t := r.root()
+	var ft Feature
+	if g, ok := t.table(0); ok {
+		geometry, err := fr.decodeGeometry(g, fr.typ, 0)
+		if err != nil {
+			return Feature{}, err
+		}
+		ft.geometry = geometry
+	}
+
+	columns := fr.columns
+	if cols := t.tables(2); len(cols) > 0 {
+		columns = make([]fgbColumn, len(cols))
+		for i, c := range cols {
+			columns[i] = fgbColumn{name: c.string(0), typ: c.uint8(1, fgbByte)}
+		}
+	}
+	props, err := decodeFGBProperties(t.bytes(1), columns)
+	if r.err != nil {
+		return Feature{}, fmt.Errorf("flatgeobuf: feature: %w", r.err)
+	}
+	if err != nil {
+		return Feature{}, err
+	}
+	ft.Properties = props
+	return ft, nil
+}
+
+func (fr *FlatGeobufReader) decodeGeometry(g fbTableRef, typ uint8, depth int) (any, error) {
+	if depth > fgbMaxDepth {
+		return nil, errors.New("flatgeobuf: geometry nested too deeply")
+	}
+	if t := g.uint8(6, fgbUnknown); t != fgbUnknown {
+		typ = t
+	}
+
+	xy := g.doubles(1)
+	z := g.doubles(2)
+	ends := g.uint32s(0)
+	if g.r.err != nil {
+		return nil, fmt.Errorf("flatgeobuf: geometry: %w", g.r.err)
+	}
+	if len(xy)%2 != 0 || (fr.hasZ && len(z) != len(xy)/2 && len(z) != 0) {
+		return nil, errors.New("flatgeobuf: mismatched coordinate arrays")
+	}
+	ps := make([]Position, len(xy)/2)
+	for i := range ps {
+		if len(z) > 0 {
+			ps[i] = Position{xy[2*i], xy[2*i+1], z[i]}
+		} else {
+			ps[i] = Position{xy[2*i], xy[2*i+1]}
+		}
+	}
+	split := func() ([][]Position, error) {
+		if len(ends) == 0 {
+			if len(ps) == 0 {
+				return nil, nil
+			}
+			return [][]Position{ps}, nil
+		}
+		out := make([][]Position, len(ends))
+		start := 0
+		for i, end := range ends {
+			if int(end) < start || int(end) > len(ps) {
+				return nil, fmt.Errorf("flatgeobuf: invalid part end %d", end)
+			}
+			out[i] = ps[start:end:end]
+			start = int(end)
+		}
+		return out, nil
+	}
+
+	switch typ {
+	case fgbPoint:
+		if len(ps) == 0 {
+			return Point{}, nil
+		}
+		return Point(ps[0]), nil
+	case fgbMultiPoint:
+		return MultiPoint(ps), nil
+	case fgbLineString:
+		return LineString(ps), nil
+	case fgbMultiLineString:
+		parts, err := split()
+		if err != nil {
+			return nil, err
+		}
+		out := make(MultiLineString, len(parts))
+		for i, p := range parts {
+			out[i] = p
+		}
+		return out, nil
+	case fgbPolygon:
+		parts, err := split()
+		if err != nil {
+			return nil, err
+		}
+		out := make(Polygon, len(parts))
+		for i, p := range parts {
+			out[i] = p
+		}
+		return out, nil
+	case fgbMultiPolygon:
+		parts := g.tables(7)
+		out := make(MultiPolygon, len(parts))
+		for i, part := range parts {
+			p, err := fr.decodeGeometry(part, fgbPolygon, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			pl, ok := p.(Polygon)
+			if !ok {
+				return nil, fmt.Errorf("flatgeobuf: unexpected %s in MultiPolygon", geometryType(p))
+			}
+			out[i] = pl
+		}
+		return out, nil
+	case fgbGeometryCollection:
+		parts := g.tables(7)
+		out := make(GeometryCollection, len(parts))
+		for i, part := range parts {
+			m, err := fr.decodeGeometry(part, fgbUnknown, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = GeometryCollectionMember{geometry: m}
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("flatgeobuf: unsupported geometry type %d", typ)
+	}
+}
+
+// decodeFGBProperties decodes a feature's properties buffer: a sequence of
+// column indexes, each followed by its value. Numbers decode as float64.
+func decodeFGBProperties(b []byte, columns []fgbColumn) (map[string]any, error) {
+	if len(columns) == 0 {
+		return nil, nil
+	}
+	props := make(map[string]any)
+	next := func(n int) ([]byte, error) {
+		if n > len(b) {
+			return nil, errFGBShort
+		}
+		v := b[:n]
+		b = b[n:]
+		return v, nil
+	}
+	for len(b) > 0 {
+		ib, err := next(2)
+		if err != nil {
+			return nil, err
+		}
+		i := int(binary.LittleEndian.Uint16(ib))
+		if i >= len(columns) {
+			return nil, fmt.Errorf("flatgeobuf: invalid column index %d", i)
+		}
+		c := columns[i]
+
+		var v any
+		switch c.typ {
+		case fgbByte, fgbUByte, fgbBool:
+			vb, err := next(1)
+			if err != nil {
+				return nil, err
+			}
+			switch c.typ {
+			case fgbByte:
+				v = float64(int8(vb[0]))
+			case fgbUByte:
+				v = float64(vb[0])
+			default:
+				v = vb[0] != 0
+			}
+		case fgbShort, fgbUShort:
+			vb, err := next(2)
+			if err != nil {
+				return nil, err
+			}
+			u := binary.LittleEndian.Uint16(vb)
+			if c.typ == fgbShort {
+				v = float64(int16(u))
+			} else {
+				v = float64(u)
+			}
+		case fgbInt, fgbUInt, fgbFloat:
+			vb, err := next(4)
+			if err != nil {
+				return nil, err
+			}
+			u := binary.LittleEndian.Uint32(vb)
+			switch c.typ {
+			case fgbInt:
+				v = float64(int32(u))
+			case fgbUInt:
+				v = float64(u)
+			default:
+				v = float64(math.Float32frombits(u))
+			}
+		case fgbLong, fgbULong, fgbDouble:
+			vb, err := next(8)
+			if err != nil {
+				return nil, err
+			}
+			u := binary.LittleEndian.Uint64(vb)
+			switch c.typ {
+			case fgbLong:
+				v = float64(int64(u))
+			case fgbULong:
+				v = float64(u)
+			default:
+				v = math.Float64frombits(u)
+			}
+		case fgbString, fgbJSON, fgbDateTime, fgbBinary:
+			nb, err := next(4)
+			if err != nil {
+				return nil, err
+			}
+			vb, err := next(int(binary.LittleEndian.Uint32(nb)))
+			if err != nil {
+				return nil, err
+			}
+			switch c.typ {
+			case fgbJSON:
+				if err := json.Unmarshal(vb, &v); err != nil {
+					return nil, fmt.Errorf("flatgeobuf: property %q: %w", c.name, err)
+				}
+			case fgbBinary:
+				v = append([]byte(nil), vb...)
+			default:
+				v = string(vb)
+			}
+		default:
+			return nil, fmt.Errorf("flatgeobuf: column %q: unsupported type %d", c.name, c.typ)
+		}
+		props[c.name] = v
+	}
+	return props, nil
+}
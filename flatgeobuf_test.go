@@ -0,0 +1,158 @@
+package joejson
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlatGeobuf(t *testing.T) {
+	testCases := map[string]FeatureCollection{
+		"Point": {Features: []Feature{
+			Feature{Properties: map[string]any{"name": "a", "n": 1.5, "ok": true}}.WithPoint(Point{1, 2}),
+			Feature{Properties: map[string]any{"name": "b", "tags": []any{"x", "y"}}}.WithPoint(Point{}),
+			{Properties: map[string]any{"n": "mixed"}},
+		}},
+		"MultiPoint Z": {Features: []Feature{
+			Feature{}.WithMultiPoint(MultiPoint{{1, 2, 3}, {4, 5, 6}}),
+		}},
+		"LineString": {Features: []Feature{
+			Feature{}.WithLineString(LineString{{1, 2}, {3, 4}, {5, 6}}),
+		}},
+		"MultiLineString": {Features: []Feature{
+			Feature{}.WithMultiLineString(MultiLineString{{{1, 2}, {3, 4}}}),
+			Feature{}.WithMultiLineString(MultiLineString{{{1, 2}, {3, 4}}, {{5, 6}, {7, 8}, {9, 10}}}),
+		}},
+		"Polygon": {Features: []Feature{
+			Feature{}.WithPolygon(Polygon{
+				{{0, 0}, {4, 0}, {4, 4}, {0, 4}, {0, 0}},
+				{{1, 1}, {1, 2}, {2, 2}, {2, 1}, {1, 1}},
+			}),
+		}},
+		"MultiPolygon": {Features: []Feature{
+			Feature{}.WithMultiPolygon(MultiPolygon{
+				{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}},
+				{{{10, 10}, {11, 10}, {11, 11}, {10, 10}}},
+			}),
+		}},
+		"mixed": {Features: []Feature{
+			Feature{}.WithPoint(Point{1, 2, 0}),
+			Feature{}.WithGeometryCollection(GeometryCollection{
+				{geometry: LineString{{1, 2, 3}, {4, 5, 6}}},
+				{geometry: MultiPolygon{{{{0, 0, 1}, {1, 0, 1}, {1, 1, 1}, {0, 0, 1}}}}},
+			}),
+		}},
+	}
+	for name, fc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			b, err := fc.MarshalFlatGeobuf(FlatGeobufOptions{Name: name, IndexNodeSize: -1})
+			assert.NoError(t, err)
+			assert.Equal(t, fgbMagic, b[:8])
+
+			got, err := UnmarshalFlatGeobuf(b)
+			assert.NoError(t, err)
+			assert.Equal(t, fc, got)
+
+			fr, err := NewFlatGeobufReader(bytes.NewReader(b))
+			assert.NoError(t, err)
+			assert.Equal(t, name, fr.Name())
+			assert.Equal(t, len(fc.Features), fr.Len())
+		})
+	}
+
+	t.Run("alignment", func(t *testing.T) {
+		b, err := testCases["mixed"].MarshalFlatGeobuf(FlatGeobufOptions{})
+		assert.NoError(t, err)
+		headerLen := binary.LittleEndian.Uint32(b[8:])
+		r := &fbReader{b: b[12 : 12+headerLen]}
+		pos, n := r.root().vector(1, 8)
+		assert.NoError(t, r.err)
+		assert.Equal(t, 4, n)
+		assert.Zero(t, pos%8)
+	})
+
+	t.Run("Bbox", func(t *testing.T) {
+		b, err := testCases["MultiLineString"].MarshalFlatGeobuf(FlatGeobufOptions{})
+		assert.NoError(t, err)
+		fr, err := NewFlatGeobufReader(bytes.NewReader(b))
+		assert.NoError(t, err)
+		assert.Equal(t, []Position{{1, 2}, {9, 10}}, fr.Bbox())
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		b, err := FeatureCollection{}.MarshalFlatGeobuf(FlatGeobufOptions{})
+		assert.NoError(t, err)
+		got, err := UnmarshalFlatGeobuf(b)
+		assert.NoError(t, err)
+		assert.Empty(t, got.Features)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := FeatureCollection{}.MarshalFlatGeobuf(FlatGeobufOptions{IndexNodeSize: 1})
+		assert.EqualError(t, err, "flatgeobuf: invalid index node size 1")
+
+		_, err = UnmarshalFlatGeobuf([]byte("fgb\x02fgb\x00\x00\x00\x00\x00"))
+		assert.EqualError(t, err, "flatgeobuf: invalid magic bytes")
+
+		b, err := testCases["Polygon"].MarshalFlatGeobuf(FlatGeobufOptions{})
+		assert.NoError(t, err)
+		_, err = UnmarshalFlatGeobuf(b[:len(b)-1])
+		assert.ErrorIs(t, err, errFGBShort)
+		_, err = UnmarshalFlatGeobuf(b[:20])
+		assert.ErrorIs(t, err, errFGBShort)
+	})
+}
+
+func TestFlatGeobufSearch(t *testing.T) {
+	var fc FeatureCollection
+	for x := 0; x < 10; x++ {
+		for y := 0; y < 10; y++ {
+			props := map[string]any{"id": float64(10*x + y)}
+			fc.Features = append(fc.Features, Feature{Properties: props}.WithPoint(Point{float64(x), float64(y)}))
+		}
+	}
+	fc.Features = append(fc.Features, Feature{Properties: map[string]any{"id": -1.0}})
+
+	for name, nodeSize := range map[string]int{"index": 4, "default index": 0, "no index": -1} {
+		t.Run(name, func(t *testing.T) {
+			b, err := fc.MarshalFlatGeobuf(FlatGeobufOptions{IndexNodeSize: nodeSize})
+			assert.NoError(t, err)
+
+			all, err := UnmarshalFlatGeobuf(b)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, fc.Features, all.Features)
+
+			fr, err := NewFlatGeobufReader(bytes.NewReader(b))
+			assert.NoError(t, err)
+			for _, search := range []struct {
+				bbox []Position
+				want []float64
+			}{
+				{[]Position{{2, 2}, {4, 3}}, []float64{22, 23, 32, 33, 42, 43}},
+				{[]Position{{8.5, 8.5}, {20, 20}}, []float64{99}},
+				{[]Position{{20, 20}, {30, 30}}, nil},
+			} {
+				assert.NoError(t, fr.Search(search.bbox))
+				var got []float64
+				for {
+					ft, err := fr.Next()
+					if err == io.EOF {
+						break
+					}
+					assert.NoError(t, err)
+					got = append(got, ft.Properties["id"].(float64))
+				}
+				assert.ElementsMatch(t, search.want, got)
+			}
+		})
+	}
+}
+
+func TestFGBLevels(t *testing.T) {
+	assert.Equal(t, [][2]int{{0, 1}}, fgbLevels(1, 16))
+	assert.Equal(t, [][2]int{{8, 108}, {1, 8}, {0, 1}}, fgbLevels(100, 16))
+	assert.Equal(t, [][2]int{{1, 17}, {0, 1}}, fgbLevels(16, 16))
+}
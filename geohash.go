@@ -0,0 +1,237 @@
+package joejson
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	geohashAlphabet     = "0123456789bcdefghjkmnpqrstuvwxyz"
+	geohashMaxPrecision = 12
+	// geohashMaxCover bounds the number of cells GeohashCover will test.
+	geohashMaxCover = 1 << 20
+)
+
+// geohashCell is a cell of the geohash grid at some precision, x counting
+// eastwards from the antimeridian and y northwards from the south pole.
+type geohashCell struct {
+	x, y      uint64
+	precision int
+}
+
+// Geohash encodes the Point as a geohash of precision characters (1 to 12).
+func (p Point) Geohash(precision int) (string, error) {
+	if len(p) < 2 {
+		return "", errors.New("geohash: empty Point")
+	}
+	c, err := geohashCellAt(Position(p), precision)
+	if err != nil {
+		return "", err
+	}
+	return c.String(), nil
+}
+
+// DecodeGeohash returns the cell a geohash names as a rectangular Polygon.
+func DecodeGeohash(hash string) (Polygon, error) {
+	c, err := parseGeohash(hash)
+	if err != nil {
+		return nil, err
+	}
+	return c.bounds().polygon(), nil
+}
+
+// GeohashNeighbors returns the eight cells adjacent to hash, clockwise from
+// north: N, NE, E, SE, S, SW, W and NW. Longitude wraps at the antimeridian;
+// cells beyond a pole are empty strings.
+func GeohashNeighbors(hash string) ([8]string, error) {
+	var out [8]string
+	c, err := parseGeohash(hash)
+	if err != nil {
+		return out, err
+	}
+	offsets := [8][2]int{{0, 1}, {1, 1}, {1, 0}, {1, -1}, {0, -1}, {-1, -1}, {-1, 0}, {-1, 1}}
+	for i, o := range offsets {
+		if n, ok := c.neighbor(o[0], o[1]); ok {
+			out[i] = n.String()
+		}
+	}
+	return out, nil
+}
+
+// GeohashMultiPolygon returns the cells hashes name as a MultiPolygon,
+// for example to draw the result of Polygon.GeohashCover.
+func GeohashMultiPolygon(hashes []string) (MultiPolygon, error) {
+	out := make(MultiPolygon, len(hashes))
+	for i, h := range hashes {
+		p, err := DecodeGeohash(h)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = p
+	}
+	return out, nil
+}
+
+// GeohashCover returns, in sorted order, the geohashes of precision
+// characters whose cells overlap the Polygon. Cells only touching its
+// boundary or lying inside a hole are left out.
+func (p Polygon) GeohashCover(precision int) ([]string, error) {
+	b, ok := geometryBounds(p)
+	if !ok {
+		return nil, nil
+	}
+	lo, err := geohashCellAt(Position{b.minX, b.minY}, precision)
+	if err != nil {
+		return nil, err
+	}
+	hi, err := geohashCellAt(Position{b.maxX, b.maxY}, precision)
+	if err != nil {
+		return nil, err
+	}
+	if n := (hi.x - lo.x + 1) * (hi.y - lo.y + 1); n > geohashMaxCover {
+		return nil, fmt.Errorf("geohash: cover needs %d cells at precision %d", n, precision)
+	}
+
+	var out []string
+	for x := lo.x; x <= hi.x; x++ {
+		for y := lo.y; y <= hi.y; y++ {
+			c := geohashCell{x, y, precision}
+			cb := c.bounds()
+			if clippedArea(p, cb) > cb.area()*1e-9 {
+				out = append(out, c.String())
+			}
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// clippedArea is the area of the part of p inside b.
+func clippedArea(p Polygon, b bounds) float64 {
+	var area float64
+	for i, ring := range p {
+		c := clipRing(ring, b)
+		switch {
+		case c == nil && i == 0:
+			return 0
+		case i == 0:
+			area = math.Abs(ringArea(c))
+		case c != nil:
+			area -= math.Abs(ringArea(c))
+		}
+	}
+	return area
+}
+
+// geohashBits splits a geohash's bits between longitude and latitude,
+// longitude taking the extra bit when the count is odd.
+func geohashBits(precision int) (lonBits, latBits int) {
+	n := 5 * precision
+	return (n + 1) / 2, n / 2
+}
+
+func geohashCellAt(p Position, precision int) (geohashCell, error) {
+	if precision < 1 || precision > geohashMaxPrecision {
+		return geohashCell{}, fmt.Errorf("geohash: precision %d out of range [1, %d]", precision, geohashMaxPrecision)
+	}
+	lon, lat := p.Lon(), p.Lat()
+	if !(lon >= -180 && lon <= 180 && lat >= -90 && lat <= 90) {
+		return geohashCell{}, fmt.Errorf("geohash: position %v out of range", []float64(p))
+	}
+	lonBits, latBits := geohashBits(precision)
+	return geohashCell{
+		x:         geohashIndex((lon+180)/360, lonBits),
+		y:         geohashIndex((lat+90)/180, latBits),
+		precision: precision,
+	}, nil
+}
+
+// geohashIndex is the cell containing fraction f of a range split into
+// 1<<bits cells, with f == 1 falling in the last.
+func geohashIndex(f float64, bits int) uint64 {
+	n := uint64(1) << bits
+	i := uint64(f * float64(n))
+	if i >= n {
+		i = n - 1
+	}
+	return i
+}
+
+func parseGeohash(hash string) (geohashCell, error) {
+	if len(hash) < 1 || len(hash) > geohashMaxPrecision {
+		return geohashCell{}, fmt.Errorf("geohash: invalid length %d", len(hash))
+	}
+	c := geohashCell{precision: len(hash)}
+	for i := 0; i < len(hash); i++ {
+		ch := hash[i]
+		if ch >= 'A' && ch <= 'Z' {
+			ch += 'a' - 'A'
+		}
+		v := -1
+		for j := 0; j < len(geohashAlphabet); j++ {
+			if geohashAlphabet[j] == ch {
+				v = j
+				break
+			}
+		}
+		if v < 0 {
+			return geohashCell{}, fmt.Errorf("geohash: invalid character %q", hash[i])
+		}
+		for bit := 4; bit >= 0; bit-- {
+			b := uint64(v>>bit) & 1
+			if (5*i+4-bit)%2 == 0 {
+				c.x = c.x<<1 | b
+			} else {
+				c.y = c.y<<1 | b
+			}
+		}
+	}
+	return c, nil
+}
+
+// String interleaves the cell's bits, longitude first, into base 32.
+func (c geohashCell) String() string {
+	lonBits, latBits := geohashBits(c.precision)
+	out := make([]byte, c.precision)
+	v := 0
+	for i := 0; i < 5*c.precision; i++ {
+		var b uint64
+		if i%2 == 0 {
+			lonBits--
+			b = c.x >> lonBits & 1
+		} else {
+			latBits--
+			b = c.y >> latBits & 1
+		}
+		v = v<<1 | int(b)
+		if i%5 == 4 {
+			out[i/5] = geohashAlphabet[v]
+			v = 0
+		}
+	}
+	return string(out)
+}
+
+func (c geohashCell) bounds() bounds {
+	lonBits, latBits := geohashBits(c.precision)
+	w := 360 / float64(uint64(1)<<lonBits)
+	h := 180 / float64(uint64(1)<<latBits)
+	minX := -180 + float64(c.x)*w
+	minY := -90 + float64(c.y)*h
+	return bounds{minX, minY, minX + w, minY + h}
+}
+
+// neighbor is the cell dx columns east and dy rows north, wrapping in
+// longitude and reporting false beyond a pole.
+func (c geohashCell) neighbor(dx, dy int) (geohashCell, bool) {
+	lonBits, latBits := geohashBits(c.precision)
+	cols, rows := int64(1)<<lonBits, int64(1)<<latBits
+	y := int64(c.y) + int64(dy)
+	if y < 0 || y >= rows {
+		return geohashCell{}, false
+	}
+	x := ((int64(c.x)+int64(dx))%cols + cols) % cols
+	return geohashCell{uint64(x), uint64(y), c.precision}, true
+}
@@ -0,0 +1,111 @@
+package joejson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeohash(t *testing.T) {
+	testCases := map[string]struct {
+		p         Point
+		precision int
+		want      string
+	}{
+		"precision 5":  {Point{-5.6, 42.6}, 5, "ezs42"},
+		"precision 11": {Point{10.40744, 57.64911}, 11, "u4pruydqqvj"},
+		"origin":       {Point{0, 0}, 1, "s"},
+		"north east":   {Point{180, 90}, 12, "zzzzzzzzzzzz"},
+		"south west":   {Point{-180, -90}, 3, "000"},
+	}
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := tt.p.Geohash(tt.precision)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			cell, err := DecodeGeohash(got)
+			assert.NoError(t, err)
+			b, _ := geometryBounds(cell)
+			assert.True(t, b.containsPosition(Position(tt.p)))
+		})
+	}
+
+	t.Run("DecodeGeohash", func(t *testing.T) {
+		got, err := DecodeGeohash("EZS42")
+		assert.NoError(t, err)
+		assert.Equal(t, Polygon{{
+			{-5.625, 42.5830078125},
+			{-5.5810546875, 42.5830078125},
+			{-5.5810546875, 42.626953125},
+			{-5.625, 42.626953125},
+			{-5.625, 42.5830078125},
+		}}, got)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := Point{1, 2}.Geohash(13)
+		assert.EqualError(t, err, "geohash: precision 13 out of range [1, 12]")
+		_, err = Point{181, 2}.Geohash(5)
+		assert.EqualError(t, err, "geohash: position [181 2] out of range")
+		_, err = Point{}.Geohash(5)
+		assert.EqualError(t, err, "geohash: empty Point")
+		_, err = DecodeGeohash("ezs4a")
+		assert.EqualError(t, err, `geohash: invalid character 'a'`)
+		_, err = DecodeGeohash("")
+		assert.EqualError(t, err, "geohash: invalid length 0")
+	})
+}
+
+func TestGeohashNeighbors(t *testing.T) {
+	got, err := GeohashNeighbors("dqcjq")
+	assert.NoError(t, err)
+	assert.Equal(t, [8]string{"dqcjw", "dqcjx", "dqcjr", "dqcjp", "dqcjn", "dqcjj", "dqcjm", "dqcjt"}, got)
+
+	got, err = GeohashNeighbors("zzzz")
+	assert.NoError(t, err)
+	wrapped, err := Point{-179.99, 89.99}.Geohash(4)
+	assert.NoError(t, err)
+	assert.Equal(t, "", got[0])
+	assert.Equal(t, wrapped, got[2])
+	assert.Equal(t, "", got[7])
+}
+
+func TestGeohashCover(t *testing.T) {
+	t.Run("exact cells", func(t *testing.T) {
+		p := Polygon{{{0, 0}, {90, 0}, {90, 45}, {0, 45}, {0, 0}}}
+		got, err := p.GeohashCover(1)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"s", "t"}, got)
+
+		mp, err := GeohashMultiPolygon(got)
+		assert.NoError(t, err)
+		assert.Equal(t, MultiPolygon{
+			{{{0, 0}, {45, 0}, {45, 45}, {0, 45}, {0, 0}}},
+			{{{45, 0}, {90, 0}, {90, 45}, {45, 45}, {45, 0}}},
+		}, mp)
+	})
+
+	t.Run("hole", func(t *testing.T) {
+		hole, err := DecodeGeohash("s7")
+		assert.NoError(t, err)
+		p := Polygon{{{0, 0}, {45, 0}, {45, 45}, {0, 45}, {0, 0}}, reversePositions(hole[0])}
+		got, err := p.GeohashCover(2)
+		assert.NoError(t, err)
+		assert.Len(t, got, 31)
+		assert.NotContains(t, got, "s7")
+	})
+
+	t.Run("triangle", func(t *testing.T) {
+		p := Polygon{{{0, 0}, {90, 0}, {0, 90}, {0, 0}}}
+		got, err := p.GeohashCover(1)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"s", "t", "u"}, got)
+	})
+
+	t.Run("too many cells", func(t *testing.T) {
+		p := Polygon{{{0, 0}, {10, 0}, {10, 10}, {0, 0}}}
+		_, err := p.GeohashCover(9)
+		assert.ErrorContains(t, err, "geohash: cover needs")
+	})
+}
@@ -119,6 +119,12 @@ func (g *GeometryCollectionMember) AsMultiPolygon() (MultiPolygon, bool) {
 	return p, ok
 }
 
+// AsGeometryCollection casts the Geometry to a GeometryCollection.
+func (g GeometryCollectionMember) AsGeometryCollection() (GeometryCollection, bool) {
+	p, ok := g.geometry.(GeometryCollection)
+	return p, ok
+}
+
 // MarshalJSON is a custom JSON marshaller.
 func (g GeometryCollectionMember) MarshalJSON() ([]byte, error) {
 	return json.Marshal(g.geometry)
@@ -126,20 +132,5 @@ func (g GeometryCollectionMember) MarshalJSON() ([]byte, error) {
 
 // Type is the type of the Geometry.
 func (g GeometryCollectionMember) Type() string {
-	switch g.geometry.(type) {
-	case Point:
-		return GeometryTypePoint
-	case MultiPoint:
-		return GeometryTypeMultiPoint
-	case LineString:
-		return GeometryTypeLineString
-	case MultiLineString:
-		return GeometryTypeMultiLineString
-	case Polygon:
-		return GeometryTypePolygon
-	case MultiPolygon:
-		return GeometryTypeMultiPolygon
-	default:
-		return ""
-	}
+	return geometryType(g.geometry)
 }
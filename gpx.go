@@ -0,0 +1,257 @@
+package joejson
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+const gpxNamespace = "http://www.topografix.com/GPX/1/1"
+
+type gpxDocument struct {
+	XMLName   xml.Name   `xml:"gpx"`
+	Xmlns     string     `xml:"xmlns,attr,omitempty"`
+	Version   string     `xml:"version,attr,omitempty"`
+	Creator   string     `xml:"creator,attr,omitempty"`
+	Waypoints []gpxPoint `xml:"wpt"`
+	Routes    []gpxRoute `xml:"rte"`
+	Tracks    []gpxTrack `xml:"trk"`
+}
+
+type gpxPoint struct {
+	Lat  float64  `xml:"lat,attr"`
+	Lon  float64  `xml:"lon,attr"`
+	Ele  *float64 `xml:"ele"`
+	Time string   `xml:"time,omitempty"`
+	Name string   `xml:"name,omitempty"`
+	Cmt  string   `xml:"cmt,omitempty"`
+	Desc string   `xml:"desc,omitempty"`
+	Sym  string   `xml:"sym,omitempty"`
+	Type string   `xml:"type,omitempty"`
+}
+
+type gpxRoute struct {
+	Name   string     `xml:"name,omitempty"`
+	Cmt    string     `xml:"cmt,omitempty"`
+	Desc   string     `xml:"desc,omitempty"`
+	Type   string     `xml:"type,omitempty"`
+	Points []gpxPoint `xml:"rtept"`
+}
+
+type gpxTrack struct {
+	Name     string       `xml:"name,omitempty"`
+	Cmt      string       `xml:"cmt,omitempty"`
+	Desc     string       `xml:"desc,omitempty"`
+	Type     string       `xml:"type,omitempty"`
+	Segments []gpxSegment `xml:"trkseg"`
+}
+
+type gpxSegment struct {
+	Points []gpxPoint `xml:"trkpt"`
+}
+
+// UnmarshalGPX decodes a GPX document into a FeatureCollection of waypoint
+// Points, route LineStrings and track MultiLineStrings, in that order.
+//
+// Elevations become the third coordinate of each Position. The name, cmt,
+// desc and type of each item become Properties, as do a waypoint's sym and
+// time. Route and track point times are kept in a "coordTimes" property of
+// type []string or [][]string, with empty strings for missing times.
+func UnmarshalGPX(b []byte) (FeatureCollection, error) {
+	var doc gpxDocument
+	if err := xml.Unmarshal(b, &doc); err != nil {
+		return FeatureCollection{}, fmt.Errorf("gpx: %w", err)
+	}
+
+	out := FeatureCollection{Features: []Feature{}}
+	for _, wpt := range doc.Waypoints {
+		props := gpxProperties(wpt.Name, wpt.Cmt, wpt.Desc, wpt.Type)
+		setNonEmpty(props, "sym", wpt.Sym)
+		setNonEmpty(props, "time", wpt.Time)
+		out.Features = append(out.Features, Feature{Properties: props}.WithPoint(Point(wpt.position())))
+	}
+
+	for _, rte := range doc.Routes {
+		props := gpxProperties(rte.Name, rte.Cmt, rte.Desc, rte.Type)
+		ls, times := gpxLine(rte.Points)
+		if times != nil {
+			props["coordTimes"] = times
+		}
+		out.Features = append(out.Features, Feature{Properties: props}.WithLineString(ls))
+	}
+
+	for _, trk := range doc.Tracks {
+		props := gpxProperties(trk.Name, trk.Cmt, trk.Desc, trk.Type)
+		mls := make(MultiLineString, len(trk.Segments))
+		times := make([][]string, len(trk.Segments))
+		hasTimes := false
+		for i, seg := range trk.Segments {
+			mls[i], times[i] = gpxLine(seg.Points)
+			if times[i] != nil {
+				hasTimes = true
+			} else {
+				times[i] = make([]string, len(seg.Points))
+			}
+		}
+		if hasTimes {
+			props["coordTimes"] = times
+		}
+		out.Features = append(out.Features, Feature{Properties: props}.WithMultiLineString(mls))
+	}
+	return out, nil
+}
+
+// MarshalGPX encodes the FeatureCollection as a GPX 1.1 document. Points and
+// MultiPoints become waypoints, LineStrings routes and MultiLineStrings
+// tracks, reading back the Properties UnmarshalGPX sets; other geometry
+// types and empty Points are an error.
+func (f FeatureCollection) MarshalGPX() ([]byte, error) {
+	doc := gpxDocument{Xmlns: gpxNamespace, Version: "1.1", Creator: "joejson"}
+	for i, ft := range f.Features {
+		switch g := ft.geometry.(type) {
+		case Point:
+			if len(g) == 0 {
+				return nil, fmt.Errorf("gpx: feature %d: Point has 0 coordinates", i)
+			}
+			doc.Waypoints = append(doc.Waypoints, gpxWaypoint(Position(g), ft.Properties))
+		case MultiPoint:
+			for _, p := range g {
+				if len(p) == 0 {
+					return nil, fmt.Errorf("gpx: feature %d: Point has 0 coordinates", i)
+				}
+				doc.Waypoints = append(doc.Waypoints, gpxWaypoint(p, ft.Properties))
+			}
+		case LineString:
+			rte := gpxRoute{
+				Name:   stringProperty(ft.Properties, "name"),
+				Cmt:    stringProperty(ft.Properties, "cmt"),
+				Desc:   stringProperty(ft.Properties, "desc"),
+				Type:   stringProperty(ft.Properties, "type"),
+				Points: gpxPoints(g, stringsProperty(ft.Properties["coordTimes"])),
+			}
+			doc.Routes = append(doc.Routes, rte)
+		case MultiLineString:
+			trk := gpxTrack{
+				Name: stringProperty(ft.Properties, "name"),
+				Cmt:  stringProperty(ft.Properties, "cmt"),
+				Desc: stringProperty(ft.Properties, "desc"),
+				Type: stringProperty(ft.Properties, "type"),
+			}
+			var times []any
+			if ts, ok := ft.Properties["coordTimes"].([]any); ok {
+				times = ts
+			} else if ts, ok := ft.Properties["coordTimes"].([][]string); ok {
+				for _, t := range ts {
+					times = append(times, t)
+				}
+			}
+			for j, ls := range g {
+				var segTimes []string
+				if j < len(times) {
+					segTimes = stringsProperty(times[j])
+				}
+				trk.Segments = append(trk.Segments, gpxSegment{gpxPoints(ls, segTimes)})
+			}
+			doc.Tracks = append(doc.Tracks, trk)
+		default:
+			return nil, fmt.Errorf("gpx: feature %d: unsupported geometry type %q", i, ft.GeometryType())
+		}
+	}
+
+	b, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("gpx: %w", err)
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+func (p gpxPoint) position() Position {
+	if p.Ele != nil {
+		return Position{p.Lon, p.Lat, *p.Ele}
+	}
+	return Position{p.Lon, p.Lat}
+}
+
+// gpxLine converts points to a LineString and their times, which are nil if
+// no point has one.
+func gpxLine(pts []gpxPoint) (LineString, []string) {
+	ls := make(LineString, len(pts))
+	times := make([]string, len(pts))
+	hasTimes := false
+	for i, pt := range pts {
+		ls[i] = pt.position()
+		times[i] = pt.Time
+		hasTimes = hasTimes || pt.Time != ""
+	}
+	if !hasTimes {
+		return ls, nil
+	}
+	return ls, times
+}
+
+func gpxWaypoint(p Position, props map[string]any) gpxPoint {
+	pt := gpxPosition(p, stringProperty(props, "time"))
+	pt.Name = stringProperty(props, "name")
+	pt.Cmt = stringProperty(props, "cmt")
+	pt.Desc = stringProperty(props, "desc")
+	pt.Sym = stringProperty(props, "sym")
+	pt.Type = stringProperty(props, "type")
+	return pt
+}
+
+func gpxPoints(ps []Position, times []string) []gpxPoint {
+	out := make([]gpxPoint, len(ps))
+	for i, p := range ps {
+		var t string
+		if i < len(times) {
+			t = times[i]
+		}
+		out[i] = gpxPosition(p, t)
+	}
+	return out
+}
+
+func gpxPosition(p Position, time string) gpxPoint {
+	pt := gpxPoint{Lat: p.Lat(), Lon: p.Lon(), Time: time}
+	if len(p) > 2 {
+		ele := p.Elevation()
+		pt.Ele = &ele
+	}
+	return pt
+}
+
+func gpxProperties(name, cmt, desc, typ string) map[string]any {
+	props := map[string]any{}
+	setNonEmpty(props, "name", name)
+	setNonEmpty(props, "cmt", cmt)
+	setNonEmpty(props, "desc", desc)
+	setNonEmpty(props, "type", typ)
+	return props
+}
+
+func setNonEmpty(props map[string]any, key, value string) {
+	if value != "" {
+		props[key] = value
+	}
+}
+
+// stringProperty returns the named property if it is a string.
+func stringProperty(props map[string]any, key string) string {
+	s, _ := props[key].(string)
+	return s
+}
+
+// stringsProperty converts a []string or JSON decoded []any property value to []string.
+func stringsProperty(v any) []string {
+	switch v := v.(type) {
+	case []string:
+		return v
+	case []any:
+		out := make([]string, len(v))
+		for i, s := range v {
+			out[i], _ = s.(string)
+		}
+		return out
+	default:
+		return nil
+	}
+}
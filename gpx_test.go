@@ -0,0 +1,79 @@
+package joejson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testGPX = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="test" xmlns="http://www.topografix.com/GPX/1/1">
+  <wpt lat="51.5" lon="-0.1">
+    <ele>11.5</ele>
+    <time>2023-05-01T10:00:00Z</time>
+    <name>Camp</name>
+    <sym>Flag</sym>
+  </wpt>
+  <rte>
+    <name>Route</name>
+    <rtept lat="1" lon="2"></rtept>
+    <rtept lat="3" lon="4"></rtept>
+  </rte>
+  <trk>
+    <name>Track</name>
+    <type>hike</type>
+    <trkseg>
+      <trkpt lat="10" lon="20"><ele>100</ele><time>2023-05-01T10:00:00Z</time></trkpt>
+      <trkpt lat="11" lon="21"><ele>110</ele></trkpt>
+    </trkseg>
+    <trkseg>
+      <trkpt lat="12" lon="22"></trkpt>
+    </trkseg>
+  </trk>
+</gpx>`
+
+func TestGPX(t *testing.T) {
+	fc, err := UnmarshalGPX([]byte(testGPX))
+	assert.NoError(t, err)
+
+	want := FeatureCollection{Features: []Feature{
+		Feature{Properties: map[string]any{
+			"name": "Camp",
+			"sym":  "Flag",
+			"time": "2023-05-01T10:00:00Z",
+		}}.WithPoint(Point{-0.1, 51.5, 11.5}),
+		Feature{Properties: map[string]any{"name": "Route"}}.WithLineString(LineString{{2, 1}, {4, 3}}),
+		Feature{Properties: map[string]any{
+			"name":       "Track",
+			"type":       "hike",
+			"coordTimes": [][]string{{"2023-05-01T10:00:00Z", ""}, {""}},
+		}}.WithMultiLineString(MultiLineString{{{20, 10, 100}, {21, 11, 110}}, {{22, 12}}}),
+	}}
+	assert.Equal(t, want, fc)
+
+	b, err := fc.MarshalGPX()
+	assert.NoError(t, err)
+	got, err := UnmarshalGPX(b)
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	t.Run("unsupported geometry", func(t *testing.T) {
+		_, err := FeatureCollection{Features: []Feature{Feature{}.WithPolygon(Polygon{})}}.MarshalGPX()
+		assert.EqualError(t, err, `gpx: feature 0: unsupported geometry type "Polygon"`)
+	})
+
+	t.Run("empty Point", func(t *testing.T) {
+		_, err := FeatureCollection{Features: []Feature{Feature{}.WithPoint(Point{})}}.MarshalGPX()
+		assert.EqualError(t, err, "gpx: feature 0: Point has 0 coordinates")
+		_, err = FeatureCollection{Features: []Feature{
+			Feature{}.WithPoint(Point{1, 2}),
+			Feature{}.WithMultiPoint(MultiPoint{{1, 2}, {}}),
+		}}.MarshalGPX()
+		assert.EqualError(t, err, "gpx: feature 1: Point has 0 coordinates")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := UnmarshalGPX([]byte(`<gpx><wpt lat="x"`))
+		assert.Error(t, err)
+	})
+}
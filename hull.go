@@ -0,0 +1,260 @@
+package joejson
+
+import (
+	"math"
+	"sort"
+)
+
+// ConvexHull returns the Point itself, or an empty member if it is empty.
+func (p Point) ConvexHull() GeometryCollectionMember {
+	return hullMember(convexHull(hullPositions(p)))
+}
+
+// ConvexHull returns the smallest convex Polygon containing every Position.
+// Fewer than three distinct Positions, or Positions all on one line,
+// give a Point or a LineString between the two furthest apart.
+func (g MultiPoint) ConvexHull() GeometryCollectionMember {
+	return hullMember(convexHull(hullPositions(g)))
+}
+
+// ConvexHull returns the smallest convex Polygon containing the LineString.
+// See MultiPoint.ConvexHull.
+func (g LineString) ConvexHull() GeometryCollectionMember {
+	return hullMember(convexHull(hullPositions(g)))
+}
+
+// ConvexHull returns the smallest convex Polygon containing every LineString.
+// See MultiPoint.ConvexHull.
+func (g MultiLineString) ConvexHull() GeometryCollectionMember {
+	return hullMember(convexHull(hullPositions(g)))
+}
+
+// ConvexHull returns the smallest convex Polygon containing the Polygon.
+// See MultiPoint.ConvexHull.
+func (p Polygon) ConvexHull() GeometryCollectionMember {
+	return hullMember(convexHull(hullPositions(p)))
+}
+
+// ConvexHull returns the smallest convex Polygon containing every Polygon.
+// See MultiPoint.ConvexHull.
+func (p MultiPolygon) ConvexHull() GeometryCollectionMember {
+	return hullMember(convexHull(hullPositions(p)))
+}
+
+// ConvexHull returns the smallest convex Polygon containing every member.
+// See MultiPoint.ConvexHull.
+func (g GeometryCollection) ConvexHull() GeometryCollectionMember {
+	return hullMember(convexHull(hullPositions(g)))
+}
+
+// ConvexHull returns the smallest convex Polygon containing the Feature's
+// Geometry. See MultiPoint.ConvexHull.
+func (f Feature) ConvexHull() GeometryCollectionMember {
+	return hullMember(convexHull(hullPositions(f.geometry)))
+}
+
+// ConvexHull returns the smallest convex Polygon containing every Feature's
+// Geometry. See MultiPoint.ConvexHull.
+func (f FeatureCollection) ConvexHull() GeometryCollectionMember {
+	return hullMember(convexHull(hullPositions(featureGeometries(f))))
+}
+
+// ConcaveHull returns the Point itself, or an empty member if it is empty.
+func (p Point) ConcaveHull(concavity float64) GeometryCollectionMember {
+	return hullMember(concaveHull(hullPositions(p), concavity))
+}
+
+// ConcaveHull returns a Polygon containing every Position that follows
+// their outline more closely than the convex hull.
+//
+// The hull starts convex, and each edge is replaced by two through the
+// inner Position nearest to it while the edge is longer than concavity
+// times that Position's distance to the nearer end, and no Position is left
+// outside. Lower values give tighter hulls: 2 suits most point clouds,
+// and +Inf keeps the convex hull. Degenerate inputs give a Point or a
+// LineString as for ConvexHull.
+func (g MultiPoint) ConcaveHull(concavity float64) GeometryCollectionMember {
+	return hullMember(concaveHull(hullPositions(g), concavity))
+}
+
+// ConcaveHull returns a Polygon following the outline of the LineString.
+// See MultiPoint.ConcaveHull.
+func (g LineString) ConcaveHull(concavity float64) GeometryCollectionMember {
+	return hullMember(concaveHull(hullPositions(g), concavity))
+}
+
+// ConcaveHull returns a Polygon following the outline of every LineString.
+// See MultiPoint.ConcaveHull.
+func (g MultiLineString) ConcaveHull(concavity float64) GeometryCollectionMember {
+	return hullMember(concaveHull(hullPositions(g), concavity))
+}
+
+// ConcaveHull returns a Polygon following the outline of the Polygon's
+// Positions. See MultiPoint.ConcaveHull.
+func (p Polygon) ConcaveHull(concavity float64) GeometryCollectionMember {
+	return hullMember(concaveHull(hullPositions(p), concavity))
+}
+
+// ConcaveHull returns a Polygon following the outline of every Polygon's
+// Positions. See MultiPoint.ConcaveHull.
+func (p MultiPolygon) ConcaveHull(concavity float64) GeometryCollectionMember {
+	return hullMember(concaveHull(hullPositions(p), concavity))
+}
+
+// ConcaveHull returns a Polygon following the outline of every member.
+// See MultiPoint.ConcaveHull.
+func (g GeometryCollection) ConcaveHull(concavity float64) GeometryCollectionMember {
+	return hullMember(concaveHull(hullPositions(g), concavity))
+}
+
+// ConcaveHull returns a Polygon following the outline of the Feature's
+// Geometry. See MultiPoint.ConcaveHull.
+func (f Feature) ConcaveHull(concavity float64) GeometryCollectionMember {
+	return hullMember(concaveHull(hullPositions(f.geometry), concavity))
+}
+
+// ConcaveHull returns a Polygon following the outline of every Feature's
+// Geometry. See MultiPoint.ConcaveHull.
+func (f FeatureCollection) ConcaveHull(concavity float64) GeometryCollectionMember {
+	return hullMember(concaveHull(hullPositions(featureGeometries(f)), concavity))
+}
+
+func featureGeometries(f FeatureCollection) GeometryCollection {
+	g := make(GeometryCollection, 0, len(f.Features))
+	for _, ft := range f.Features {
+		g = append(g, GeometryCollectionMember{ft.geometry})
+	}
+	return g
+}
+
+// hullPositions returns the distinct Positions of g ordered by longitude
+// then latitude.
+func hullPositions(g any) []Position {
+	var ps []Position
+	eachPosition(g, func(p Position) {
+		ps = append(ps, p)
+	})
+	sort.SliceStable(ps, func(i, j int) bool {
+		if ps[i].Lon() != ps[j].Lon() {
+			return ps[i].Lon() < ps[j].Lon()
+		}
+		return ps[i].Lat() < ps[j].Lat()
+	})
+	return dedupePositions(ps)
+}
+
+// hullMember wraps an open counterclockwise hull as the simplest Geometry.
+func hullMember(hull []Position) GeometryCollectionMember {
+	switch len(hull) {
+	case 0:
+		return GeometryCollectionMember{}
+	case 1:
+		return GeometryCollectionMember{Point(hull[0])}
+	case 2:
+		return GeometryCollectionMember{LineString(hull)}
+	}
+	return GeometryCollectionMember{Polygon{append(LinearRing(hull), hull[0])}}
+}
+
+// convexHull returns the vertices of the convex hull of sorted distinct
+// positions counterclockwise from the first, using Andrew's monotone
+// chain. Positions along its edges are left out, so collinear input gives
+// just the two ends.
+func convexHull(ps []Position) []Position {
+	if len(ps) < 3 {
+		return ps
+	}
+	hull := make([]Position, 0, 2*len(ps))
+	for _, p := range ps {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	lower := len(hull) + 1
+	for i := len(ps) - 2; i >= 0; i-- {
+		p := ps[i]
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	return hull[:len(hull)-1]
+}
+
+// concaveHull digs the convex hull of ps inwards one edge at a time, after
+// Park and Oh's concave hull algorithm.
+func concaveHull(ps []Position, concavity float64) []Position {
+	hull := convexHull(ps)
+	if len(hull) < 3 {
+		return hull
+	}
+	onHull := make(map[[2]float64]bool, len(hull))
+	for _, p := range hull {
+		onHull[[2]float64{p.Lon(), p.Lat()}] = true
+	}
+	var inner []Position
+	for _, p := range ps {
+		if !onHull[[2]float64{p.Lon(), p.Lat()}] {
+			inner = append(inner, p)
+		}
+	}
+	used := make([]bool, len(inner))
+
+	for i := 0; i < len(hull); {
+		j := digCandidate(hull, i, inner, used, concavity)
+		if j < 0 {
+			i++
+			continue
+		}
+		used[j] = true
+		hull = append(hull[:i+1], append([]Position{inner[j]}, hull[i+1:]...)...)
+	}
+	return hull
+}
+
+// digCandidate returns the index of the inner position to put between
+// hull[i] and the next vertex, or -1 if the edge should stay.
+func digCandidate(hull []Position, i int, inner []Position, used []bool, concavity float64) int {
+	n := len(hull)
+	prev, a, b, next := hull[(i+n-1)%n], hull[i], hull[(i+1)%n], hull[(i+2)%n]
+
+	// the nearest position to the edge that is not nearer a neighbouring edge.
+	best, bestDist := -1, math.Inf(1)
+	for j, p := range inner {
+		if used[j] {
+			continue
+		}
+		d := segmentDistance(p, a, b)
+		if d < bestDist && d < segmentDistance(p, prev, a) && d < segmentDistance(p, b, next) {
+			best, bestDist = j, d
+		}
+	}
+	if best < 0 {
+		return -1
+	}
+	p := inner[best]
+	if concavity*math.Min(vecNorm(vecSub(p, a)), vecNorm(vecSub(p, b))) > vecNorm(vecSub(b, a)) {
+		return -1
+	}
+
+	// digging must not leave a position outside or cross the hull.
+	for j, q := range inner {
+		if j != best && !used[j] && cross(a, b, q) >= 0 && cross(b, p, q) > 0 && cross(p, a, q) > 0 {
+			return -1
+		}
+	}
+	for k := range hull {
+		c, d := hull[k], hull[(k+1)%n]
+		if k == i {
+			continue
+		}
+		if !positionsEqual(c, a) && !positionsEqual(d, a) && segmentsIntersect(a, p, c, d) {
+			return -1
+		}
+		if !positionsEqual(c, b) && !positionsEqual(d, b) && segmentsIntersect(p, b, c, d) {
+			return -1
+		}
+	}
+	return best
+}
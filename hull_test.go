@@ -0,0 +1,139 @@
+package joejson
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvexHull(t *testing.T) {
+	testCases := map[string]struct {
+		g interface {
+			ConvexHull() GeometryCollectionMember
+		}
+		want GeometryCollectionMember
+	}{
+		"empty Point":   {Point{}, GeometryCollectionMember{}},
+		"Point":         {Point{1, 2}, GeometryCollectionMember{Point{1, 2}}},
+		"same Position": {MultiPoint{{1, 2}, {1, 2, 5}}, GeometryCollectionMember{Point{1, 2}}},
+		"collinear": {
+			MultiPoint{{2, 2}, {0, 0}, {1, 1}, {3, 3}},
+			GeometryCollectionMember{LineString{{0, 0}, {3, 3}}},
+		},
+		"MultiPoint": {
+			MultiPoint{{0, 0}, {2, 0}, {1, 1}, {2, 2}, {0, 2}, {1, 0}, {1, 3}},
+			GeometryCollectionMember{Polygon{{{0, 0}, {2, 0}, {2, 2}, {1, 3}, {0, 2}, {0, 0}}}},
+		},
+		"LineString": {
+			LineString{{0, 0}, {4, 0}, {1, 1}, {0, 4}},
+			GeometryCollectionMember{Polygon{{{0, 0}, {4, 0}, {0, 4}, {0, 0}}}},
+		},
+		"Polygon": {
+			Polygon{{{0, 0}, {0, 4}, {1, 1}, {4, 0}, {0, 0}}},
+			GeometryCollectionMember{Polygon{{{0, 0}, {4, 0}, {0, 4}, {0, 0}}}},
+		},
+		"MultiPolygon": {
+			MultiPolygon{square(0, 0, 1), square(3, 0, 1)},
+			GeometryCollectionMember{Polygon{{{0, 0}, {4, 0}, {4, 1}, {0, 1}, {0, 0}}}},
+		},
+		"GeometryCollection": {
+			GeometryCollection{{Point{0, 0}}, {LineString{{2, 0}, {1, 2}}}},
+			GeometryCollectionMember{Polygon{{{0, 0}, {2, 0}, {1, 2}, {0, 0}}}},
+		},
+		"Feature": {
+			Feature{}.WithLineString(LineString{{0, 0}, {1, 1}}),
+			GeometryCollectionMember{LineString{{0, 0}, {1, 1}}},
+		},
+		"FeatureCollection": {
+			FeatureCollection{Features: []Feature{
+				Feature{}.WithPoint(Point{0, 0}),
+				{},
+				Feature{}.WithMultiPoint(MultiPoint{{2, 0}, {0, 2}}),
+			}},
+			GeometryCollectionMember{Polygon{{{0, 0}, {2, 0}, {0, 2}, {0, 0}}}},
+		},
+	}
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.g.ConvexHull())
+		})
+	}
+}
+
+func TestConcaveHull(t *testing.T) {
+	// a C shape: the points of a 10 by 10 grid less its middle right part.
+	var c MultiPoint
+	for x := 0; x <= 10; x++ {
+		for y := 0; y <= 10; y++ {
+			if x > 3 && y > 3 && y < 7 {
+				continue
+			}
+			c = append(c, Position{float64(x), float64(y)})
+		}
+	}
+
+	t.Run("follows outline", func(t *testing.T) {
+		hull := c.ConcaveHull(0.5)
+		got, ok := hull.AsPolygon()
+		assert.True(t, ok)
+		assert.Equal(t, 72.0, ringArea(got[0]))
+		assert.False(t, ringContains(got[0], Position{8, 5}))
+
+		// a higher concavity leaves the notch, which is deeper than wide.
+		hull = c.ConcaveHull(2)
+		got, ok = hull.AsPolygon()
+		assert.True(t, ok)
+		assert.Equal(t, 100.0, ringArea(got[0]))
+		for _, p := range c {
+			assert.True(t, ringContains(got[0], p) || onRing(got[0], p), "%v outside", p)
+		}
+	})
+
+	t.Run("infinite concavity is convex", func(t *testing.T) {
+		assert.Equal(t, c.ConvexHull(), c.ConcaveHull(math.Inf(1)))
+	})
+
+	t.Run("degenerate", func(t *testing.T) {
+		assert.Equal(t, GeometryCollectionMember{Point{1, 2}}, MultiPoint{{1, 2}}.ConcaveHull(2))
+		assert.Equal(t,
+			GeometryCollectionMember{LineString{{0, 0}, {2, 0}}},
+			LineString{{0, 0}, {1, 0}, {2, 0}}.ConcaveHull(2),
+		)
+		assert.Equal(t, GeometryCollectionMember{}, FeatureCollection{}.ConcaveHull(2))
+	})
+
+	t.Run("no crossings", func(t *testing.T) {
+		var spiral MultiPoint
+		for i := 0; i < 200; i++ {
+			a := float64(i) * 0.2
+			spiral = append(spiral, Position{a * math.Cos(a), a * math.Sin(a)})
+		}
+		hull := spiral.ConcaveHull(1)
+		got, ok := hull.AsPolygon()
+		assert.True(t, ok)
+		ring := got[0]
+		n := len(ring) - 1
+		for i := 0; i < n; i++ {
+			for j := i + 2; j < n; j++ {
+				if i == 0 && j == n-1 {
+					continue
+				}
+				assert.False(t, segmentsIntersect(ring[i], ring[i+1], ring[j], ring[j+1]), "edges %d and %d cross", i, j)
+			}
+		}
+		for _, p := range spiral {
+			assert.True(t, ringContains(ring, p) || onRing(ring, p), "%v outside", p)
+		}
+	})
+}
+
+// onRing reports whether p lies on an edge of the ring.
+func onRing(ring LinearRing, p Position) bool {
+	for i := 0; i+1 < len(ring); i++ {
+		if segmentDistance(p, ring[i], ring[i+1]) < 1e-9 {
+			return true
+		}
+	}
+	return false
+}
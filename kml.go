@@ -0,0 +1,357 @@
+package joejson
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const kmlNamespace = "http://www.opengis.net/kml/2.2"
+
+type kmlDocument struct {
+	XMLName    xml.Name       `xml:"kml"`
+	Xmlns      string         `xml:"xmlns,attr"`
+	Placemarks []kmlPlacemark `xml:"Document>Placemark"`
+}
+
+type kmlPlacemark struct {
+	ID           string           `xml:"id,attr,omitempty"`
+	Name         string           `xml:"name,omitempty"`
+	Description  string           `xml:"description,omitempty"`
+	ExtendedData *kmlExtendedData `xml:"ExtendedData"`
+	// Geometries collects every other child element; only the geometry
+	// elements among them are used.
+	Geometries []kmlGeometry `xml:",any"`
+}
+
+type kmlExtendedData struct {
+	Data       []kmlData       `xml:"Data"`
+	SchemaData []kmlSchemaData `xml:"SchemaData"`
+}
+
+type kmlSchemaData struct {
+	SimpleData []kmlSimpleData `xml:"SimpleData"`
+}
+
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type kmlSimpleData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:",chardata"`
+}
+
+// kmlGeometry is any KML geometry element, identified by XMLName.
+type kmlGeometry struct {
+	XMLName     xml.Name
+	Coordinates string        `xml:"coordinates,omitempty"`
+	Outer       *kmlBoundary  `xml:"outerBoundaryIs"`
+	Inner       []kmlBoundary `xml:"innerBoundaryIs"`
+	Geometries  []kmlGeometry `xml:",any"`
+}
+
+type kmlBoundary struct {
+	Rings []kmlRing `xml:"LinearRing"`
+}
+
+type kmlRing struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+// UnmarshalKML decodes every Placemark in a KML document, at any Folder
+// depth, into a Feature. A Placemark's id becomes the Feature ID, and its
+// name, description and ExtendedData become string Properties.
+// MultiGeometry elements become GeometryCollections; Placemarks without a
+// geometry have none.
+func UnmarshalKML(b []byte) (FeatureCollection, error) {
+	out := FeatureCollection{Features: []Feature{}}
+	dec := xml.NewDecoder(bytes.NewReader(b))
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return out, nil
+		}
+		if err != nil {
+			return FeatureCollection{}, fmt.Errorf("kml: %w", err)
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok || se.Name.Local != "Placemark" {
+			continue
+		}
+
+		var pm kmlPlacemark
+		if err := dec.DecodeElement(&pm, &se); err != nil {
+			return FeatureCollection{}, fmt.Errorf("kml: %w", err)
+		}
+		ft, err := pm.feature()
+		if err != nil {
+			return FeatureCollection{}, fmt.Errorf("kml: placemark %d: %w", len(out.Features), err)
+		}
+		out.Features = append(out.Features, ft)
+	}
+}
+
+// MarshalKML encodes the FeatureCollection as a KML 2.2 Document of
+// Placemarks. Multi geometries and GeometryCollections become
+// MultiGeometry elements. The "name" and "description" Properties are
+// written as such and any others as ExtendedData, JSON encoding values
+// that are not strings. Empty Points and Polygons, which KML cannot hold,
+// are an error.
+func (f FeatureCollection) MarshalKML() ([]byte, error) {
+	doc := kmlDocument{Xmlns: kmlNamespace, Placemarks: make([]kmlPlacemark, len(f.Features))}
+	for i, ft := range f.Features {
+		pm, err := kmlFeature(ft)
+		if err != nil {
+			return nil, fmt.Errorf("kml: feature %d: %w", i, err)
+		}
+		doc.Placemarks[i] = pm
+	}
+
+	b, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("kml: %w", err)
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+func (pm kmlPlacemark) feature() (Feature, error) {
+	ft := Feature{Properties: map[string]any{}}
+	if pm.ID != "" {
+		ft.ID = pm.ID
+	}
+	setNonEmpty(ft.Properties, "name", strings.TrimSpace(pm.Name))
+	setNonEmpty(ft.Properties, "description", strings.TrimSpace(pm.Description))
+	if ed := pm.ExtendedData; ed != nil {
+		for _, d := range ed.Data {
+			ft.Properties[d.Name] = d.Value
+		}
+		for _, sd := range ed.SchemaData {
+			for _, d := range sd.SimpleData {
+				ft.Properties[d.Name] = d.Value
+			}
+		}
+	}
+
+	for _, g := range pm.Geometries {
+		geom, ok, err := g.geometry()
+		if err != nil {
+			return Feature{}, err
+		}
+		if ok {
+			ft.geometry = geom
+			break
+		}
+	}
+	return ft, nil
+}
+
+// geometry converts g, reporting false if it is not a geometry element.
+func (g kmlGeometry) geometry() (any, bool, error) {
+	switch g.XMLName.Local {
+	case "Point":
+		ps, err := parseKMLCoordinates(g.Coordinates)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(ps) != 1 {
+			return nil, false, fmt.Errorf("Point has %d coordinates", len(ps))
+		}
+		return Point(ps[0]), true, nil
+	case "LineString":
+		ps, err := parseKMLCoordinates(g.Coordinates)
+		if err != nil {
+			return nil, false, err
+		}
+		return LineString(ps), true, nil
+	case "LinearRing":
+		ps, err := parseKMLCoordinates(g.Coordinates)
+		if err != nil {
+			return nil, false, err
+		}
+		return Polygon{ps}, true, nil
+	case "Polygon":
+		if g.Outer == nil || len(g.Outer.Rings) != 1 {
+			return nil, false, errors.New("Polygon needs one outer LinearRing")
+		}
+		var pl Polygon
+		for _, b := range append([]kmlBoundary{*g.Outer}, g.Inner...) {
+			for _, r := range b.Rings {
+				ps, err := parseKMLCoordinates(r.Coordinates)
+				if err != nil {
+					return nil, false, err
+				}
+				pl = append(pl, ps)
+			}
+		}
+		return pl, true, nil
+	case "MultiGeometry":
+		gc := GeometryCollection{}
+		for _, child := range g.Geometries {
+			geom, ok, err := child.geometry()
+			if err != nil {
+				return nil, false, err
+			}
+			if ok {
+				gc = append(gc, GeometryCollectionMember{geom})
+			}
+		}
+		return gc, true, nil
+	default:
+		return nil, false, nil
+	}
+}
+
+func parseKMLCoordinates(s string) ([]Position, error) {
+	var out []Position
+	for _, tuple := range strings.Fields(s) {
+		parts := strings.Split(tuple, ",")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("invalid coordinate %q", tuple)
+		}
+		p := make(Position, len(parts))
+		for i, part := range parts {
+			v, err := strconv.ParseFloat(part, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid coordinate %q", tuple)
+			}
+			p[i] = v
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func formatKMLCoordinates(ps []Position) string {
+	tuples := make([]string, len(ps))
+	for i, p := range ps {
+		vs := make([]string, len(p))
+		for j, v := range p {
+			vs[j] = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		tuples[i] = strings.Join(vs, ",")
+	}
+	return strings.Join(tuples, " ")
+}
+
+func kmlFeature(ft Feature) (kmlPlacemark, error) {
+	var pm kmlPlacemark
+	if ft.ID != nil {
+		pm.ID = fmt.Sprint(ft.ID)
+	}
+
+	keys := make([]string, 0, len(ft.Properties))
+	for k := range ft.Properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := ft.Properties[k]
+		s, isString := v.(string)
+		switch {
+		case k == "name" && isString:
+			pm.Name = s
+			continue
+		case k == "description" && isString:
+			pm.Description = s
+			continue
+		case !isString:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return kmlPlacemark{}, fmt.Errorf("property %q: %w", k, err)
+			}
+			s = string(b)
+		}
+		if pm.ExtendedData == nil {
+			pm.ExtendedData = &kmlExtendedData{}
+		}
+		pm.ExtendedData.Data = append(pm.ExtendedData.Data, kmlData{Name: k, Value: s})
+	}
+
+	if ft.geometry != nil {
+		g, err := kmlGeometryOf(ft.geometry)
+		if err != nil {
+			return kmlPlacemark{}, err
+		}
+		pm.Geometries = []kmlGeometry{g}
+	}
+	return pm, nil
+}
+
+func kmlGeometryOf(g any) (kmlGeometry, error) {
+	named := func(name string) kmlGeometry {
+		return kmlGeometry{XMLName: xml.Name{Local: name}}
+	}
+	multi := func(parts []any) (kmlGeometry, error) {
+		out := named("MultiGeometry")
+		for _, part := range parts {
+			child, err := kmlGeometryOf(part)
+			if err != nil {
+				return kmlGeometry{}, err
+			}
+			out.Geometries = append(out.Geometries, child)
+		}
+		return out, nil
+	}
+
+	switch g := g.(type) {
+	case Point:
+		if len(g) == 0 {
+			return kmlGeometry{}, errors.New("Point has 0 coordinates")
+		}
+		out := named("Point")
+		out.Coordinates = formatKMLCoordinates([]Position{Position(g)})
+		return out, nil
+	case LineString:
+		out := named("LineString")
+		out.Coordinates = formatKMLCoordinates(g)
+		return out, nil
+	case Polygon:
+		if len(g) == 0 {
+			return kmlGeometry{}, errors.New("Polygon needs one outer LinearRing")
+		}
+		out := named("Polygon")
+		for i, lr := range g {
+			b := kmlBoundary{[]kmlRing{{formatKMLCoordinates(lr)}}}
+			if i == 0 {
+				out.Outer = &b
+			} else {
+				out.Inner = append(out.Inner, b)
+			}
+		}
+		return out, nil
+	case MultiPoint:
+		parts := make([]any, len(g))
+		for i, p := range g {
+			parts[i] = Point(p)
+		}
+		return multi(parts)
+	case MultiLineString:
+		parts := make([]any, len(g))
+		for i, ls := range g {
+			parts[i] = LineString(ls)
+		}
+		return multi(parts)
+	case MultiPolygon:
+		parts := make([]any, len(g))
+		for i, pl := range g {
+			parts[i] = pl
+		}
+		return multi(parts)
+	case GeometryCollection:
+		parts := make([]any, len(g))
+		for i, m := range g {
+			parts[i] = m.geometry
+		}
+		return multi(parts)
+	default:
+		return kmlGeometry{}, fmt.Errorf("unsupported geometry type %T", g)
+	}
+}
@@ -0,0 +1,109 @@
+package joejson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testKML = `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2">
+  <Document>
+    <Folder>
+      <Placemark id="p1">
+        <name>Pin</name>
+        <styleUrl>#pin</styleUrl>
+        <ExtendedData>
+          <Data name="kind"><value>site</value></Data>
+        </ExtendedData>
+        <Point><altitudeMode>absolute</altitudeMode><coordinates>1,2,3</coordinates></Point>
+      </Placemark>
+    </Folder>
+    <Placemark>
+      <description>Field</description>
+      <Polygon>
+        <outerBoundaryIs><LinearRing><coordinates>
+          0,0 4,0 4,4 0,4 0,0
+        </coordinates></LinearRing></outerBoundaryIs>
+        <innerBoundaryIs><LinearRing><coordinates>1,1 1,2 2,2 1,1</coordinates></LinearRing></innerBoundaryIs>
+      </Polygon>
+    </Placemark>
+    <Placemark>
+      <MultiGeometry>
+        <Point><coordinates>1,1</coordinates></Point>
+        <LineString><coordinates>0,0 1,1</coordinates></LineString>
+      </MultiGeometry>
+    </Placemark>
+    <Placemark><name>Empty</name></Placemark>
+  </Document>
+</kml>`
+
+func TestKML(t *testing.T) {
+	fc, err := UnmarshalKML([]byte(testKML))
+	assert.NoError(t, err)
+
+	want := FeatureCollection{Features: []Feature{
+		Feature{ID: "p1", Properties: map[string]any{"name": "Pin", "kind": "site"}}.WithPoint(Point{1, 2, 3}),
+		Feature{Properties: map[string]any{"description": "Field"}}.WithPolygon(Polygon{
+			{{0, 0}, {4, 0}, {4, 4}, {0, 4}, {0, 0}},
+			{{1, 1}, {1, 2}, {2, 2}, {1, 1}},
+		}),
+		Feature{Properties: map[string]any{}}.WithGeometryCollection(
+			GeometryCollection{}.AppendPoint(Point{1, 1}).AppendLineString(LineString{{0, 0}, {1, 1}}),
+		),
+		{Properties: map[string]any{"name": "Empty"}},
+	}}
+	assert.Equal(t, want, fc)
+
+	b, err := fc.MarshalKML()
+	assert.NoError(t, err)
+	got, err := UnmarshalKML(b)
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	t.Run("multi geometries", func(t *testing.T) {
+		fc := FeatureCollection{Features: []Feature{
+			Feature{ID: 1.0, Properties: map[string]any{"n": 2.0}}.WithMultiPoint(MultiPoint{{1, 2}, {3, 4}}),
+		}}
+		b, err := fc.MarshalKML()
+		assert.NoError(t, err)
+		got, err := UnmarshalKML(b)
+		assert.NoError(t, err)
+		assert.Equal(t, FeatureCollection{Features: []Feature{
+			Feature{ID: "1", Properties: map[string]any{"n": "2"}}.WithGeometryCollection(
+				GeometryCollection{}.AppendPoint(Point{1, 2}).AppendPoint(Point{3, 4}),
+			),
+		}}, got)
+	})
+
+	t.Run("empty geometries", func(t *testing.T) {
+		fc := FeatureCollection{Features: []Feature{
+			Feature{}.WithLineString(LineString{}),
+			Feature{}.WithPolygon(Polygon{}),
+		}}
+		_, err := fc.MarshalKML()
+		assert.EqualError(t, err, "kml: feature 1: Polygon needs one outer LinearRing")
+
+		fc.Features[1] = Feature{}.WithMultiPolygon(MultiPolygon{{}})
+		_, err = fc.MarshalKML()
+		assert.EqualError(t, err, "kml: feature 1: Polygon needs one outer LinearRing")
+
+		fc.Features[1] = Feature{}.WithPoint(Point{})
+		_, err = fc.MarshalKML()
+		assert.EqualError(t, err, "kml: feature 1: Point has 0 coordinates")
+
+		fc.Features = fc.Features[:1]
+		b, err := fc.MarshalKML()
+		assert.NoError(t, err)
+		got, err := UnmarshalKML(b)
+		assert.NoError(t, err)
+		assert.Equal(t, FeatureCollection{Features: []Feature{
+			Feature{Properties: map[string]any{}}.WithLineString(nil),
+		}}, got)
+	})
+
+	t.Run("invalid coordinates", func(t *testing.T) {
+		_, err := UnmarshalKML([]byte(`<kml><Placemark><Point><coordinates>1;2</coordinates></Point></Placemark></kml>`))
+		assert.EqualError(t, err, `kml: placemark 0: invalid coordinate "1;2"`)
+	})
+}
@@ -0,0 +1,257 @@
+package joejson
+
+import "math"
+
+// LineOptions configures measuring along a LineString.
+type LineOptions struct {
+	// Geodesic treats positions as WGS84 longitude/latitude, measures
+	// distances in metres on a sphere of the WGS84 equatorial radius and
+	// follows great circles between positions. Antipodal positions are
+	// joined along the meridian heading north from the first, or towards
+	// longitude 0 when it is a pole.
+	// Otherwise distances are planar, in the units of the positions.
+	Geodesic bool
+}
+
+// Length returns the length of the LineString.
+func (g LineString) Length(opts LineOptions) float64 {
+	var total float64
+	for i := 0; i+1 < len(g); i++ {
+		total += segmentLength(g[i], g[i+1], opts)
+	}
+	return total
+}
+
+// Interpolate returns the Position at fraction of the way along the
+// LineString, clamped to [0, 1]. Elevations are interpolated linearly
+// when both neighbouring Positions have one.
+// An empty LineString gives nil.
+func (g LineString) Interpolate(fraction float64, opts LineOptions) Position {
+	return g.InterpolateDistance(fraction*g.Length(opts), opts)
+}
+
+// InterpolateDistance returns the Position distance along the LineString,
+// clamped to its ends. See LineString.Interpolate.
+func (g LineString) InterpolateDistance(distance float64, opts LineOptions) Position {
+	if len(g) == 0 {
+		return nil
+	}
+	if distance > 0 {
+		for i := 0; i+1 < len(g); i++ {
+			l := segmentLength(g[i], g[i+1], opts)
+			if distance < l {
+				return segmentPosition(g[i], g[i+1], distance/l, opts)
+			}
+			distance -= l
+		}
+		return append(Position(nil), g[len(g)-1]...)
+	}
+	return append(Position(nil), g[0]...)
+}
+
+// Locate returns the fraction of the way along the LineString of the
+// position on it nearest to p, in [0, 1]. It is 0 for an empty Point or a
+// LineString of no length.
+func (g LineString) Locate(p Point, opts LineOptions) float64 {
+	i, t, _ := g.nearest(p, opts)
+	if i < 0 {
+		return 0
+	}
+	total := g.Length(opts)
+	if total == 0 {
+		return 0
+	}
+	var along float64
+	for j := 0; j < i; j++ {
+		along += segmentLength(g[j], g[j+1], opts)
+	}
+	along += t * segmentLength(g[i], g[i+1], opts)
+	return math.Min(1, along/total)
+}
+
+// NearestPoint returns the position on the LineString nearest to p and its
+// distance from p. Elevations are interpolated as by Interpolate.
+// An empty LineString or Point gives nil.
+func (g LineString) NearestPoint(p Point, opts LineOptions) (Position, float64) {
+	i, t, d := g.nearest(p, opts)
+	switch {
+	case i < 0:
+		return nil, 0
+	case len(g) == 1:
+		return append(Position(nil), g[0]...), d
+	}
+	return segmentPosition(g[i], g[i+1], t, opts), d
+}
+
+// Substring returns the part of the LineString between the fractions start
+// and end of the way along it, clamped to [0, 1], keeping the Positions in
+// between. If start is after end the part is reversed. A part of no length
+// has its one Position twice.
+func (g LineString) Substring(start, end float64, opts LineOptions) LineString {
+	if len(g) == 0 {
+		return nil
+	}
+	if start > end {
+		return LineString(reversePositions(g.Substring(end, start, opts)))
+	}
+	total := g.Length(opts)
+	from := math.Max(0, math.Min(1, start)) * total
+	to := math.Max(0, math.Min(1, end)) * total
+
+	out := LineString{g.InterpolateDistance(from, opts)}
+	var along float64
+	for i := 0; i+1 < len(g); i++ {
+		along += segmentLength(g[i], g[i+1], opts)
+		if along > from && along < to {
+			out = append(out, append(Position(nil), g[i+1]...))
+		}
+	}
+	return append(out, g.InterpolateDistance(to, opts))
+}
+
+// nearest returns the segment index, the fraction along it and the
+// distance of the position on g nearest to p, or -1 if there is none.
+// A LineString of one Position is treated as a segment of no length.
+func (g LineString) nearest(p Point, opts LineOptions) (int, float64, float64) {
+	if len(g) == 0 || len(p) < 2 {
+		return -1, 0, 0
+	}
+	if len(g) == 1 {
+		g = LineString{g[0], g[0]}
+	}
+	best, bestT, bestDist := -1, 0.0, math.Inf(1)
+	for i := 0; i+1 < len(g); i++ {
+		t := segmentNearest(Position(p), g[i], g[i+1], opts)
+		if d := segmentLength(Position(p), segmentPosition(g[i], g[i+1], t, opts), opts); d < bestDist {
+			best, bestT, bestDist = i, t, d
+		}
+	}
+	return best, bestT, bestDist
+}
+
+func segmentLength(a, b Position, opts LineOptions) float64 {
+	if opts.Geodesic {
+		return greatCircleDistance(a, b)
+	}
+	return math.Hypot(b.Lon()-a.Lon(), b.Lat()-a.Lat())
+}
+
+// segmentPosition returns the position at fraction t along a-b.
+func segmentPosition(a, b Position, t float64, opts LineOptions) Position {
+	p := lerpPosition(a, b, t)
+	if opts.Geodesic && t > 0 && t < 1 {
+		p[0], p[1] = fromUnitVector(slerp(unitVectorOf(a), unitVectorOf(b), t))
+	}
+	return p
+}
+
+// segmentNearest returns the fraction along a-b of its position nearest p.
+func segmentNearest(p, a, b Position, opts LineOptions) float64 {
+	if !opts.Geodesic {
+		d := vecSub(b, a)
+		l := vecDot(d, d)
+		if l == 0 {
+			return 0
+		}
+		return math.Max(0, math.Min(1, vecDot(vecSub(p, a), d)/l))
+	}
+
+	va, vb, vp := unitVectorOf(a), unitVectorOf(b), unitVectorOf(p)
+	d, omega := arcDirection(va, vb)
+	n := cross3(va, d)
+	if omega == 0 || norm3(n) == 0 {
+		return 0
+	}
+	// the foot of p on the great circle, as an angle from a towards b.
+	foot := sub3(vp, scale3(n, dot3(vp, n)))
+	theta := math.Atan2(dot3(cross3(va, foot), n), dot3(va, foot))
+	if norm3(foot) > 0 && theta >= 0 && theta <= omega {
+		return theta / omega
+	}
+	if vectorAngle(vp, va) <= vectorAngle(vp, vb) {
+		return 0
+	}
+	return 1
+}
+
+// greatCircleDistance returns the distance in metres between two WGS84
+// positions on a sphere of the WGS84 equatorial radius.
+func greatCircleDistance(a, b Position) float64 {
+	lat1, lat2 := a.Lat()*math.Pi/180, b.Lat()*math.Pi/180
+	dLat, dLon := lat2-lat1, (b.Lon()-a.Lon())*math.Pi/180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * wgs84SemiMajorAxis * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// unitVectorOf returns the unit vector from the centre of the sphere
+// through a longitude/latitude position.
+func unitVectorOf(p Position) [3]float64 {
+	lon, lat := p.Lon()*math.Pi/180, p.Lat()*math.Pi/180
+	return [3]float64{math.Cos(lat) * math.Cos(lon), math.Cos(lat) * math.Sin(lon), math.Sin(lat)}
+}
+
+func fromUnitVector(v [3]float64) (lon, lat float64) {
+	return math.Atan2(v[1], v[0]) * 180 / math.Pi, math.Atan2(v[2], math.Hypot(v[0], v[1])) * 180 / math.Pi
+}
+
+// slerp returns the unit vector at fraction t along the great circle arc
+// from a to b.
+func slerp(a, b [3]float64, t float64) [3]float64 {
+	d, omega := arcDirection(a, b)
+	return add3(scale3(a, math.Cos(t*omega)), scale3(d, math.Sin(t*omega)))
+}
+
+// arcSinEpsilon is how close to 0 the sine of the angle between two unit
+// vectors may get before the great circle through them is not defined.
+const arcSinEpsilon = 1e-12
+
+// arcDirection returns the unit vector perpendicular to a along the great
+// circle arc from a to b, and the angle of the arc. When a and b are
+// antipodal every meridian through a joins them, so it takes the one heading
+// north, or towards longitude 0 from a pole. When they coincide the
+// direction is zero.
+func arcDirection(a, b [3]float64) ([3]float64, float64) {
+	omega := vectorAngle(a, b)
+	d := sub3(b, scale3(a, dot3(a, b)))
+	if l := norm3(d); l > arcSinEpsilon {
+		return scale3(d, 1/l), omega
+	}
+	if omega < math.Pi/2 {
+		return [3]float64{}, omega
+	}
+	for _, axis := range [][3]float64{{0, 0, 1}, {1, 0, 0}} {
+		d = sub3(axis, scale3(a, dot3(a, axis)))
+		if l := norm3(d); l > arcSinEpsilon {
+			return scale3(d, 1/l), omega
+		}
+	}
+	return [3]float64{}, omega
+}
+
+func vectorAngle(a, b [3]float64) float64 {
+	return math.Atan2(norm3(cross3(a, b)), dot3(a, b))
+}
+
+func add3(a, b [3]float64) [3]float64 {
+	return [3]float64{a[0] + b[0], a[1] + b[1], a[2] + b[2]}
+}
+
+func sub3(a, b [3]float64) [3]float64 {
+	return [3]float64{a[0] - b[0], a[1] - b[1], a[2] - b[2]}
+}
+
+func scale3(a [3]float64, k float64) [3]float64 {
+	return [3]float64{a[0] * k, a[1] * k, a[2] * k}
+}
+
+func dot3(a, b [3]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+func cross3(a, b [3]float64) [3]float64 {
+	return [3]float64{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
+
+func norm3(a [3]float64) float64 {
+	return math.Sqrt(dot3(a, a))
+}
@@ -0,0 +1,137 @@
+package joejson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineReferencing(t *testing.T) {
+	// an L shape 10 long with elevations rising by 1 per unit.
+	ls := LineString{{0, 0, 0}, {4, 0, 4}, {4, 6, 10}}
+	planar := LineOptions{}
+
+	t.Run("Length", func(t *testing.T) {
+		assert.Equal(t, 10.0, ls.Length(planar))
+		assert.Equal(t, 0.0, LineString{}.Length(planar))
+	})
+
+	t.Run("Interpolate", func(t *testing.T) {
+		testCases := map[string]struct {
+			fraction float64
+			want     Position
+		}{
+			"start":      {0, Position{0, 0, 0}},
+			"first leg":  {0.25, Position{2.5, 0, 2.5}},
+			"vertex":     {0.4, Position{4, 0, 4}},
+			"second leg": {0.7, Position{4, 3, 7}},
+			"end":        {1, Position{4, 6, 10}},
+			"before":     {-1, Position{0, 0, 0}},
+			"beyond":     {2, Position{4, 6, 10}},
+		}
+		for name, tt := range testCases {
+			t.Run(name, func(t *testing.T) {
+				assert.Equal(t, tt.want, ls.Interpolate(tt.fraction, planar))
+			})
+		}
+		assert.Equal(t, Position{4, 1, 5}, ls.InterpolateDistance(5, planar))
+		assert.Nil(t, LineString{}.Interpolate(0.5, planar))
+		assert.Equal(t, Position{1, 0}, LineString{{0, 0}, {2, 0, 7}}.Interpolate(0.5, planar))
+	})
+
+	t.Run("Locate and NearestPoint", func(t *testing.T) {
+		testCases := map[string]struct {
+			p        Point
+			fraction float64
+			nearest  Position
+			distance float64
+		}{
+			"on line":   {Point{2, 0}, 0.2, Position{2, 0, 2}, 0},
+			"beside":    {Point{2, -3}, 0.2, Position{2, 0, 2}, 3},
+			"corner":    {Point{6, -1}, 0.4, Position{4, 0, 4}, 2.23606797749979},
+			"second":    {Point{1, 5}, 0.9, Position{4, 5, 9}, 3},
+			"before":    {Point{-3, 0}, 0, Position{0, 0, 0}, 3},
+			"after end": {Point{4, 10}, 1, Position{4, 6, 10}, 4},
+		}
+		for name, tt := range testCases {
+			t.Run(name, func(t *testing.T) {
+				assert.InDelta(t, tt.fraction, ls.Locate(tt.p, planar), 1e-12)
+				got, d := ls.NearestPoint(tt.p, planar)
+				assert.Equal(t, tt.nearest, got)
+				assert.InDelta(t, tt.distance, d, 1e-12)
+			})
+		}
+
+		got, d := LineString{{1, 1}}.NearestPoint(Point{4, 5}, planar)
+		assert.Equal(t, Position{1, 1}, got)
+		assert.Equal(t, 5.0, d)
+		got, _ = ls.NearestPoint(Point{}, planar)
+		assert.Nil(t, got)
+		assert.Equal(t, 0.0, LineString{{1, 1}}.Locate(Point{4, 5}, planar))
+	})
+
+	t.Run("Substring", func(t *testing.T) {
+		testCases := map[string]struct {
+			start, end float64
+			want       LineString
+		}{
+			"whole":     {0, 1, ls},
+			"across":    {0.2, 0.7, LineString{{2, 0, 2}, {4, 0, 4}, {4, 3, 7}}},
+			"within":    {0.5, 0.7, LineString{{4, 1, 5}, {4, 3, 7}}},
+			"reversed":  {0.7, 0.2, LineString{{4, 3, 7}, {4, 0, 4}, {2, 0, 2}}},
+			"to vertex": {0, 0.4, LineString{{0, 0, 0}, {4, 0, 4}}},
+			"clamped":   {-1, 0.1, LineString{{0, 0, 0}, {1, 0, 1}}},
+			"point":     {0.5, 0.5, LineString{{4, 1, 5}, {4, 1, 5}}},
+		}
+		for name, tt := range testCases {
+			t.Run(name, func(t *testing.T) {
+				assert.Equal(t, tt.want, ls.Substring(tt.start, tt.end, planar))
+			})
+		}
+	})
+
+	t.Run("Geodesic", func(t *testing.T) {
+		geodesic := LineOptions{Geodesic: true}
+		// along the equator a degree is 2πR/360 metres.
+		degree := 111319.49079327357
+		eq := LineString{{0, 0, 100}, {10, 0, 200}}
+		assert.InDelta(t, 10*degree, eq.Length(geodesic), 1e-6)
+		assertPositionsInDelta(t, Point{2.5, 0, 125}, Point(eq.Interpolate(0.25, geodesic)))
+		assertPositionsInDelta(t, Point{1, 0, 110}, Point(eq.InterpolateDistance(degree, geodesic)))
+
+		got, d := eq.NearestPoint(Point{3, 1}, geodesic)
+		assertPositionsInDelta(t, Point{3, 0, 130}, Point(got))
+		assert.InDelta(t, degree, d, 1)
+		assert.InDelta(t, 0.3, eq.Locate(Point{3, 1}, geodesic), 1e-9)
+
+		// the great circle between two points at 60°N bulges north.
+		north := LineString{{-30, 60}, {30, 60}}
+		mid := north.Interpolate(0.5, geodesic)
+		assert.InDelta(t, 0, mid.Lon(), 1e-9)
+		assert.Greater(t, mid.Lat(), 62.0)
+		assert.Less(t, north.Length(geodesic), LineString{{-30, 60}, {0, 60}, {30, 60}}.Length(geodesic))
+
+		// the antimeridian is crossed the short way.
+		am := LineString{{179, 0}, {-179, 0}}
+		assert.InDelta(t, 2*degree, am.Length(geodesic), 1e-6)
+		assertPositionsInDelta(t, Point{180, 0}, Point(am.Interpolate(0.5, geodesic)))
+		assert.InDelta(t, 0.75, am.Locate(Point{-179.5, 0.5}, geodesic), 1e-6)
+
+		sub := eq.Substring(0.1, 0.2, geodesic)
+		assert.Len(t, sub, 2)
+		assertPositionsInDelta(t, Point{1, 0, 110}, Point(sub[0]))
+
+		// antipodal positions are joined along the meridian heading north.
+		anti := LineString{{0, 0}, {180, 0}}
+		assert.InDelta(t, 180*degree, anti.Length(geodesic), 1e-6)
+		assertPositionsInDelta(t, Point{0, 45}, Point(anti.Interpolate(0.25, geodesic)))
+		assertPositionsInDelta(t, Point{180, 45}, Point(anti.Interpolate(0.75, geodesic)))
+		assert.InDelta(t, 1.0/3, anti.Locate(Point{0, 60}, geodesic), 1e-9)
+		got, d = anti.NearestPoint(Point{180, 30}, geodesic)
+		assertPositionsInDelta(t, Point{180, 30}, Point(got))
+		assert.InDelta(t, 0, d, 1e-6)
+		// or towards longitude 0 from a pole.
+		poles := LineString{{0, 90}, {0, -90}}
+		assertPositionsInDelta(t, Point{0, 0}, Point(poles.Interpolate(0.5, geodesic)))
+	})
+}
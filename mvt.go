@@ -0,0 +1,929 @@
+package joejson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	// mvtDefaultExtent is the tile extent used when MVTOptions.Extent is zero.
+	mvtDefaultExtent = 4096
+	mvtVersion       = 2
+
+	mvtTypePoint      = 1
+	mvtTypeLineString = 2
+	mvtTypePolygon    = 3
+
+	mvtMoveTo    = 1
+	mvtLineTo    = 2
+	mvtClosePath = 7
+
+	// protobuf wire types.
+	pbVarint  = 0
+	pbFixed64 = 1
+	pbBytes   = 2
+	pbFixed32 = 5
+)
+
+var errMVTShort = errors.New("mvt: unexpected end of input")
+
+// MVTOptions configures Mapbox Vector Tile encoding.
+type MVTOptions struct {
+	// Extent is the width and height of the tile's integer coordinate grid.
+	// Zero means 4096.
+	Extent int
+	// Buffer is how far beyond the tile's edges, in tile coordinates,
+	// geometries are kept before being clipped.
+	Buffer int
+}
+
+// MarshalMVT encodes the FeatureCollection as a Mapbox Vector Tile with a
+// single layer for tile z/x/y. See MarshalMVT.
+func (f FeatureCollection) MarshalMVT(layer string, z, x, y int, opts MVTOptions) ([]byte, error) {
+	return MarshalMVT(map[string]FeatureCollection{layer: f}, z, x, y, opts)
+}
+
+// MarshalMVT encodes each FeatureCollection as a named layer of a Mapbox
+// Vector Tile (version 2) for tile z/x/y, with layers written in name order.
+//
+// Positions are WGS84 longitude/latitude; they are projected to Web Mercator
+// tile coordinates, clipped to the tile plus opts.Buffer and snapped to the
+// integer grid. Geometries left empty are dropped, and a GeometryCollection
+// is written as one tile feature per point, line and polygon part.
+// Polygon rings are rewound as the specification requires.
+//
+// Properties with nil values are skipped, strings, bools and numbers map
+// to their tile value types and anything else is stored as a JSON string.
+// Non-negative integer IDs are kept; other IDs are dropped.
+func MarshalMVT(layers map[string]FeatureCollection, z, x, y int, opts MVTOptions) ([]byte, error) {
+	extent := opts.Extent
+	if extent == 0 {
+		extent = mvtDefaultExtent
+	}
+	tile, err := newMVTTile(z, x, y, extent)
+	if err != nil {
+		return nil, err
+	}
+	buffer := float64(opts.Buffer)
+	clip := bounds{-buffer, -buffer, tile.extent + buffer, tile.extent + buffer}
+
+	names := make([]string, 0, len(layers))
+	for name := range layers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out []byte
+	for _, name := range names {
+		layer, err := encodeMVTLayer(name, layers[name], tile, clip)
+		if err != nil {
+			return nil, err
+		}
+		out = appendPBBytes(out, 3, layer)
+	}
+	return out, nil
+}
+
+// UnmarshalMVT decodes every layer of a Mapbox Vector Tile for tile z/x/y,
+// keyed by layer name, with positions unprojected to WGS84.
+//
+// Numeric property values decode as float64 and integer IDs as float64,
+// matching encoding/json. Features of unknown geometry type are skipped.
+func UnmarshalMVT(b []byte, z, x, y int) (map[string]FeatureCollection, error) {
+	if _, err := newMVTTile(z, x, y, mvtDefaultExtent); err != nil {
+		return nil, err
+	}
+
+	out := map[string]FeatureCollection{}
+	r := pbReader{b: b}
+	for !r.done() {
+		field, wire, err := r.key()
+		if err != nil {
+			return nil, err
+		}
+		if field != 3 || wire != pbBytes {
+			if err := r.skip(wire); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		msg, err := r.bytes()
+		if err != nil {
+			return nil, err
+		}
+		name, fc, err := decodeMVTLayer(msg, z, x, y)
+		if err != nil {
+			return nil, err
+		}
+		out[name] = fc
+	}
+	return out, nil
+}
+
+// mvtTile maps WGS84 positions to a tile's coordinate grid.
+type mvtTile struct {
+	x, y, extent float64
+	// size is the extent of the whole world at the tile's zoom.
+	size float64
+}
+
+func newMVTTile(z, x, y, extent int) (mvtTile, error) {
+	if z < 0 || z > 30 {
+		return mvtTile{}, fmt.Errorf("mvt: invalid zoom %d", z)
+	}
+	if n := 1 << z; x < 0 || x >= n || y < 0 || y >= n {
+		return mvtTile{}, fmt.Errorf("mvt: tile %d/%d/%d out of range", z, x, y)
+	}
+	if extent <= 0 {
+		return mvtTile{}, fmt.Errorf("mvt: invalid extent %d", extent)
+	}
+	return mvtTile{
+		x:      float64(x),
+		y:      float64(y),
+		extent: float64(extent),
+		size:   float64(extent) * float64(int(1)<<z),
+	}, nil
+}
+
+func (t mvtTile) project(p Position) Position {
+	m := WGS84ToWebMercator(p)
+	half := math.Pi * wgs84SemiMajorAxis
+	px := (m.Lon()+half)/(2*half)*t.size - t.x*t.extent
+	py := (half-m.Lat())/(2*half)*t.size - t.y*t.extent
+	return Position{px, py}
+}
+
+func (t mvtTile) unproject(p Position) Position {
+	half := math.Pi * wgs84SemiMajorAxis
+	mx := (p.Lon()+t.x*t.extent)/t.size*2*half - half
+	my := half - (p.Lat()+t.y*t.extent)/t.size*2*half
+	return WebMercatorToWGS84(Position{mx, my})
+}
+
+// mvtLayerEncoder accumulates a layer's deduplicated keys and values.
+type mvtLayerEncoder struct {
+	keys       []string
+	keyIndex   map[string]uint32
+	values     [][]byte
+	valueIndex map[string]uint32
+}
+
+func encodeMVTLayer(name string, fc FeatureCollection, tile mvtTile, clip bounds) ([]byte, error) {
+	enc := mvtLayerEncoder{keyIndex: map[string]uint32{}, valueIndex: map[string]uint32{}}
+
+	var features []byte
+	for _, ft := range fc.Features {
+		parts := mvtParts(mapPositions(ft.geometry, tile.project), clip)
+		if len(parts) == 0 {
+			continue
+		}
+		tags, err := enc.tags(ft.Properties)
+		if err != nil {
+			return nil, err
+		}
+		id, hasID := mvtID(ft.ID)
+		for _, g := range parts {
+			typ, cmds := encodeMVTGeometry(g)
+			var msg []byte
+			if hasID {
+				msg = appendPBVarint(msg, 1, id)
+			}
+			msg = appendPBPacked(msg, 2, tags)
+			msg = appendPBVarint(msg, 3, typ)
+			msg = appendPBPacked(msg, 4, cmds)
+			features = appendPBBytes(features, 2, msg)
+		}
+	}
+
+	var out []byte
+	out = appendPBVarint(out, 15, mvtVersion)
+	out = appendPBBytes(out, 1, []byte(name))
+	out = append(out, features...)
+	for _, k := range enc.keys {
+		out = appendPBBytes(out, 3, []byte(k))
+	}
+	for _, v := range enc.values {
+		out = appendPBBytes(out, 4, v)
+	}
+	out = appendPBVarint(out, 5, uint64(tile.extent))
+	return out, nil
+}
+
+// tags converts properties to key/value index pairs, in key order.
+func (e *mvtLayerEncoder) tags(props map[string]any) ([]uint32, error) {
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var tags []uint32
+	for _, k := range keys {
+		if props[k] == nil {
+			continue
+		}
+		val, err := encodeMVTValue(props[k])
+		if err != nil {
+			return nil, fmt.Errorf("mvt: property %q: %w", k, err)
+		}
+		ki, ok := e.keyIndex[k]
+		if !ok {
+			ki = uint32(len(e.keys))
+			e.keys = append(e.keys, k)
+			e.keyIndex[k] = ki
+		}
+		vi, ok := e.valueIndex[string(val)]
+		if !ok {
+			vi = uint32(len(e.values))
+			e.values = append(e.values, val)
+			e.valueIndex[string(val)] = vi
+		}
+		tags = append(tags, ki, vi)
+	}
+	return tags, nil
+}
+
+// encodeMVTValue encodes v as a tile Value message.
+func encodeMVTValue(v any) ([]byte, error) {
+	switch v := v.(type) {
+	case string:
+		return appendPBBytes(nil, 1, []byte(v)), nil
+	case float32:
+		return appendPBFixed32(nil, 2, math.Float32bits(v)), nil
+	case float64:
+		return appendPBFixed64(nil, 3, math.Float64bits(v)), nil
+	case int:
+		return appendPBVarint(nil, 6, zigzag(int64(v))), nil
+	case int8:
+		return appendPBVarint(nil, 6, zigzag(int64(v))), nil
+	case int16:
+		return appendPBVarint(nil, 6, zigzag(int64(v))), nil
+	case int32:
+		return appendPBVarint(nil, 6, zigzag(int64(v))), nil
+	case int64:
+		return appendPBVarint(nil, 6, zigzag(v)), nil
+	case uint:
+		return appendPBVarint(nil, 5, uint64(v)), nil
+	case uint8:
+		return appendPBVarint(nil, 5, uint64(v)), nil
+	case uint16:
+		return appendPBVarint(nil, 5, uint64(v)), nil
+	case uint32:
+		return appendPBVarint(nil, 5, uint64(v)), nil
+	case uint64:
+		return appendPBVarint(nil, 5, v), nil
+	case bool:
+		var b uint64
+		if v {
+			b = 1
+		}
+		return appendPBVarint(nil, 7, b), nil
+	default:
+		bs, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return appendPBBytes(nil, 1, bs), nil
+	}
+}
+
+// mvtID converts a Feature ID to a tile feature id if it is a non-negative integer.
+func mvtID(id any) (uint64, bool) {
+	switch id := id.(type) {
+	case float64:
+		if id >= 0 && id < math.MaxUint64 && id == math.Trunc(id) {
+			return uint64(id), true
+		}
+	case int:
+		if id >= 0 {
+			return uint64(id), true
+		}
+	case int64:
+		if id >= 0 {
+			return uint64(id), true
+		}
+	case uint64:
+		return id, true
+	}
+	return 0, false
+}
+
+// mvtParts clips and snaps g, already in tile coordinates, returning at most
+// one MultiPoint, MultiLineString and MultiPolygon.
+func mvtParts(g any, clip bounds) []any {
+	var points MultiPoint
+	var lines MultiLineString
+	var polygons MultiPolygon
+
+	var collect func(g any)
+	collect = func(g any) {
+		switch g := g.(type) {
+		case Point:
+			if len(g) > 0 && clip.containsPosition(Position(g)) {
+				points = append(points, mvtRound(Position(g)))
+			}
+		case MultiPoint:
+			for _, p := range g {
+				collect(Point(p))
+			}
+		case LineString:
+			for _, part := range clipLine(g, clip) {
+				if part = mvtSnap(part); len(part) >= 2 {
+					lines = append(lines, part)
+				}
+			}
+		case MultiLineString:
+			for _, ls := range g {
+				collect(LineString(ls))
+			}
+		case Polygon:
+			if pl := mvtPolygon(g, clip); pl != nil {
+				polygons = append(polygons, pl)
+			}
+		case MultiPolygon:
+			for _, pl := range g {
+				collect(pl)
+			}
+		case GeometryCollection:
+			for _, m := range g {
+				collect(m.geometry)
+			}
+		}
+	}
+	collect(g)
+
+	var out []any
+	if len(points) > 0 {
+		out = append(out, points)
+	}
+	if len(lines) > 0 {
+		out = append(out, lines)
+	}
+	if len(polygons) > 0 {
+		out = append(out, polygons)
+	}
+	return out
+}
+
+// mvtPolygon clips, snaps and rewinds pl so exterior rings have a positive
+// area in tile coordinates and holes a negative one. It returns nil if the
+// exterior ring collapses.
+func mvtPolygon(pl Polygon, clip bounds) Polygon {
+	var out Polygon
+	for i, lr := range pl {
+		ring := mvtSnap(clipRing(lr, clip))
+		area := ringArea(ring)
+		if len(ring) < 4 || area == 0 {
+			if i == 0 {
+				return nil
+			}
+			continue
+		}
+		if (area > 0) != (i == 0) {
+			ring = reversePositions(ring)
+		}
+		out = append(out, ring)
+	}
+	return out
+}
+
+func mvtRound(p Position) Position {
+	return Position{math.Round(p.Lon()), math.Round(p.Lat())}
+}
+
+// mvtSnap rounds ps to the tile grid, dropping consecutive duplicates.
+func mvtSnap(ps []Position) []Position {
+	var out []Position
+	for _, p := range ps {
+		p = mvtRound(p)
+		if len(out) == 0 || !positionsEqual(out[len(out)-1], p) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// ringArea is the signed shoelace area of a ring, open or closed; positive
+// when counterclockwise with y pointing up.
+func ringArea(ring []Position) float64 {
+	var sum float64
+	for i, p := range ring {
+		q := ring[(i+1)%len(ring)]
+		sum += p.Lon()*q.Lat() - q.Lon()*p.Lat()
+	}
+	return sum / 2
+}
+
+func reversePositions(ps []Position) []Position {
+	out := make([]Position, len(ps))
+	for i, p := range ps {
+		out[len(ps)-1-i] = p
+	}
+	return out
+}
+
+// mvtCursor writes geometry commands relative to the previous position.
+type mvtCursor struct {
+	cmds []uint32
+	x, y int64
+}
+
+func (c *mvtCursor) command(id, count int) {
+	c.cmds = append(c.cmds, uint32(id&0x7|count<<3))
+}
+
+func (c *mvtCursor) point(p Position) {
+	x, y := int64(p.Lon()), int64(p.Lat())
+	c.cmds = append(c.cmds, uint32(zigzag(x-c.x)), uint32(zigzag(y-c.y)))
+	c.x, c.y = x, y
+}
+
+func (c *mvtCursor) path(ps []Position, closed bool) {
+	if closed {
+		ps = openRing(ps)
+	}
+	c.command(mvtMoveTo, 1)
+	c.point(ps[0])
+	if len(ps) > 1 {
+		c.command(mvtLineTo, len(ps)-1)
+		for _, p := range ps[1:] {
+			c.point(p)
+		}
+	}
+	if closed {
+		c.command(mvtClosePath, 1)
+	}
+}
+
+// encodeMVTGeometry encodes g, in integer tile coordinates, as a geometry
+// type and command sequence.
+func encodeMVTGeometry(g any) (uint64, []uint32) {
+	var c mvtCursor
+	switch g := g.(type) {
+	case Point:
+		c.command(mvtMoveTo, 1)
+		c.point(Position(g))
+		return mvtTypePoint, c.cmds
+	case MultiPoint:
+		c.command(mvtMoveTo, len(g))
+		for _, p := range g {
+			c.point(p)
+		}
+		return mvtTypePoint, c.cmds
+	case LineString:
+		c.path(g, false)
+		return mvtTypeLineString, c.cmds
+	case MultiLineString:
+		for _, ls := range g {
+			c.path(ls, false)
+		}
+		return mvtTypeLineString, c.cmds
+	case Polygon:
+		for _, lr := range g {
+			c.path(lr, true)
+		}
+		return mvtTypePolygon, c.cmds
+	case MultiPolygon:
+		for _, pl := range g {
+			for _, lr := range pl {
+				c.path(lr, true)
+			}
+		}
+		return mvtTypePolygon, c.cmds
+	default:
+		return 0, nil
+	}
+}
+
+func decodeMVTLayer(b []byte, z, x, y int) (string, FeatureCollection, error) {
+	var name string
+	var features [][]byte
+	var keys []string
+	var values []any
+	extent := uint64(mvtDefaultExtent)
+
+	r := pbReader{b: b}
+	for !r.done() {
+		field, wire, err := r.key()
+		if err != nil {
+			return "", FeatureCollection{}, err
+		}
+		switch {
+		case field == 1 && wire == pbBytes:
+			v, err := r.bytes()
+			if err != nil {
+				return "", FeatureCollection{}, err
+			}
+			name = string(v)
+		case field == 2 && wire == pbBytes:
+			v, err := r.bytes()
+			if err != nil {
+				return "", FeatureCollection{}, err
+			}
+			features = append(features, v)
+		case field == 3 && wire == pbBytes:
+			v, err := r.bytes()
+			if err != nil {
+				return "", FeatureCollection{}, err
+			}
+			keys = append(keys, string(v))
+		case field == 4 && wire == pbBytes:
+			v, err := r.bytes()
+			if err != nil {
+				return "", FeatureCollection{}, err
+			}
+			val, err := decodeMVTValue(v)
+			if err != nil {
+				return "", FeatureCollection{}, err
+			}
+			values = append(values, val)
+		case field == 5 && wire == pbVarint:
+			if extent, err = r.varint(); err != nil {
+				return "", FeatureCollection{}, err
+			}
+		default:
+			if err := r.skip(wire); err != nil {
+				return "", FeatureCollection{}, err
+			}
+		}
+	}
+
+	if extent == 0 || extent > math.MaxInt32 {
+		return "", FeatureCollection{}, fmt.Errorf("mvt: layer %q: invalid extent %d", name, extent)
+	}
+	tile, err := newMVTTile(z, x, y, int(extent))
+	if err != nil {
+		return "", FeatureCollection{}, err
+	}
+
+	fc := FeatureCollection{Features: []Feature{}}
+	for _, msg := range features {
+		ft, ok, err := decodeMVTFeature(msg, keys, values, tile)
+		if err != nil {
+			return "", FeatureCollection{}, fmt.Errorf("mvt: layer %q: %w", name, err)
+		}
+		if ok {
+			fc.Features = append(fc.Features, ft)
+		}
+	}
+	return name, fc, nil
+}
+
+func decodeMVTFeature(b []byte, keys []string, values []any, tile mvtTile) (Feature, bool, error) {
+	var ft Feature
+	var typ uint64
+	var tags, cmds []uint32
+
+	r := pbReader{b: b}
+	for !r.done() {
+		field, wire, err := r.key()
+		if err != nil {
+			return Feature{}, false, err
+		}
+		switch {
+		case field == 1 && wire == pbVarint:
+			id, err := r.varint()
+			if err != nil {
+				return Feature{}, false, err
+			}
+			ft.ID = float64(id)
+		case field == 2 && (wire == pbBytes || wire == pbVarint):
+			if tags, err = r.appendUint32s(tags, wire); err != nil {
+				return Feature{}, false, err
+			}
+		case field == 3 && wire == pbVarint:
+			if typ, err = r.varint(); err != nil {
+				return Feature{}, false, err
+			}
+		case field == 4 && (wire == pbBytes || wire == pbVarint):
+			if cmds, err = r.appendUint32s(cmds, wire); err != nil {
+				return Feature{}, false, err
+			}
+		default:
+			if err := r.skip(wire); err != nil {
+				return Feature{}, false, err
+			}
+		}
+	}
+
+	if len(tags)%2 != 0 {
+		return Feature{}, false, errors.New("odd number of tags")
+	}
+	for i := 0; i < len(tags); i += 2 {
+		k, v := int(tags[i]), int(tags[i+1])
+		if k >= len(keys) || v >= len(values) {
+			return Feature{}, false, fmt.Errorf("tag %d/%d out of range", k, v)
+		}
+		if ft.Properties == nil {
+			ft.Properties = map[string]any{}
+		}
+		ft.Properties[keys[k]] = values[v]
+	}
+
+	g, err := decodeMVTGeometry(typ, cmds)
+	if err != nil || g == nil {
+		return Feature{}, false, err
+	}
+	ft.geometry = mapPositions(g, tile.unproject)
+	return ft, true, nil
+}
+
+func decodeMVTValue(b []byte) (any, error) {
+	var v any
+	r := pbReader{b: b}
+	for !r.done() {
+		field, wire, err := r.key()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case field == 1 && wire == pbBytes:
+			s, err := r.bytes()
+			if err != nil {
+				return nil, err
+			}
+			v = string(s)
+		case field == 2 && wire == pbFixed32:
+			u, err := r.fixed32()
+			if err != nil {
+				return nil, err
+			}
+			v = float64(math.Float32frombits(u))
+		case field == 3 && wire == pbFixed64:
+			u, err := r.fixed64()
+			if err != nil {
+				return nil, err
+			}
+			v = math.Float64frombits(u)
+		case (field == 4 || field == 5 || field == 6 || field == 7) && wire == pbVarint:
+			u, err := r.varint()
+			if err != nil {
+				return nil, err
+			}
+			switch field {
+			case 4:
+				v = float64(int64(u))
+			case 5:
+				v = float64(u)
+			case 6:
+				v = float64(unzigzag(u))
+			default:
+				v = u != 0
+			}
+		default:
+			if err := r.skip(wire); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
+
+// decodeMVTGeometry interprets a command sequence, leaving positions in tile
+// coordinates. It returns nil for unknown geometry types and empty geometries.
+func decodeMVTGeometry(typ uint64, cmds []uint32) (any, error) {
+	var parts [][]Position
+	var x, y int64
+	for i := 0; i < len(cmds); {
+		id, count := cmds[i]&0x7, int(cmds[i]>>3)
+		i++
+		switch id {
+		case mvtMoveTo, mvtLineTo:
+			if len(cmds)-i < 2*count {
+				return nil, errors.New("truncated geometry")
+			}
+			for j := 0; j < count; j++ {
+				x += unzigzag(uint64(cmds[i]))
+				y += unzigzag(uint64(cmds[i+1]))
+				i += 2
+				p := Position{float64(x), float64(y)}
+				if id == mvtMoveTo {
+					parts = append(parts, []Position{p})
+					continue
+				}
+				if len(parts) == 0 {
+					return nil, errors.New("LineTo before MoveTo")
+				}
+				parts[len(parts)-1] = append(parts[len(parts)-1], p)
+			}
+		case mvtClosePath:
+			if len(parts) == 0 {
+				return nil, errors.New("ClosePath before MoveTo")
+			}
+			last := parts[len(parts)-1]
+			parts[len(parts)-1] = append(last, last[0])
+		default:
+			return nil, fmt.Errorf("unknown geometry command %d", id)
+		}
+	}
+
+	switch typ {
+	case mvtTypePoint:
+		var mp MultiPoint
+		for _, part := range parts {
+			mp = append(mp, part...)
+		}
+		switch len(mp) {
+		case 0:
+			return nil, nil
+		case 1:
+			return Point(mp[0]), nil
+		}
+		return mp, nil
+	case mvtTypeLineString:
+		var mls MultiLineString
+		for _, part := range parts {
+			if len(part) >= 2 {
+				mls = append(mls, part)
+			}
+		}
+		switch len(mls) {
+		case 0:
+			return nil, nil
+		case 1:
+			return LineString(mls[0]), nil
+		}
+		return mls, nil
+	case mvtTypePolygon:
+		// each exterior ring starts a polygon and holes follow it. Rings are
+		// reversed since the y axis points down, giving RFC 7946 winding.
+		var mp MultiPolygon
+		for _, ring := range parts {
+			switch area := ringArea(ring); {
+			case area > 0:
+				mp = append(mp, Polygon{reversePositions(ring)})
+			case area < 0 && len(mp) > 0:
+				mp[len(mp)-1] = append(mp[len(mp)-1], reversePositions(ring))
+			}
+		}
+		switch len(mp) {
+		case 0:
+			return nil, nil
+		case 1:
+			return mp[0], nil
+		}
+		return mp, nil
+	default:
+		return nil, nil
+	}
+}
+
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+func unzigzag(u uint64) int64 {
+	return int64(u>>1) ^ -int64(u&1)
+}
+
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func appendPBKey(b []byte, field, wire int) []byte {
+	return appendVarint(b, uint64(field<<3|wire))
+}
+
+func appendPBVarint(b []byte, field int, v uint64) []byte {
+	return appendVarint(appendPBKey(b, field, pbVarint), v)
+}
+
+func appendPBBytes(b []byte, field int, v []byte) []byte {
+	b = appendVarint(appendPBKey(b, field, pbBytes), uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendPBFixed32(b []byte, field int, v uint32) []byte {
+	b = appendPBKey(b, field, pbFixed32)
+	return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func appendPBFixed64(b []byte, field int, v uint64) []byte {
+	b = appendPBKey(b, field, pbFixed64)
+	for i := 0; i < 8; i++ {
+		b = append(b, byte(v>>(8*i)))
+	}
+	return b
+}
+
+// appendPBPacked writes vs as a packed repeated field, omitting it when empty.
+func appendPBPacked(b []byte, field int, vs []uint32) []byte {
+	if len(vs) == 0 {
+		return b
+	}
+	var packed []byte
+	for _, v := range vs {
+		packed = appendVarint(packed, uint64(v))
+	}
+	return appendPBBytes(b, field, packed)
+}
+
+// pbReader reads protocol buffer wire format fields.
+type pbReader struct {
+	b   []byte
+	off int
+}
+
+func (r *pbReader) done() bool {
+	return r.off >= len(r.b)
+}
+
+func (r *pbReader) varint() (uint64, error) {
+	var v uint64
+	for shift := 0; shift < 64; shift += 7 {
+		if r.done() {
+			return 0, errMVTShort
+		}
+		c := r.b[r.off]
+		r.off++
+		v |= uint64(c&0x7f) << shift
+		if c < 0x80 {
+			return v, nil
+		}
+	}
+	return 0, errors.New("mvt: varint overflow")
+}
+
+func (r *pbReader) key() (field, wire int, err error) {
+	v, err := r.varint()
+	return int(v >> 3), int(v & 0x7), err
+}
+
+func (r *pbReader) bytes() ([]byte, error) {
+	n, err := r.varint()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(r.b)-r.off) {
+		return nil, errMVTShort
+	}
+	v := r.b[r.off : r.off+int(n)]
+	r.off += int(n)
+	return v, nil
+}
+
+func (r *pbReader) fixed(n int) (uint64, error) {
+	if len(r.b)-r.off < n {
+		return 0, errMVTShort
+	}
+	var v uint64
+	for i := 0; i < n; i++ {
+		v |= uint64(r.b[r.off+i]) << (8 * i)
+	}
+	r.off += n
+	return v, nil
+}
+
+func (r *pbReader) fixed32() (uint32, error) {
+	v, err := r.fixed(4)
+	return uint32(v), err
+}
+
+func (r *pbReader) fixed64() (uint64, error) {
+	return r.fixed(8)
+}
+
+// appendUint32s reads a repeated uint32 field, packed or not.
+func (r *pbReader) appendUint32s(vs []uint32, wire int) ([]uint32, error) {
+	if wire == pbVarint {
+		v, err := r.varint()
+		return append(vs, uint32(v)), err
+	}
+	b, err := r.bytes()
+	if err != nil {
+		return nil, err
+	}
+	packed := pbReader{b: b}
+	for !packed.done() {
+		v, err := packed.varint()
+		if err != nil {
+			return nil, err
+		}
+		vs = append(vs, uint32(v))
+	}
+	return vs, nil
+}
+
+func (r *pbReader) skip(wire int) error {
+	var err error
+	switch wire {
+	case pbVarint:
+		_, err = r.varint()
+	case pbFixed64:
+		_, err = r.fixed(8)
+	case pbBytes:
+		_, err = r.bytes()
+	case pbFixed32:
+		_, err = r.fixed(4)
+	default:
+		err = fmt.Errorf("mvt: unsupported wire type %d", wire)
+	}
+	return err
+}
@@ -0,0 +1,189 @@
+package joejson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeMVTGeometry(t *testing.T) {
+	// examples from the vector tile specification.
+	testCases := map[string]struct {
+		g    any
+		typ  uint64
+		cmds []uint32
+		// decoded is the expected decoding when it differs from g.
+		decoded any
+	}{
+		"Point": {
+			g:    Point{25, 17},
+			typ:  mvtTypePoint,
+			cmds: []uint32{9, 50, 34},
+		},
+		"MultiPoint": {
+			g:    MultiPoint{{5, 7}, {3, 2}},
+			typ:  mvtTypePoint,
+			cmds: []uint32{17, 10, 14, 3, 9},
+		},
+		"LineString": {
+			g:    LineString{{2, 2}, {2, 10}, {10, 10}},
+			typ:  mvtTypeLineString,
+			cmds: []uint32{9, 4, 4, 18, 0, 16, 16, 0},
+		},
+		"MultiLineString": {
+			g:    MultiLineString{{{2, 2}, {2, 10}, {10, 10}}, {{1, 1}, {3, 5}}},
+			typ:  mvtTypeLineString,
+			cmds: []uint32{9, 4, 4, 18, 0, 16, 16, 0, 9, 17, 17, 10, 4, 8},
+		},
+		"Polygon": {
+			g:    Polygon{{{3, 6}, {8, 12}, {20, 34}, {3, 6}}},
+			typ:  mvtTypePolygon,
+			cmds: []uint32{9, 6, 12, 18, 10, 12, 24, 44, 15},
+			// the y axis points down, so rings are reversed.
+			decoded: Polygon{{{3, 6}, {20, 34}, {8, 12}, {3, 6}}},
+		},
+	}
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			typ, cmds := encodeMVTGeometry(tt.g)
+			assert.Equal(t, tt.typ, typ)
+			assert.Equal(t, tt.cmds, cmds)
+
+			want := tt.decoded
+			if want == nil {
+				want = tt.g
+			}
+			got, err := decodeMVTGeometry(typ, cmds)
+			assert.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestMVT(t *testing.T) {
+	// tile 1/1/0 covers longitudes 0 to 180 and latitudes 0 to ~85.05.
+	fc := FeatureCollection{Features: []Feature{
+		Feature{ID: 7.0, Properties: map[string]any{"name": "a", "n": 2, "ok": true, "skip": nil}}.WithPoint(Point{90, 0}),
+		Feature{ID: "not numeric", Properties: map[string]any{"name": "a"}}.WithLineString(LineString{{-90, 0}, {90, 0}}),
+		Feature{}.WithPolygon(Polygon{
+			{{45, 0}, {135, 0}, {135, 66.51326044311186}, {45, 66.51326044311186}, {45, 0}},
+			// hole wound the wrong way.
+			{{67.5, 40.97989806962013}, {112.5, 40.97989806962013}, {112.5, 55.77657301866769}, {67.5, 55.77657301866769}, {67.5, 40.97989806962013}},
+		}),
+		Feature{}.WithPoint(Point{-90, 0}),
+		Feature{Properties: map[string]any{"tags": []string{"x"}}}.WithGeometryCollection(
+			GeometryCollection{}.AppendPoint(Point{90, 0}).AppendLineString(LineString{{90, 0}, {135, 0}}),
+		),
+	}}
+
+	b, err := fc.MarshalMVT("layer", 1, 1, 0, MVTOptions{})
+	assert.NoError(t, err)
+
+	layers, err := UnmarshalMVT(b, 1, 1, 0)
+	assert.NoError(t, err)
+	got := layers["layer"].Features
+	assert.Len(t, got, 5)
+
+	assert.Equal(t, 7.0, got[0].ID)
+	assert.Equal(t, map[string]any{"name": "a", "n": 2.0, "ok": true}, got[0].Properties)
+	assertPositionsInDelta(t, Point{90, 0}, got[0].geometry)
+
+	assert.Nil(t, got[1].ID)
+	assertPositionsInDelta(t, LineString{{0, 0}, {90, 0}}, got[1].geometry)
+
+	// rings come back with RFC 7946 winding.
+	pl, ok := got[2].AsPolygon()
+	assert.True(t, ok)
+	if assert.Len(t, pl, 2) {
+		assert.Greater(t, ringArea(pl[0]), 0.0)
+		assert.Less(t, ringArea(pl[1]), 0.0)
+		b, _ := geometryBounds(LineString(pl[0]))
+		assert.InDeltaSlice(t, []float64{45, 0, 135, 66.51326044311186}, []float64{b.minX, b.minY, b.maxX, b.maxY}, 1e-6)
+		b, _ = geometryBounds(LineString(pl[1]))
+		assert.InDeltaSlice(t, []float64{67.5, 40.97989806962013, 112.5, 55.77657301866769}, []float64{b.minX, b.minY, b.maxX, b.maxY}, 1e-6)
+	}
+
+	// the GeometryCollection is split by geometry type.
+	assert.Equal(t, map[string]any{"tags": `["x"]`}, got[3].Properties)
+	assertPositionsInDelta(t, Point{90, 0}, got[3].geometry)
+	assertPositionsInDelta(t, LineString{{90, 0}, {135, 0}}, got[4].geometry)
+
+	t.Run("buffer", func(t *testing.T) {
+		b, err := fc.MarshalMVT("layer", 1, 1, 0, MVTOptions{Extent: 256, Buffer: 64})
+		assert.NoError(t, err)
+		layers, err := UnmarshalMVT(b, 1, 1, 0)
+		assert.NoError(t, err)
+		assertPositionsInDelta(t, LineString{{-45, 0}, {90, 0}}, layers["layer"].Features[1].geometry)
+	})
+
+	t.Run("layers", func(t *testing.T) {
+		b, err := MarshalMVT(map[string]FeatureCollection{"a": fc, "b": {}}, 1, 1, 0, MVTOptions{})
+		assert.NoError(t, err)
+		layers, err := UnmarshalMVT(b, 1, 1, 0)
+		assert.NoError(t, err)
+		assert.Len(t, layers["a"].Features, 5)
+		assert.Equal(t, FeatureCollection{Features: []Feature{}}, layers["b"])
+	})
+}
+
+func TestMVTValue(t *testing.T) {
+	testCases := map[string]struct {
+		v    any
+		want any
+	}{
+		"int8":   {int8(-3), -3.0},
+		"int16":  {int16(-300), -300.0},
+		"int":    {-7, -7.0},
+		"uint8":  {uint8(200), 200.0},
+		"uint16": {uint16(60000), 60000.0},
+		"uint64": {uint64(9), 9.0},
+		"float":  {1.5, 1.5},
+		"bool":   {true, true},
+		"string": {"x", "x"},
+		"other":  {[]int{1}, "[1]"},
+	}
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			b, err := encodeMVTValue(tt.v)
+			assert.NoError(t, err)
+			got, err := decodeMVTValue(b)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMVTErrors(t *testing.T) {
+	_, err := FeatureCollection{}.MarshalMVT("layer", 1, 2, 0, MVTOptions{})
+	assert.EqualError(t, err, "mvt: tile 1/2/0 out of range")
+
+	_, err = FeatureCollection{}.MarshalMVT("layer", 0, 0, 0, MVTOptions{Extent: -1})
+	assert.EqualError(t, err, "mvt: invalid extent -1")
+
+	fc := FeatureCollection{Features: []Feature{Feature{}.WithPoint(Point{1, 1})}}
+	b, err := fc.MarshalMVT("layer", 0, 0, 0, MVTOptions{})
+	assert.NoError(t, err)
+	_, err = UnmarshalMVT(b[:len(b)-1], 0, 0, 0)
+	assert.ErrorIs(t, err, errMVTShort)
+
+	_, err = decodeMVTGeometry(mvtTypeLineString, []uint32{10, 0, 0})
+	assert.EqualError(t, err, "LineTo before MoveTo")
+
+	_, err = decodeMVTGeometry(mvtTypePoint, []uint32{17, 0, 0})
+	assert.EqualError(t, err, "truncated geometry")
+}
+
+// assertPositionsInDelta compares the type and every Position of two geometries.
+func assertPositionsInDelta(t *testing.T, want, got any) {
+	t.Helper()
+	assert.Equal(t, geometryType(want), geometryType(got))
+	var w, g []Position
+	eachPosition(want, func(p Position) { w = append(w, p) })
+	eachPosition(got, func(p Position) { g = append(g, p) })
+	if !assert.Equal(t, len(w), len(g)) {
+		return
+	}
+	for i := range w {
+		assert.InDeltaSlice(t, w[i], g[i], 1e-6)
+	}
+}
@@ -0,0 +1,454 @@
+package joejson
+
+import (
+	"math"
+	"sort"
+)
+
+// overlayEps is the relative tolerance for parallel edges and for
+// intersections falling on an edge's end.
+const overlayEps = 1e-10
+
+type overlayOp int
+
+const (
+	overlayUnion overlayOp = iota
+	overlayIntersection
+	overlayDifference
+	overlayXor
+)
+
+// Union returns the area covered by either Polygon.
+func (p Polygon) Union(o Polygon) MultiPolygon {
+	return overlay(MultiPolygon{p}, MultiPolygon{o}, overlayUnion)
+}
+
+// Intersection returns the area covered by both Polygons.
+func (p Polygon) Intersection(o Polygon) MultiPolygon {
+	return overlay(MultiPolygon{p}, MultiPolygon{o}, overlayIntersection)
+}
+
+// Difference returns the area covered by the Polygon but not by o.
+func (p Polygon) Difference(o Polygon) MultiPolygon {
+	return overlay(MultiPolygon{p}, MultiPolygon{o}, overlayDifference)
+}
+
+// Xor returns the area covered by exactly one of the Polygons.
+func (p Polygon) Xor(o Polygon) MultiPolygon {
+	return overlay(MultiPolygon{p}, MultiPolygon{o}, overlayXor)
+}
+
+// Union returns the area covered by either MultiPolygon.
+//
+// Like the other overlay operations it works in planar lon/lat, accepts
+// rings of either winding and returns exterior rings counterclockwise and
+// holes clockwise, as RFC 7946 recommends. Edges the inputs share are
+// merged, and parts meeting at a single point stay separate Polygons.
+// Elevations are dropped.
+func (p MultiPolygon) Union(o MultiPolygon) MultiPolygon {
+	return overlay(p, o, overlayUnion)
+}
+
+// Intersection returns the area covered by both MultiPolygons.
+// See MultiPolygon.Union.
+func (p MultiPolygon) Intersection(o MultiPolygon) MultiPolygon {
+	return overlay(p, o, overlayIntersection)
+}
+
+// Difference returns the area covered by the MultiPolygon but not by o.
+// See MultiPolygon.Union.
+func (p MultiPolygon) Difference(o MultiPolygon) MultiPolygon {
+	return overlay(p, o, overlayDifference)
+}
+
+// Xor returns the area covered by exactly one of the MultiPolygons.
+// See MultiPolygon.Union.
+func (p MultiPolygon) Xor(o MultiPolygon) MultiPolygon {
+	return overlay(p, o, overlayXor)
+}
+
+// overlayEdge is a directed edge of one input, with the interior of that
+// input on its left.
+type overlayEdge struct {
+	a, b Position
+	// src is 0 for the first input and 1 for the second.
+	src    int
+	splits []Position
+}
+
+// overlayKey identifies a directed edge by its end points.
+type overlayKey struct {
+	ax, ay, bx, by float64
+}
+
+func edgeKey(a, b Position) overlayKey {
+	return overlayKey{a.Lon(), a.Lat(), b.Lon(), b.Lat()}
+}
+
+// overlayRing is an open ring and its bounds.
+type overlayRing struct {
+	ps     []Position
+	bounds bounds
+}
+
+// overlay splits the edges of a and b wherever they meet, keeps the pieces
+// bounding the result of op and joins them back into rings.
+func overlay(a, b MultiPolygon, op overlayOp) MultiPolygon {
+	s := newSnapper(a, b)
+	rings := [2][]overlayRing{overlayRings(a, s), overlayRings(b, s)}
+	edges := splitOverlayEdges(rings, s)
+
+	var keys [2]map[overlayKey]bool
+	for i := range keys {
+		keys[i] = make(map[overlayKey]bool)
+	}
+	for _, e := range edges {
+		keys[e.src][edgeKey(e.a, e.b)] = true
+	}
+
+	var selected [][2]Position
+	for _, e := range edges {
+		other := 1 - e.src
+		same := keys[other][edgeKey(e.a, e.b)]
+		opposite := keys[other][edgeKey(e.b, e.a)]
+		inside := false
+		if !same && !opposite {
+			inside = ringsContain(rings[other], lerpPosition(e.a, e.b, 0.5))
+		}
+
+		keep, reverse := false, false
+		switch op {
+		case overlayUnion:
+			keep = (!inside && !opposite && !same) || (same && e.src == 0)
+		case overlayIntersection:
+			keep = inside || (same && e.src == 0)
+		case overlayDifference:
+			if e.src == 0 {
+				keep = (!inside && !same && !opposite) || opposite
+			} else {
+				keep, reverse = inside, true
+			}
+		case overlayXor:
+			keep = !same && !opposite
+			reverse = inside
+		}
+		switch {
+		case keep && reverse:
+			selected = append(selected, [2]Position{e.b, e.a})
+		case keep:
+			selected = append(selected, [2]Position{e.a, e.b})
+		}
+	}
+	return assembleRings(selected)
+}
+
+// snapper merges positions closer than its tolerance, so positions that
+// should coincide but differ by rounding error become equal.
+type snapper struct {
+	tol   float64
+	cells map[[2]int64][]Position
+}
+
+// newSnapper returns a snapper whose tolerance is about 2^-36 of the
+// largest coordinate in a or b.
+func newSnapper(a, b MultiPolygon) *snapper {
+	var m float64
+	for _, mp := range []MultiPolygon{a, b} {
+		eachPosition(mp, func(p Position) {
+			m = math.Max(m, math.Max(math.Abs(p.Lon()), math.Abs(p.Lat())))
+		})
+	}
+	_, exp := math.Frexp(m)
+	return &snapper{tol: math.Ldexp(1, exp-36), cells: make(map[[2]int64][]Position)}
+}
+
+// snap returns a position already seen within tolerance of p, or p itself
+// without any elevation.
+func (s *snapper) snap(p Position) Position {
+	cx, cy := int64(math.Floor(p.Lon()/s.tol)), int64(math.Floor(p.Lat()/s.tol))
+	for dx := int64(-1); dx <= 1; dx++ {
+		for dy := int64(-1); dy <= 1; dy++ {
+			for _, q := range s.cells[[2]int64{cx + dx, cy + dy}] {
+				if math.Abs(q.Lon()-p.Lon()) <= s.tol && math.Abs(q.Lat()-p.Lat()) <= s.tol {
+					return q
+				}
+			}
+		}
+	}
+	q := Position{p.Lon(), p.Lat()}
+	s.cells[[2]int64{cx, cy}] = append(s.cells[[2]int64{cx, cy}], q)
+	return q
+}
+
+// overlayRings normalizes every ring: positions are snapped,
+// repeated positions are dropped, as are polygons whose exterior ring
+// collapses, and rings are wound so the interior is on their left.
+func overlayRings(mp MultiPolygon, s *snapper) []overlayRing {
+	var out []overlayRing
+	for _, p := range mp {
+		for i, lr := range p {
+			var ring []Position
+			for _, pos := range openRing(lr) {
+				pos = s.snap(pos)
+				if len(ring) == 0 || !positionsEqual(ring[len(ring)-1], pos) {
+					ring = append(ring, pos)
+				}
+			}
+			for len(ring) > 1 && positionsEqual(ring[0], ring[len(ring)-1]) {
+				ring = ring[:len(ring)-1]
+			}
+			area := ringArea(ring)
+			if len(ring) < 3 || area == 0 {
+				if i == 0 {
+					break
+				}
+				continue
+			}
+			if (area > 0) != (i == 0) {
+				ring = reversePositions(ring)
+			}
+			b, _ := geometryBounds(LineString(ring))
+			out = append(out, overlayRing{ring, b})
+		}
+	}
+	return out
+}
+
+// ringsContain reports whether p is inside an odd number of rings.
+func ringsContain(rings []overlayRing, p Position) bool {
+	in := false
+	for _, r := range rings {
+		if r.bounds.containsPosition(p) && ringContains(r.ps, p) {
+			in = !in
+		}
+	}
+	return in
+}
+
+// splitOverlayEdges returns the edges of both inputs split at every point
+// where they touch or cross another edge, so that edges only meet at their
+// ends and shared stretches become identical edges.
+func splitOverlayEdges(rings [2][]overlayRing, s *snapper) []overlayEdge {
+	var edges []*overlayEdge
+	for src, rs := range rings {
+		for _, r := range rs {
+			for i, a := range r.ps {
+				edges = append(edges, &overlayEdge{a: a, b: r.ps[(i+1)%len(r.ps)], src: src})
+			}
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		return math.Min(edges[i].a.Lon(), edges[i].b.Lon()) < math.Min(edges[j].a.Lon(), edges[j].b.Lon())
+	})
+	for i, e := range edges {
+		maxX := math.Max(e.a.Lon(), e.b.Lon())
+		minY, maxY := math.Min(e.a.Lat(), e.b.Lat()), math.Max(e.a.Lat(), e.b.Lat())
+		for _, f := range edges[i+1:] {
+			if math.Min(f.a.Lon(), f.b.Lon()) > maxX {
+				break
+			}
+			if math.Max(f.a.Lat(), f.b.Lat()) < minY || math.Min(f.a.Lat(), f.b.Lat()) > maxY {
+				continue
+			}
+			intersectEdges(e, f, s)
+		}
+	}
+
+	var out []overlayEdge
+	for _, e := range edges {
+		d := vecSub(e.b, e.a)
+		param := func(p Position) float64 {
+			return vecDot(vecSub(p, e.a), d)
+		}
+		sort.Slice(e.splits, func(i, j int) bool {
+			return param(e.splits[i]) < param(e.splits[j])
+		})
+		prev := e.a
+		for _, p := range append(e.splits, e.b) {
+			if positionsEqual(prev, p) {
+				continue
+			}
+			out = append(out, overlayEdge{a: prev, b: p, src: e.src})
+			prev = p
+		}
+	}
+	return out
+}
+
+// intersectEdges records where e and f touch or cross as splits of both.
+// Points within tolerance of an end snap to it, so edges meeting at a
+// vertex share it exactly.
+func intersectEdges(e, f *overlayEdge, sn *snapper) {
+	r, s := vecSub(e.b, e.a), vecSub(f.b, f.a)
+	qp := vecSub(f.a, e.a)
+	d := vecCross(r, s)
+	if math.Abs(d) <= overlayEps*vecNorm(r)*vecNorm(s) {
+		if math.Abs(vecCross(qp, r)) > overlayEps*vecNorm(r)*vecNorm(qp) {
+			return
+		}
+		// collinear: each end inside the other edge splits it.
+		e.splitAt(f.a)
+		e.splitAt(f.b)
+		f.splitAt(e.a)
+		f.splitAt(e.b)
+		return
+	}
+
+	t, u := vecCross(qp, s)/d, vecCross(qp, r)/d
+	if t < -overlayEps || t > 1+overlayEps || u < -overlayEps || u > 1+overlayEps {
+		return
+	}
+	var p Position
+	switch {
+	case math.Abs(t) <= overlayEps:
+		p = e.a
+	case math.Abs(t-1) <= overlayEps:
+		p = e.b
+	case math.Abs(u) <= overlayEps:
+		p = f.a
+	case math.Abs(u-1) <= overlayEps:
+		p = f.b
+	default:
+		p = sn.snap(lerpPosition(e.a, e.b, t))
+	}
+	e.splitAt(p)
+	f.splitAt(p)
+}
+
+// splitAt records p as a split of e if it lies strictly between its ends.
+func (e *overlayEdge) splitAt(p Position) {
+	if positionsEqual(p, e.a) || positionsEqual(p, e.b) {
+		return
+	}
+	d := vecSub(e.b, e.a)
+	if t := vecDot(vecSub(p, e.a), d) / vecDot(d, d); t > 0 && t < 1 {
+		e.splits = append(e.splits, p)
+	}
+}
+
+// assembleRings joins directed edges into rings, taking the sharpest left
+// turn wherever several edges leave a vertex so each ring hugs one face.
+// Counterclockwise rings become exteriors and clockwise rings their holes.
+func assembleRings(edges [][2]Position) MultiPolygon {
+	// edges used in both directions separate two parts of the result.
+	count := make(map[overlayKey]int)
+	for _, e := range edges {
+		count[edgeKey(e[0], e[1])]++
+	}
+	var kept [][2]Position
+	for _, e := range edges {
+		k := edgeKey(e[0], e[1])
+		if count[k] > 0 && count[edgeKey(e[1], e[0])] == 0 {
+			kept = append(kept, e)
+			count[k] = 0
+		}
+	}
+
+	outgoing := make(map[[2]float64][]int)
+	for i, e := range kept {
+		k := [2]float64{e[0].Lon(), e[0].Lat()}
+		outgoing[k] = append(outgoing[k], i)
+	}
+
+	used := make([]bool, len(kept))
+	var rings [][]Position
+	for i := range kept {
+		if used[i] {
+			continue
+		}
+		used[i] = true
+		ring := []Position{kept[i][0]}
+		for cur := i; ; {
+			end := kept[cur][1]
+			if positionsEqual(end, ring[0]) {
+				rings = append(rings, splitTouchingRing(ring)...)
+				break
+			}
+			ring = append(ring, end)
+
+			in := vecSub(end, kept[cur][0])
+			next, best := -1, math.Inf(-1)
+			for _, j := range outgoing[[2]float64{end.Lon(), end.Lat()}] {
+				if used[j] {
+					continue
+				}
+				dir := vecSub(kept[j][1], end)
+				turn := math.Atan2(vecCross(in, dir), vecDot(in, dir))
+				if turn == math.Pi {
+					turn = -math.Pi
+				}
+				if turn > best {
+					next, best = j, turn
+				}
+			}
+			if next < 0 {
+				break
+			}
+			used[next] = true
+			cur = next
+		}
+	}
+
+	var out MultiPolygon
+	var holes [][]Position
+	for _, r := range rings {
+		switch area := ringArea(r); {
+		case len(r) < 3:
+		case area > 0:
+			out = append(out, Polygon{append(r, r[0])})
+		case area < 0:
+			holes = append(holes, r)
+		}
+	}
+	for _, h := range holes {
+		p := lerpPosition(h[0], h[1], 0.5)
+		best, bestArea := -1, math.Inf(1)
+		for i, pl := range out {
+			if area := ringArea(pl[0]); area < bestArea && ringContains(pl[0], p) {
+				best, bestArea = i, area
+			}
+		}
+		if best >= 0 {
+			out[best] = append(out[best], append(h, h[0]))
+		}
+	}
+	return out
+}
+
+// splitTouchingRing splits an open ring at positions it passes more than
+// once, returning each loop as its own open ring.
+func splitTouchingRing(ring []Position) [][]Position {
+	var out [][]Position
+	var stack []Position
+	seen := make(map[[2]float64]int)
+	for _, p := range ring {
+		k := [2]float64{p.Lon(), p.Lat()}
+		if i, ok := seen[k]; ok {
+			out = append(out, append([]Position(nil), stack[i:]...))
+			for _, q := range stack[i+1:] {
+				delete(seen, [2]float64{q.Lon(), q.Lat()})
+			}
+			stack = stack[:i+1]
+			continue
+		}
+		seen[k] = len(stack)
+		stack = append(stack, p)
+	}
+	return append(out, stack)
+}
+
+func vecSub(a, b Position) Position {
+	return Position{a.Lon() - b.Lon(), a.Lat() - b.Lat()}
+}
+
+func vecDot(a, b Position) float64 {
+	return a.Lon()*b.Lon() + a.Lat()*b.Lat()
+}
+
+func vecCross(a, b Position) float64 {
+	return a.Lon()*b.Lat() - a.Lat()*b.Lon()
+}
+
+func vecNorm(a Position) float64 {
+	return math.Hypot(a.Lon(), a.Lat())
+}
@@ -0,0 +1,183 @@
+package joejson
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func square(x, y, size float64) Polygon {
+	return Polygon{{{x, y}, {x + size, y}, {x + size, y + size}, {x, y + size}, {x, y}}}
+}
+
+// multiPolygonArea sums exterior areas less hole areas, checking winding.
+func multiPolygonArea(t *testing.T, mp MultiPolygon) float64 {
+	t.Helper()
+	var total float64
+	for _, p := range mp {
+		for i, r := range p {
+			assert.True(t, positionsEqual(r[0], r[len(r)-1]), "ring not closed")
+			area := ringArea(r)
+			if i == 0 {
+				assert.Greater(t, area, 0.0, "exterior not counterclockwise")
+			} else {
+				assert.Less(t, area, 0.0, "hole not clockwise")
+			}
+			total += area
+		}
+	}
+	return total
+}
+
+func TestOverlay(t *testing.T) {
+	withHole := Polygon{
+		{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}},
+		{{3, 3}, {3, 7}, {7, 7}, {7, 3}, {3, 3}},
+	}
+	testCases := map[string]struct {
+		a, b                    MultiPolygon
+		union, inter, diff, xor float64
+		unionParts              int
+	}{
+		"overlapping": {
+			a: MultiPolygon{square(0, 0, 2)}, b: MultiPolygon{square(1, 1, 2)},
+			union: 7, inter: 1, diff: 3, xor: 6, unionParts: 1,
+		},
+		"disjoint": {
+			a: MultiPolygon{square(0, 0, 1)}, b: MultiPolygon{square(5, 5, 1)},
+			union: 2, inter: 0, diff: 1, xor: 2, unionParts: 2,
+		},
+		"shared edge": {
+			a: MultiPolygon{square(0, 0, 1)}, b: MultiPolygon{square(1, 0, 1)},
+			union: 2, inter: 0, diff: 1, xor: 2, unionParts: 1,
+		},
+		"partly shared edge": {
+			a: MultiPolygon{square(0, 0, 2)}, b: MultiPolygon{square(2, 1, 2)},
+			union: 8, inter: 0, diff: 4, xor: 8, unionParts: 1,
+		},
+		"touching corner": {
+			a: MultiPolygon{square(0, 0, 1)}, b: MultiPolygon{square(1, 1, 1)},
+			union: 2, inter: 0, diff: 1, xor: 2, unionParts: 2,
+		},
+		"identical": {
+			a: MultiPolygon{square(0, 0, 1)}, b: MultiPolygon{square(0, 0, 1)},
+			union: 1, inter: 1, diff: 0, xor: 0, unionParts: 1,
+		},
+		"contained": {
+			a: MultiPolygon{square(0, 0, 4)}, b: MultiPolygon{square(1, 1, 1)},
+			union: 16, inter: 1, diff: 15, xor: 15, unionParts: 1,
+		},
+		"hole filled": {
+			a: MultiPolygon{withHole}, b: MultiPolygon{square(3, 3, 4)},
+			union: 100, inter: 0, diff: 84, xor: 100, unionParts: 1,
+		},
+		"across hole": {
+			a: MultiPolygon{withHole}, b: MultiPolygon{square(5, -5, 10)},
+			union: 163, inter: 21, diff: 63, xor: 142, unionParts: 1,
+		},
+		"empty": {
+			a: MultiPolygon{square(0, 0, 2)}, b: nil,
+			union: 4, inter: 0, diff: 4, xor: 4, unionParts: 1,
+		},
+		"degenerate": {
+			a: MultiPolygon{square(0, 0, 2)}, b: MultiPolygon{{{{1, 1}, {1, 1}, {3, 3}, {1, 1}}}},
+			union: 4, inter: 0, diff: 4, xor: 4, unionParts: 1,
+		},
+	}
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			union := tt.a.Union(tt.b)
+			assert.InDelta(t, tt.union, multiPolygonArea(t, union), 1e-9)
+			assert.Len(t, union, tt.unionParts)
+			assert.InDelta(t, tt.inter, multiPolygonArea(t, tt.a.Intersection(tt.b)), 1e-9)
+			assert.InDelta(t, tt.diff, multiPolygonArea(t, tt.a.Difference(tt.b)), 1e-9)
+			assert.InDelta(t, tt.xor, multiPolygonArea(t, tt.a.Xor(tt.b)), 1e-9)
+		})
+	}
+
+	t.Run("Polygon", func(t *testing.T) {
+		got := square(0, 0, 2).Intersection(square(1, 1, 2))
+		assert.Equal(t, MultiPolygon{{{{2, 2}, {1, 2}, {1, 1}, {2, 1}, {2, 2}}}}, got)
+	})
+
+	t.Run("clockwise input", func(t *testing.T) {
+		cw := Polygon{reversePositions(square(1, 1, 2)[0])}
+		assert.InDelta(t, 7.0, multiPolygonArea(t, square(0, 0, 2).Union(cw)), 1e-9)
+	})
+
+	t.Run("difference makes hole", func(t *testing.T) {
+		got := square(0, 0, 10).Difference(square(3, 3, 4))
+		assert.Len(t, got, 1)
+		assert.Len(t, got[0], 2)
+		assert.InDelta(t, 84.0, multiPolygonArea(t, got), 1e-9)
+	})
+
+	t.Run("hole touching exterior", func(t *testing.T) {
+		tri := Polygon{{{0, 0}, {5, 5}, {0, 10}, {0, 0}}}
+		got := square(0, 0, 10).Difference(tri)
+		assert.Len(t, got, 1)
+		assert.Len(t, got[0], 1)
+		assert.InDelta(t, 75.0, multiPolygonArea(t, got), 1e-9)
+
+		inner := Polygon{{{0, 5}, {5, 3}, {5, 7}, {0, 5}}}
+		got = square(0, 0, 10).Difference(inner)
+		assert.Len(t, got, 1)
+		assert.Len(t, got[0], 2)
+		assert.InDelta(t, 90.0, multiPolygonArea(t, got), 1e-9)
+	})
+
+	t.Run("adjacent parts dissolve", func(t *testing.T) {
+		var row MultiPolygon
+		for i := 0; i < 5; i++ {
+			row = append(row, square(float64(i), 0, 1))
+		}
+		got := row.Union(nil)
+		assert.Len(t, got, 1)
+		assert.InDelta(t, 5.0, multiPolygonArea(t, got), 1e-9)
+	})
+}
+
+func TestOverlayNearCoincidentVertices(t *testing.T) {
+	// b's first vertex is a rounding error away from a's third.
+	a := MultiPolygon{{{{0.5, 0}, {0.5, 0.4}, {0.1, 0.3}, {0.5, 0}}}}
+	b := MultiPolygon{{{{0.1 + 4e-15, 0.3 - 1e-15}, {0.1, 0}, {0.5, 0.2}, {0.1 + 4e-15, 0.3 - 1e-15}}}}
+	areaA, areaB := multiPolygonArea(t, a), multiPolygonArea(t, b)
+	union := multiPolygonArea(t, a.Union(b))
+	inter := multiPolygonArea(t, a.Intersection(b))
+	assert.InDelta(t, areaA+areaB, union+inter, 1e-9)
+	assert.InDelta(t, areaA-inter, multiPolygonArea(t, a.Difference(b)), 1e-9)
+	assert.InDelta(t, union-inter, multiPolygonArea(t, a.Xor(b)), 1e-9)
+}
+
+func TestOverlayDropsElevations(t *testing.T) {
+	a := Polygon{{{0, 0, 5}, {2, 0, 5}, {2, 2, 5}, {0, 2, 5}, {0, 0, 5}}}
+	got := a.Intersection(square(1, 1, 2))
+	assert.Equal(t, MultiPolygon{{{{2, 2}, {1, 2}, {1, 1}, {2, 1}, {2, 2}}}}, got)
+}
+
+func TestOverlayAreaIdentities(t *testing.T) {
+	// for any a and b: |a∪b| + |a∩b| = |a| + |b|, |a-b| = |a| - |a∩b| and
+	// |a xor b| = |a∪b| - |a∩b|.
+	star := func(cx, cy, turn float64) MultiPolygon {
+		var ring LinearRing
+		for i := 0; i < 14; i++ {
+			r := 10.0
+			if i%2 == 1 {
+				r = 4
+			}
+			a := float64(i)*math.Pi/7 + turn
+			ring = append(ring, Position{cx + r*math.Cos(a), cy + r*math.Sin(a)})
+		}
+		return MultiPolygon{{append(ring, ring[0])}}
+	}
+	for _, b := range []MultiPolygon{star(3, 1, 0.2), star(0, 0, 0.1), star(0, 0, math.Pi/7), star(12, 3, 0)} {
+		a := star(0, 0, 0)
+		areaA, areaB := multiPolygonArea(t, a), multiPolygonArea(t, b)
+		union := multiPolygonArea(t, a.Union(b))
+		inter := multiPolygonArea(t, a.Intersection(b))
+		assert.InDelta(t, areaA+areaB, union+inter, 1e-9)
+		assert.InDelta(t, areaA-inter, multiPolygonArea(t, a.Difference(b)), 1e-9)
+		assert.InDelta(t, union-inter, multiPolygonArea(t, a.Xor(b)), 1e-9)
+	}
+}
@@ -0,0 +1,113 @@
+package joejson
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+var errPolylineTruncated = errors.New("polyline: truncated input")
+
+// EncodePolyline encodes the LineString with the Google encoded polyline
+// algorithm, using precision decimal places (5 or 6 in practice).
+// Positions are written latitude first, as the format requires.
+func (g LineString) EncodePolyline(precision int) string {
+	factor := math.Pow10(precision)
+
+	var sb strings.Builder
+	var prevLat, prevLon int64
+	for _, p := range g {
+		lat := int64(math.Round(p.Lat() * factor))
+		lon := int64(math.Round(p.Lon() * factor))
+		writePolylineValue(&sb, lat-prevLat)
+		writePolylineValue(&sb, lon-prevLon)
+		prevLat, prevLon = lat, lon
+	}
+	return sb.String()
+}
+
+// DecodePolyline decodes a Google encoded polyline with precision decimal places into a LineString.
+func DecodePolyline(s string, precision int) (LineString, error) {
+	factor := math.Pow10(precision)
+
+	out := LineString{}
+	var lat, lon int64
+	for i := 0; i < len(s); {
+		dLat, n, err := readPolylineValue(s[i:])
+		if err != nil {
+			return nil, err
+		}
+		i += n
+		if i >= len(s) {
+			return nil, errPolylineTruncated
+		}
+		dLon, n, err := readPolylineValue(s[i:])
+		if err != nil {
+			return nil, err
+		}
+		i += n
+
+		lat += dLat
+		lon += dLon
+		out = append(out, Position{float64(lon) / factor, float64(lat) / factor})
+	}
+	return out, nil
+}
+
+// EncodePolylines encodes each LineString as a Google encoded polyline.
+func (g MultiLineString) EncodePolylines(precision int) []string {
+	out := make([]string, len(g))
+	for i, ls := range g {
+		out[i] = ls.EncodePolyline(precision)
+	}
+	return out
+}
+
+// DecodePolylines decodes Google encoded polylines into a MultiLineString.
+func DecodePolylines(ss []string, precision int) (MultiLineString, error) {
+	out := make(MultiLineString, len(ss))
+	for i, s := range ss {
+		ls, err := DecodePolyline(s, precision)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = ls
+	}
+	return out, nil
+}
+
+func writePolylineValue(sb *strings.Builder, v int64) {
+	u := uint64(v) << 1
+	if v < 0 {
+		u = ^u
+	}
+	for u >= 0x20 {
+		sb.WriteByte(byte(0x20|u&0x1f) + 63)
+		u >>= 5
+	}
+	sb.WriteByte(byte(u) + 63)
+}
+
+// readPolylineValue decodes one value, returning it and the number of bytes consumed.
+func readPolylineValue(s string) (int64, int, error) {
+	var u uint64
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 63 || c > 126 {
+			return 0, 0, errors.New("polyline: invalid character")
+		}
+		if i >= 12 {
+			return 0, 0, errors.New("polyline: value overflow")
+		}
+		chunk := uint64(c - 63)
+		u |= (chunk & 0x1f) << (5 * i)
+		if chunk&0x20 == 0 {
+			v := int64(u >> 1)
+			if u&1 != 0 {
+				v = ^v
+			}
+			return v, i + 1, nil
+		}
+	}
+	return 0, 0, errPolylineTruncated
+}
@@ -0,0 +1,58 @@
+package joejson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolyline(t *testing.T) {
+	testCases := map[string]struct {
+		ls        LineString
+		precision int
+		polyline  string
+	}{
+		"precision 5": {
+			ls:        LineString{{-120.2, 38.5}, {-120.95, 40.7}, {-126.453, 43.252}},
+			precision: 5,
+			polyline:  "_p~iF~ps|U_ulLnnqC_mqNvxq`@",
+		},
+		"precision 6": {
+			ls:        LineString{{-120.2, 38.5}, {-120.95, 40.7}, {-126.453, 43.252}},
+			precision: 6,
+			polyline:  "_izlhA~rlgdF_{geC~ywl@_kwzCn`{nI",
+		},
+		"empty": {
+			ls:        LineString{},
+			precision: 5,
+			polyline:  "",
+		},
+	}
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.polyline, tt.ls.EncodePolyline(tt.precision))
+
+			got, err := DecodePolyline(tt.polyline, tt.precision)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.ls, got)
+		})
+	}
+
+	t.Run("MultiLineString", func(t *testing.T) {
+		mls := MultiLineString{{{-120.2, 38.5}, {-120.95, 40.7}}, {{1, 2}}}
+		got, err := DecodePolylines(mls.EncodePolylines(5), 5)
+		assert.NoError(t, err)
+		assert.Equal(t, mls, got)
+	})
+
+	for name, s := range map[string]string{
+		"truncated value":   "_p~i",
+		"missing longitude": "_p~iF",
+		"invalid character": "_p~iF ",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := DecodePolyline(s, 5)
+			assert.Error(t, err)
+		})
+	}
+}
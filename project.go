@@ -0,0 +1,300 @@
+package joejson
+
+import "math"
+
+// Project applies fn to the Point's Position.
+// fn must return a new Position rather than modify its argument.
+func (p Point) Project(fn func(Position) Position) Point {
+	return mapPositions(p, fn).(Point)
+}
+
+// Project applies fn to every Position.
+func (g MultiPoint) Project(fn func(Position) Position) MultiPoint {
+	return mapPositions(g, fn).(MultiPoint)
+}
+
+// Project applies fn to every Position.
+func (g LineString) Project(fn func(Position) Position) LineString {
+	return mapPositions(g, fn).(LineString)
+}
+
+// Project applies fn to every Position.
+func (g MultiLineString) Project(fn func(Position) Position) MultiLineString {
+	return mapPositions(g, fn).(MultiLineString)
+}
+
+// Project applies fn to every Position.
+func (p Polygon) Project(fn func(Position) Position) Polygon {
+	return mapPositions(p, fn).(Polygon)
+}
+
+// Project applies fn to every Position.
+func (p MultiPolygon) Project(fn func(Position) Position) MultiPolygon {
+	return mapPositions(p, fn).(MultiPolygon)
+}
+
+// Project applies fn to every Position of every member.
+func (g GeometryCollection) Project(fn func(Position) Position) GeometryCollection {
+	return mapPositions(g, fn).(GeometryCollection)
+}
+
+// Project applies fn to every Position of the Feature's Geometry.
+// A Bbox is recomputed from the projected Geometry, or has its corners projected if there is no Geometry.
+func (f Feature) Project(fn func(Position) Position) Feature {
+	f.geometry = mapPositions(f.geometry, fn)
+	f.Bbox = projectBbox(f.Bbox, f.geometry, fn)
+	return f
+}
+
+// Project applies fn to every Feature.
+// A Bbox is recomputed from the projected Features, or has its corners projected if there are none.
+func (f FeatureCollection) Project(fn func(Position) Position) FeatureCollection {
+	features := make([]Feature, len(f.Features))
+	geometries := make(GeometryCollection, 0, len(f.Features))
+	for i, ft := range f.Features {
+		features[i] = ft.Project(fn)
+		geometries = append(geometries, GeometryCollectionMember{features[i].geometry})
+	}
+	f.Features = features
+	f.Bbox = projectBbox(f.Bbox, geometries, fn)
+	return f
+}
+
+func projectBbox(bbox []Position, g any, fn func(Position) Position) []Position {
+	if bbox == nil {
+		return nil
+	}
+	if b, ok := geometryBounds(g); ok {
+		return b.bbox()
+	}
+	out := make([]Position, len(bbox))
+	for i, p := range bbox {
+		out[i] = fn(p)
+	}
+	return out
+}
+
+// mapPositions rebuilds g, which may be any geometry type, with every Position replaced by fn's result.
+func mapPositions(g any, fn func(Position) Position) any {
+	switch g := g.(type) {
+	case Point:
+		if g == nil {
+			return g
+		}
+		return Point(fn(Position(g)))
+	case MultiPoint:
+		return MultiPoint(mapPositionSlice(g, fn))
+	case LineString:
+		return LineString(mapPositionSlice(g, fn))
+	case MultiLineString:
+		if g == nil {
+			return g
+		}
+		out := make(MultiLineString, len(g))
+		for i, ls := range g {
+			out[i] = mapPositionSlice(ls, fn)
+		}
+		return out
+	case Polygon:
+		if g == nil {
+			return g
+		}
+		out := make(Polygon, len(g))
+		for i, lr := range g {
+			out[i] = mapPositionSlice(lr, fn)
+		}
+		return out
+	case MultiPolygon:
+		if g == nil {
+			return g
+		}
+		out := make(MultiPolygon, len(g))
+		for i, pl := range g {
+			out[i] = mapPositions(pl, fn).(Polygon)
+		}
+		return out
+	case GeometryCollection:
+		if g == nil {
+			return g
+		}
+		out := make(GeometryCollection, len(g))
+		for i, m := range g {
+			out[i] = GeometryCollectionMember{mapPositions(m.geometry, fn)}
+		}
+		return out
+	default:
+		return g
+	}
+}
+
+func mapPositionSlice(ps []Position, fn func(Position) Position) []Position {
+	if ps == nil {
+		return nil
+	}
+	out := make([]Position, len(ps))
+	for i, p := range ps {
+		out[i] = fn(p)
+	}
+	return out
+}
+
+const (
+	// wgs84SemiMajorAxis is the WGS84 equatorial radius in metres.
+	wgs84SemiMajorAxis = 6378137.0
+	// wgs84Flattening is the WGS84 ellipsoid flattening.
+	wgs84Flattening = 1 / 298.257223563
+	// webMercatorMaxLat is the latitude at which Web Mercator becomes square.
+	webMercatorMaxLat = 85.051128779806604
+)
+
+// WGS84ToWebMercator projects a WGS84 longitude/latitude Position to
+// EPSG:3857 metres. Latitudes are clamped to the Web Mercator range.
+// Any elevation is kept unchanged.
+func WGS84ToWebMercator(p Position) Position {
+	lat := math.Max(-webMercatorMaxLat, math.Min(webMercatorMaxLat, p.Lat()))
+	x := wgs84SemiMajorAxis * p.Lon() * math.Pi / 180
+	y := wgs84SemiMajorAxis * math.Log(math.Tan(math.Pi/4+lat*math.Pi/360))
+	return withXY(p, x, y)
+}
+
+// WebMercatorToWGS84 unprojects an EPSG:3857 Position to WGS84 longitude/latitude.
+// Any elevation is kept unchanged.
+func WebMercatorToWGS84(p Position) Position {
+	lon := p.Lon() / wgs84SemiMajorAxis * 180 / math.Pi
+	lat := (2*math.Atan(math.Exp(p.Lat()/wgs84SemiMajorAxis)) - math.Pi/2) * 180 / math.Pi
+	return withXY(p, lon, lat)
+}
+
+// UTMZone returns the UTM zone and hemisphere containing a WGS84 Position,
+// including the Norway and Svalbard exceptions.
+func UTMZone(p Position) (zone int, north bool) {
+	lon, lat := p.Lon(), p.Lat()
+	zone = int(math.Floor((lon+180)/6)) + 1
+	if zone > 60 {
+		zone = 60
+	}
+	if zone < 1 {
+		zone = 1
+	}
+
+	switch {
+	case lat >= 56 && lat < 64 && lon >= 3 && lon < 12:
+		zone = 32
+	case lat >= 72 && lat < 84 && lon >= 0 && lon < 9:
+		zone = 31
+	case lat >= 72 && lat < 84 && lon >= 9 && lon < 21:
+		zone = 33
+	case lat >= 72 && lat < 84 && lon >= 21 && lon < 33:
+		zone = 35
+	case lat >= 72 && lat < 84 && lon >= 33 && lon < 42:
+		zone = 37
+	}
+	return zone, lat >= 0
+}
+
+// utm holds the Krüger series coefficients for transverse Mercator on WGS84.
+var utm = func() (c struct {
+	alpha, beta, delta  [3]float64
+	rectifyingRadius, n float64
+}) {
+	n := wgs84Flattening / (2 - wgs84Flattening)
+	n2, n3 := n*n, n*n*n
+	c.n = n
+	c.rectifyingRadius = wgs84SemiMajorAxis / (1 + n) * (1 + n2/4 + n2*n2/64)
+	c.alpha = [3]float64{n/2 - 2*n2/3 + 5*n3/16, 13*n2/48 - 3*n3/5, 61 * n3 / 240}
+	c.beta = [3]float64{n/2 - 2*n2/3 + 37*n3/96, n2/48 + n3/15, 17 * n3 / 480}
+	c.delta = [3]float64{2*n - 2*n2/3 - 2*n3, 7*n2/3 - 8*n3/5, 56 * n3 / 15}
+	return c
+}()
+
+const (
+	utmScale         = 0.9996
+	utmFalseEasting  = 500000.0
+	utmFalseNorthing = 10000000.0
+)
+
+// WGS84ToUTM returns a projection from WGS84 longitude/latitude to
+// easting/northing metres in the given UTM zone and hemisphere.
+// Any elevation is kept unchanged.
+func WGS84ToUTM(zone int, north bool) func(Position) Position {
+	lon0 := utmCentralMeridian(zone)
+	falseNorthing := 0.0
+	if !north {
+		falseNorthing = utmFalseNorthing
+	}
+	e := 2 * math.Sqrt(utm.n) / (1 + utm.n)
+
+	return func(p Position) Position {
+		phi := p.Lat() * math.Pi / 180
+		dLambda := p.Lon()*math.Pi/180 - lon0
+
+		t := math.Sinh(math.Atanh(math.Sin(phi)) - e*math.Atanh(e*math.Sin(phi)))
+		xi := math.Atan2(t, math.Cos(dLambda))
+		eta := math.Atanh(math.Sin(dLambda) / math.Sqrt(1+t*t))
+
+		x, y := eta, xi
+		for j, a := range utm.alpha {
+			k := 2 * float64(j+1)
+			x += a * math.Cos(k*xi) * math.Sinh(k*eta)
+			y += a * math.Sin(k*xi) * math.Cosh(k*eta)
+		}
+
+		return withXY(p,
+			utmFalseEasting+utmScale*utm.rectifyingRadius*x,
+			falseNorthing+utmScale*utm.rectifyingRadius*y,
+		)
+	}
+}
+
+// UTMToWGS84 returns a projection from easting/northing metres in the
+// given UTM zone and hemisphere to WGS84 longitude/latitude.
+// Any elevation is kept unchanged.
+func UTMToWGS84(zone int, north bool) func(Position) Position {
+	lon0 := utmCentralMeridian(zone)
+	falseNorthing := 0.0
+	if !north {
+		falseNorthing = utmFalseNorthing
+	}
+
+	return func(p Position) Position {
+		xi := (p.Lat() - falseNorthing) / (utmScale * utm.rectifyingRadius)
+		eta := (p.Lon() - utmFalseEasting) / (utmScale * utm.rectifyingRadius)
+
+		xiP, etaP := xi, eta
+		for j, b := range utm.beta {
+			k := 2 * float64(j+1)
+			xiP -= b * math.Sin(k*xi) * math.Cosh(k*eta)
+			etaP -= b * math.Cos(k*xi) * math.Sinh(k*eta)
+		}
+
+		chi := math.Asin(math.Sin(xiP) / math.Cosh(etaP))
+		phi := chi
+		for j, d := range utm.delta {
+			phi += d * math.Sin(2*float64(j+1)*chi)
+		}
+		lambda := lon0 + math.Atan2(math.Sinh(etaP), math.Cos(xiP))
+
+		return withXY(p, lambda*180/math.Pi, phi*180/math.Pi)
+	}
+}
+
+func utmCentralMeridian(zone int) float64 {
+	return (float64(zone-1)*6 - 180 + 3) * math.Pi / 180
+}
+
+// withXY copies p with its first two coordinates replaced.
+func withXY(p Position, x, y float64) Position {
+	out := make(Position, 2, maxInt(2, len(p)))
+	out[0], out[1] = x, y
+	if len(p) > 2 {
+		out = append(out, p[2:]...)
+	}
+	return out
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
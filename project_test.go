@@ -0,0 +1,88 @@
+package joejson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjections(t *testing.T) {
+	london := Position{-0.1278, 51.5074, 11}
+	zone, north := UTMZone(london)
+
+	testCases := map[string]struct {
+		forward, inverse func(Position) Position
+		in, want         Position
+		delta            float64
+	}{
+		"Web Mercator": {
+			forward: WGS84ToWebMercator,
+			inverse: WebMercatorToWGS84,
+			in:      Position{180, 0},
+			want:    Position{20037508.342789244, 0},
+			delta:   1e-6,
+		},
+		"Web Mercator with elevation": {
+			forward: WGS84ToWebMercator,
+			inverse: WebMercatorToWGS84,
+			in:      london,
+			want:    Position{-14226.630, 6711542.475, 11},
+			delta:   1e-3,
+		},
+		"UTM north": {
+			forward: WGS84ToUTM(zone, north),
+			inverse: UTMToWGS84(zone, north),
+			in:      london,
+			want:    Position{699316.0, 5710164.0, 11},
+			delta:   1,
+		},
+		"UTM south": {
+			forward: WGS84ToUTM(56, false),
+			inverse: UTMToWGS84(56, false),
+			in:      Position{151.2093, -33.8688},
+			want:    Position{334368.6, 6250948.3},
+			delta:   1,
+		},
+		"UTM central meridian": {
+			forward: WGS84ToUTM(31, true),
+			inverse: UTMToWGS84(31, true),
+			in:      Position{3, 0},
+			want:    Position{500000, 0},
+			delta:   1e-6,
+		},
+	}
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got := tt.forward(tt.in)
+			assert.InDeltaSlice(t, tt.want, got, tt.delta)
+			assert.InDeltaSlice(t, tt.in, tt.inverse(got), 1e-8)
+		})
+	}
+
+	assert.Equal(t, 30, zone)
+	assert.True(t, north)
+}
+
+func TestProject(t *testing.T) {
+	ring := LinearRing{{0, 0}, {1, 0}, {1, 1}, {0, 0}}
+	ft := Feature{Bbox: []Position{{0, 0}, {1, 1}}}.WithGeometryCollection(
+		GeometryCollection{}.AppendPoint(Point{1, 1}).AppendPolygon(Polygon{ring}),
+	)
+	shift := func(p Position) Position { return Position{p.Lon() + 10, p.Lat() * 2} }
+
+	got := ft.Project(shift)
+	gc, ok := got.AsGeometryCollection()
+	assert.True(t, ok)
+	pt, _ := gc[0].AsPoint()
+	pl, _ := gc[1].AsPolygon()
+	assert.Equal(t, Point{11, 2}, pt)
+	assert.Equal(t, Polygon{{{10, 0}, {11, 0}, {11, 2}, {10, 0}}}, pl)
+	assert.Equal(t, []Position{{10, 0}, {11, 2}}, got.Bbox)
+
+	// the original is untouched.
+	assert.Equal(t, LinearRing{{0, 0}, {1, 0}, {1, 1}, {0, 0}}, ring)
+	assert.Equal(t, []Position{{0, 0}, {1, 1}}, ft.Bbox)
+
+	fc := FeatureCollection{Features: []Feature{ft}, Bbox: []Position{{0, 0}, {1, 1}}}.Project(shift)
+	assert.Equal(t, []Position{{10, 0}, {11, 2}}, fc.Bbox)
+}
@@ -0,0 +1,386 @@
+package joejson
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+const (
+	rtreeMaxEntries = 16
+	rtreeMinEntries = rtreeMaxEntries * 2 / 5
+)
+
+// RTree is an in-memory spatial index over Features.
+//
+// The index holds references to Features rather than copies, so a tree built
+// from a FeatureCollection returns pointers into its Features slice.
+// Features are indexed by their Bbox when set, otherwise by the bounds of their Geometry.
+type RTree struct {
+	root    *rtreeNode
+	indexed map[*Feature]bounds
+}
+
+type rtreeNode struct {
+	// height is zero for leaves.
+	height  int
+	entries []rtreeEntry
+}
+
+type rtreeEntry struct {
+	bounds  bounds
+	child   *rtreeNode
+	feature *Feature
+}
+
+// NewRTree bulk loads an RTree using Sort-Tile-Recursive packing.
+// Features without a Geometry or Bbox are not indexed.
+func NewRTree(features []Feature) *RTree {
+	t := &RTree{
+		root:    &rtreeNode{},
+		indexed: make(map[*Feature]bounds, len(features)),
+	}
+
+	entries := make([]rtreeEntry, 0, len(features))
+	for i := range features {
+		f := &features[i]
+		b, ok := featureBounds(*f)
+		if !ok {
+			continue
+		}
+		t.indexed[f] = b
+		entries = append(entries, rtreeEntry{bounds: b, feature: f})
+	}
+	if len(entries) == 0 {
+		return t
+	}
+
+	height := 0
+	for {
+		nodes := strPack(entries, height)
+		if len(nodes) == 1 {
+			t.root = nodes[0]
+			return t
+		}
+		entries = make([]rtreeEntry, len(nodes))
+		for i, n := range nodes {
+			entries[i] = rtreeEntry{bounds: n.bounds(), child: n}
+		}
+		height++
+	}
+}
+
+// strPack groups entries into nodes of the given height, tiling first by x then by y.
+func strPack(entries []rtreeEntry, height int) []*rtreeNode {
+	nodeCount := int(math.Ceil(float64(len(entries)) / rtreeMaxEntries))
+	sliceCount := int(math.Ceil(math.Sqrt(float64(nodeCount))))
+	sliceSize := sliceCount * rtreeMaxEntries
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].bounds.minX+entries[i].bounds.maxX < entries[j].bounds.minX+entries[j].bounds.maxX
+	})
+
+	nodes := make([]*rtreeNode, 0, nodeCount)
+	for start := 0; start < len(entries); start += sliceSize {
+		slice := entries[start:minInt(start+sliceSize, len(entries))]
+		sort.Slice(slice, func(i, j int) bool {
+			return slice[i].bounds.minY+slice[i].bounds.maxY < slice[j].bounds.minY+slice[j].bounds.maxY
+		})
+		for i := 0; i < len(slice); i += rtreeMaxEntries {
+			chunk := slice[i:minInt(i+rtreeMaxEntries, len(slice))]
+			nodes = append(nodes, &rtreeNode{
+				height:  height,
+				entries: append([]rtreeEntry(nil), chunk...),
+			})
+		}
+	}
+	return nodes
+}
+
+// Len is the number of indexed Features.
+func (t *RTree) Len() int {
+	return len(t.indexed)
+}
+
+// Insert adds a Feature to the index. It returns false if the Feature has no
+// Geometry or Bbox, or is already indexed.
+func (t *RTree) Insert(f *Feature) bool {
+	if _, ok := t.indexed[f]; ok {
+		return false
+	}
+	b, ok := featureBounds(*f)
+	if !ok {
+		return false
+	}
+	if t.indexed == nil {
+		t.indexed = make(map[*Feature]bounds)
+	}
+	t.indexed[f] = b
+	t.insert(rtreeEntry{bounds: b, feature: f})
+	return true
+}
+
+func (t *RTree) insert(e rtreeEntry) {
+	if t.root == nil {
+		t.root = &rtreeNode{}
+	}
+	if split := t.root.insert(e); split != nil {
+		old := t.root
+		t.root = &rtreeNode{
+			height: old.height + 1,
+			entries: []rtreeEntry{
+				{bounds: old.bounds(), child: old},
+				{bounds: split.bounds(), child: split},
+			},
+		}
+	}
+}
+
+// insert adds a leaf entry below n, returning n's new sibling if n was split.
+func (n *rtreeNode) insert(e rtreeEntry) *rtreeNode {
+	if n.height == 0 {
+		n.entries = append(n.entries, e)
+	} else {
+		i := n.chooseSubtree(e.bounds)
+		child := n.entries[i].child
+		split := child.insert(e)
+		n.entries[i].bounds = child.bounds()
+		if split != nil {
+			n.entries = append(n.entries, rtreeEntry{bounds: split.bounds(), child: split})
+		}
+	}
+
+	if len(n.entries) > rtreeMaxEntries {
+		return n.split()
+	}
+	return nil
+}
+
+// chooseSubtree picks the entry needing the least enlargement to include b.
+func (n *rtreeNode) chooseSubtree(b bounds) int {
+	best, bestGrowth, bestArea := 0, math.Inf(1), math.Inf(1)
+	for i, e := range n.entries {
+		area := e.bounds.area()
+		growth := e.bounds.extend(b).area() - area
+		if growth < bestGrowth || (growth == bestGrowth && area < bestArea) {
+			best, bestGrowth, bestArea = i, growth, area
+		}
+	}
+	return best
+}
+
+// split divides n's entries in two using Guttman's quadratic split, keeping
+// one group in n and returning the other as a new node.
+func (n *rtreeNode) split() *rtreeNode {
+	entries := n.entries
+
+	// pick the pair of seeds that would waste the most area together.
+	seedA, seedB, worst := 0, 1, math.Inf(-1)
+	for i := 0; i < len(entries); i++ {
+		for j := i + 1; j < len(entries); j++ {
+			waste := entries[i].bounds.extend(entries[j].bounds).area() - entries[i].bounds.area() - entries[j].bounds.area()
+			if waste > worst {
+				seedA, seedB, worst = i, j, waste
+			}
+		}
+	}
+
+	groupA := []rtreeEntry{entries[seedA]}
+	groupB := []rtreeEntry{entries[seedB]}
+	boundsA, boundsB := entries[seedA].bounds, entries[seedB].bounds
+
+	remaining := make([]rtreeEntry, 0, len(entries)-2)
+	for i, e := range entries {
+		if i != seedA && i != seedB {
+			remaining = append(remaining, e)
+		}
+	}
+
+	for len(remaining) > 0 {
+		if len(groupA)+len(remaining) == rtreeMinEntries {
+			groupA = append(groupA, remaining...)
+			break
+		}
+		if len(groupB)+len(remaining) == rtreeMinEntries {
+			groupB = append(groupB, remaining...)
+			break
+		}
+
+		// pick the entry with the strongest preference for one group.
+		next, nextGrowthA, nextGrowthB, preference := 0, 0.0, 0.0, math.Inf(-1)
+		for i, e := range remaining {
+			growthA := boundsA.extend(e.bounds).area() - boundsA.area()
+			growthB := boundsB.extend(e.bounds).area() - boundsB.area()
+			if d := math.Abs(growthA - growthB); d > preference {
+				next, nextGrowthA, nextGrowthB, preference = i, growthA, growthB, d
+			}
+		}
+		e := remaining[next]
+		remaining = append(remaining[:next], remaining[next+1:]...)
+
+		toA := nextGrowthA < nextGrowthB ||
+			(nextGrowthA == nextGrowthB && boundsA.area() < boundsB.area()) ||
+			(nextGrowthA == nextGrowthB && boundsA.area() == boundsB.area() && len(groupA) <= len(groupB))
+		if toA {
+			groupA = append(groupA, e)
+			boundsA = boundsA.extend(e.bounds)
+		} else {
+			groupB = append(groupB, e)
+			boundsB = boundsB.extend(e.bounds)
+		}
+	}
+
+	n.entries = groupA
+	return &rtreeNode{height: n.height, entries: groupB}
+}
+
+func (n *rtreeNode) bounds() bounds {
+	b := emptyBounds()
+	for _, e := range n.entries {
+		b = b.extend(e.bounds)
+	}
+	return b
+}
+
+// Delete removes a Feature from the index, returning false if it was not indexed.
+func (t *RTree) Delete(f *Feature) bool {
+	b, ok := t.indexed[f]
+	if !ok {
+		return false
+	}
+	delete(t.indexed, f)
+
+	var orphans []rtreeEntry
+	t.root.remove(f, b, &orphans)
+
+	for t.root.height > 0 && len(t.root.entries) == 1 {
+		t.root = t.root.entries[0].child
+	}
+	if t.root.height > 0 && len(t.root.entries) == 0 {
+		t.root = &rtreeNode{}
+	}
+
+	for _, e := range orphans {
+		t.insert(e)
+	}
+	return true
+}
+
+// remove deletes f from below n, collecting the leaf entries of any
+// under-full nodes into orphans for reinsertion.
+func (n *rtreeNode) remove(f *Feature, b bounds, orphans *[]rtreeEntry) bool {
+	if n.height == 0 {
+		for i, e := range n.entries {
+			if e.feature == f {
+				n.entries = append(n.entries[:i], n.entries[i+1:]...)
+				return true
+			}
+		}
+		return false
+	}
+
+	for i, e := range n.entries {
+		if !e.bounds.contains(b) || !e.child.remove(f, b, orphans) {
+			continue
+		}
+		if len(e.child.entries) < rtreeMinEntries {
+			e.child.leaves(orphans)
+			n.entries = append(n.entries[:i], n.entries[i+1:]...)
+		} else {
+			n.entries[i].bounds = e.child.bounds()
+		}
+		return true
+	}
+	return false
+}
+
+// leaves appends every leaf entry below n to out.
+func (n *rtreeNode) leaves(out *[]rtreeEntry) {
+	for _, e := range n.entries {
+		if n.height == 0 {
+			*out = append(*out, e)
+		} else {
+			e.child.leaves(out)
+		}
+	}
+}
+
+// Search returns the Features whose bounds intersect bbox, a pair of
+// south-west and north-east Positions as used by Feature.Bbox.
+func (t *RTree) Search(bbox []Position) []*Feature {
+	b, ok := bboxBounds(bbox)
+	if !ok || t.root == nil {
+		return nil
+	}
+
+	var out []*Feature
+	stack := []*rtreeNode{t.root}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, e := range n.entries {
+			if !b.intersects(e.bounds) {
+				continue
+			}
+			if n.height == 0 {
+				out = append(out, e.feature)
+			} else {
+				stack = append(stack, e.child)
+			}
+		}
+	}
+	return out
+}
+
+// Nearest returns up to k Features closest to p, nearest first.
+// Distance is planar and measured to each Feature's bounds.
+func (t *RTree) Nearest(p Point, k int) []*Feature {
+	if k <= 0 || t.root == nil || len(p) < 2 {
+		return nil
+	}
+
+	pos := Position(p)
+	q := &rtreeQueue{}
+	for _, e := range t.root.entries {
+		heap.Push(q, rtreeQueueItem{entry: e, dist: e.bounds.distance(pos)})
+	}
+
+	out := make([]*Feature, 0, k)
+	for q.Len() > 0 && len(out) < k {
+		item := heap.Pop(q).(rtreeQueueItem)
+		if item.entry.child == nil {
+			out = append(out, item.entry.feature)
+			continue
+		}
+		for _, e := range item.entry.child.entries {
+			heap.Push(q, rtreeQueueItem{entry: e, dist: e.bounds.distance(pos)})
+		}
+	}
+	return out
+}
+
+type rtreeQueueItem struct {
+	entry rtreeEntry
+	dist  float64
+}
+
+// rtreeQueue is a min-heap of entries ordered by distance.
+type rtreeQueue []rtreeQueueItem
+
+func (q rtreeQueue) Len() int           { return len(q) }
+func (q rtreeQueue) Less(i, j int) bool { return q[i].dist < q[j].dist }
+func (q rtreeQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *rtreeQueue) Push(x any)        { *q = append(*q, x.(rtreeQueueItem)) }
+func (q *rtreeQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,113 @@
+package joejson
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRTree(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	features := make([]Feature, 1000)
+	for i := range features {
+		features[i] = Feature{ID: i}.WithPoint(Point{rnd.Float64()*360 - 180, rnd.Float64()*180 - 90})
+	}
+
+	bruteSearch := func(fs []*Feature, bbox []Position) []int {
+		b, _ := bboxBounds(bbox)
+		var out []int
+		for _, f := range fs {
+			if fb, ok := featureBounds(*f); ok && b.intersects(fb) {
+				out = append(out, f.ID.(int))
+			}
+		}
+		sort.Ints(out)
+		return out
+	}
+	ids := func(fs []*Feature) []int {
+		out := make([]int, 0, len(fs))
+		for _, f := range fs {
+			out = append(out, f.ID.(int))
+		}
+		sort.Ints(out)
+		return out
+	}
+	bboxes := [][]Position{
+		{{-10, -10}, {10, 10}},
+		{{-180, -90}, {180, 90}},
+		{{100, 40}, {101, 41}},
+		{{-50, 0}, {0, 80}},
+	}
+
+	t.Run("Search", func(t *testing.T) {
+		tree := NewRTree(features)
+		assert.Equal(t, len(features), tree.Len())
+
+		all := make([]*Feature, len(features))
+		for i := range features {
+			all[i] = &features[i]
+		}
+		for _, bbox := range bboxes {
+			want := bruteSearch(all, bbox)
+			if len(want) == 0 {
+				want = []int{}
+			}
+			assert.Equal(t, want, ids(tree.Search(bbox)))
+		}
+	})
+
+	t.Run("Nearest", func(t *testing.T) {
+		tree := NewRTree(features)
+		p := Point{12.5, -3}
+		got := tree.Nearest(p, 5)
+		assert.Len(t, got, 5)
+
+		dists := make([]float64, len(features))
+		for i, f := range features {
+			b, _ := featureBounds(f)
+			dists[i] = b.distance(Position(p))
+		}
+		sort.Float64s(dists)
+		for i, f := range got {
+			b, _ := featureBounds(*f)
+			assert.Equal(t, dists[i], b.distance(Position(p)))
+		}
+	})
+
+	t.Run("Insert and Delete", func(t *testing.T) {
+		tree := &RTree{}
+		live := map[*Feature]bool{}
+		for i := range features {
+			assert.True(t, tree.Insert(&features[i]))
+			live[&features[i]] = true
+		}
+		assert.False(t, tree.Insert(&features[0]))
+
+		for i := 0; i < len(features); i += 3 {
+			assert.True(t, tree.Delete(&features[i]))
+			delete(live, &features[i])
+		}
+		assert.False(t, tree.Delete(&features[0]))
+		assert.Equal(t, len(live), tree.Len())
+
+		var remaining []*Feature
+		for f := range live {
+			remaining = append(remaining, f)
+		}
+		for _, bbox := range bboxes {
+			want := bruteSearch(remaining, bbox)
+			if len(want) == 0 {
+				want = []int{}
+			}
+			assert.Equal(t, want, ids(tree.Search(bbox)))
+		}
+
+		for f := range live {
+			assert.True(t, tree.Delete(f))
+		}
+		assert.Equal(t, 0, tree.Len())
+		assert.Empty(t, tree.Search([]Position{{-180, -90}, {180, 90}}))
+	})
+}
@@ -0,0 +1,774 @@
+package joejson
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	shpFileCode = 9994
+	shpVersion  = 1000
+
+	shpNull        = 0
+	shpPoint       = 1
+	shpPolyLine    = 3
+	shpPolygon     = 5
+	shpMultiPoint  = 8
+	shpPointZ      = 11
+	shpPolyLineZ   = 13
+	shpPolygonZ    = 15
+	shpMultiPointZ = 18
+	shpPointM      = 21
+	shpPolyLineM   = 23
+	shpPolygonM    = 25
+	shpMultiPointM = 28
+
+	// shpZ is added to a base shape type for its Z variant.
+	shpZ = 10
+
+	dbfHeaderTerminator = 0x0d
+	dbfEOF              = 0x1a
+	dbfMaxFieldLength   = 254
+	dbfMaxDecimals      = 15
+)
+
+// dbfLastUpdate is the last update date, as years since 1900, month and day,
+// written to every DBF header so that output is reproducible.
+var dbfLastUpdate = [3]byte{70, 1, 1}
+
+// wgs84PRJ is the .prj content written by MarshalShapefile.
+const wgs84PRJ = `GEOGCS["GCS_WGS_1984",DATUM["D_WGS_1984",SPHEROID["WGS_1984",6378137.0,298.257223563]],PRIMEM["Greenwich",0.0],UNIT["Degree",0.0174532925199433]]`
+
+var errSHPShort = errors.New("shp: unexpected end of input")
+
+// Shapefile holds the contents of a shapefile's files.
+type Shapefile struct {
+	// SHP is the main file holding geometries.
+	SHP []byte
+	// SHX is the index of SHP records. It is not needed for reading.
+	SHX []byte
+	// DBF is the dBase attribute table, optional when reading.
+	DBF []byte
+	// PRJ is the coordinate system, as Esri WKT.
+	PRJ []byte
+	// CPG names the code page of DBF text. Text is always read and written
+	// as UTF-8, so MarshalShapefile sets it to "UTF-8" and
+	// UnmarshalShapefile ignores it.
+	CPG []byte
+}
+
+// UnmarshalShapefile decodes a shapefile into a FeatureCollection, one
+// Feature per record, with DBF attributes as Properties.
+//
+// Point, MultiPoint, PolyLine and Polygon shapes are supported, with their
+// Z and M variants; Z values become elevations and M values are dropped.
+// PolyLines with one part become LineStrings. Polygon rings are grouped as
+// in UnmarshalEsriJSON, giving a Polygon or MultiPolygon with RFC 7946
+// winding. Null shapes give Features without geometry, and records deleted
+// from the DBF are skipped.
+//
+// DBF numeric fields decode as float64, logical fields as bool, dates as
+// "YYYY-MM-DD" strings and anything else as a trimmed string; blank values
+// are nil.
+func UnmarshalShapefile(s Shapefile) (FeatureCollection, error) {
+	shapes, err := readSHP(s.SHP)
+	if err != nil {
+		return FeatureCollection{}, err
+	}
+
+	var records []map[string]any
+	if s.DBF != nil {
+		if records, err = readDBF(s.DBF); err != nil {
+			return FeatureCollection{}, err
+		}
+		if len(records) != len(shapes) {
+			return FeatureCollection{}, fmt.Errorf("shp: %d shapes but %d dbf records", len(shapes), len(records))
+		}
+	}
+
+	out := FeatureCollection{Features: make([]Feature, 0, len(shapes))}
+	for i, g := range shapes {
+		ft := Feature{geometry: g}
+		if records != nil {
+			if records[i] == nil {
+				continue
+			}
+			ft.Properties = records[i]
+		}
+		out.Features = append(out.Features, ft)
+	}
+	return out, nil
+}
+
+// UnmarshalShapefileZip decodes the first shapefile in a zip archive, using
+// the .dbf file of the same name if there is one.
+func UnmarshalShapefileZip(b []byte) (FeatureCollection, error) {
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return FeatureCollection{}, err
+	}
+
+	files := map[string]*zip.File{}
+	var shpName string
+	for _, f := range zr.File {
+		name := strings.ToLower(f.Name)
+		files[name] = f
+		if shpName == "" && path.Ext(name) == ".shp" {
+			shpName = name
+		}
+	}
+	if shpName == "" {
+		return FeatureCollection{}, errors.New("shp: no .shp file in archive")
+	}
+
+	read := func(name string) ([]byte, error) {
+		f, ok := files[name]
+		if !ok {
+			return nil, nil
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+
+	var s Shapefile
+	base := strings.TrimSuffix(shpName, ".shp")
+	if s.SHP, err = read(shpName); err != nil {
+		return FeatureCollection{}, err
+	}
+	if s.DBF, err = read(base + ".dbf"); err != nil {
+		return FeatureCollection{}, err
+	}
+	if s.CPG, err = read(base + ".cpg"); err != nil {
+		return FeatureCollection{}, err
+	}
+	return UnmarshalShapefile(s)
+}
+
+// MarshalShapefile encodes the FeatureCollection as a shapefile. Every
+// geometry must map to the same shape type: Points to Point,
+// MultiPoints to MultiPoint, LineStrings and MultiLineStrings to PolyLine
+// and Polygons and MultiPolygons to Polygon, using the Z variant if any
+// Position has an elevation. Features without geometry are Null shapes.
+//
+// Properties become DBF fields named by their keys truncated to 10 bytes.
+// Fields holding only bools are logical, those holding only numbers are
+// numeric and everything else is character data, truncated to 254 bytes,
+// with values formatted as by MarshalCSV. Truncation never splits a UTF-8
+// character. The DBF's last update date is fixed at 1970-01-01 so the output
+// only depends on f. PRJ is set to WGS84 and CPG to UTF-8.
+// More than 2046 fields or records longer than 65535 bytes are an error.
+func (f FeatureCollection) MarshalShapefile() (Shapefile, error) {
+	typ, err := shapefileType(f.Features)
+	if err != nil {
+		return Shapefile{}, err
+	}
+
+	var records bytes.Buffer
+	var index bytes.Buffer
+	b := emptyBounds()
+	zMin, zMax := math.Inf(1), math.Inf(-1)
+	for i, ft := range f.Features {
+		content := encodeShape(ft.geometry, typ)
+		var header [8]byte
+		binary.BigEndian.PutUint32(header[0:], uint32(100+records.Len())/2)
+		binary.BigEndian.PutUint32(header[4:], uint32(len(content)/2))
+		index.Write(header[:])
+		binary.BigEndian.PutUint32(header[0:], uint32(i+1))
+		records.Write(header[:])
+		records.Write(content)
+
+		eachPosition(ft.geometry, func(p Position) {
+			b = b.extendPosition(p)
+			if len(p) > 2 {
+				zMin, zMax = math.Min(zMin, p[2]), math.Max(zMax, p[2])
+			}
+		})
+	}
+	if b.isEmpty() {
+		b = bounds{}
+	}
+	if zMin > zMax {
+		zMin, zMax = 0, 0
+	}
+
+	dbf, err := writeDBF(f.Features)
+	if err != nil {
+		return Shapefile{}, err
+	}
+
+	return Shapefile{
+		SHP: append(shpHeader(100+records.Len(), typ, b, zMin, zMax), records.Bytes()...),
+		SHX: append(shpHeader(100+index.Len(), typ, b, zMin, zMax), index.Bytes()...),
+		DBF: dbf,
+		PRJ: []byte(wgs84PRJ),
+		CPG: []byte("UTF-8"),
+	}, nil
+}
+
+func shpHeader(length, typ int, b bounds, zMin, zMax float64) []byte {
+	out := make([]byte, 100)
+	binary.BigEndian.PutUint32(out[0:], shpFileCode)
+	binary.BigEndian.PutUint32(out[24:], uint32(length/2))
+	binary.LittleEndian.PutUint32(out[28:], shpVersion)
+	binary.LittleEndian.PutUint32(out[32:], uint32(typ))
+	for i, v := range []float64{b.minX, b.minY, b.maxX, b.maxY, zMin, zMax} {
+		binary.LittleEndian.PutUint64(out[36+8*i:], math.Float64bits(v))
+	}
+	return out
+}
+
+// shapefileType finds the single shape type for features' geometries.
+func shapefileType(features []Feature) (int, error) {
+	typ, hasZ := shpNull, false
+	for i, ft := range features {
+		var t int
+		switch ft.geometry.(type) {
+		case nil:
+			continue
+		case Point:
+			t = shpPoint
+		case MultiPoint:
+			t = shpMultiPoint
+		case LineString, MultiLineString:
+			t = shpPolyLine
+		case Polygon, MultiPolygon:
+			t = shpPolygon
+		default:
+			return 0, fmt.Errorf("shp: feature %d: unsupported geometry type %q", i, ft.GeometryType())
+		}
+		if typ != shpNull && t != typ {
+			return 0, fmt.Errorf("shp: feature %d: geometry type %q does not match earlier features", i, ft.GeometryType())
+		}
+		typ = t
+		eachPosition(ft.geometry, func(p Position) {
+			hasZ = hasZ || len(p) > 2
+		})
+	}
+	if hasZ {
+		typ += shpZ
+	}
+	return typ, nil
+}
+
+// encodeShape encodes a record's content. M values of Z shapes are omitted
+// where optional and zero otherwise.
+func encodeShape(g any, typ int) []byte {
+	var buf bytes.Buffer
+	var scratch [8]byte
+	putInt32 := func(v int32) {
+		binary.LittleEndian.PutUint32(scratch[:4], uint32(v))
+		buf.Write(scratch[:4])
+	}
+	putFloat64s := func(vs ...float64) {
+		for _, v := range vs {
+			binary.LittleEndian.PutUint64(scratch[:], math.Float64bits(v))
+			buf.Write(scratch[:])
+		}
+	}
+	if g == nil {
+		putInt32(shpNull)
+		return buf.Bytes()
+	}
+	putInt32(int32(typ))
+
+	hasZ := typ > shpZ
+	var parts [][]Position
+	switch g := g.(type) {
+	case Point:
+		p := Position(g)
+		putFloat64s(p.Lon(), p.Lat())
+		if hasZ {
+			putFloat64s(p.Elevation(), 0)
+		}
+		return buf.Bytes()
+	case MultiPoint:
+		parts = [][]Position{g}
+	case LineString:
+		parts = [][]Position{g}
+	case MultiLineString:
+		for _, ls := range g {
+			parts = append(parts, ls)
+		}
+	case Polygon:
+		parts = windClockwise(g)
+	case MultiPolygon:
+		for _, pl := range g {
+			parts = append(parts, windClockwise(pl)...)
+		}
+	}
+
+	var all []Position
+	for _, part := range parts {
+		all = append(all, part...)
+	}
+	b, ok := geometryBounds(g)
+	if !ok {
+		b = bounds{}
+	}
+	putFloat64s(b.minX, b.minY, b.maxX, b.maxY)
+	if typ%shpZ != shpMultiPoint {
+		putInt32(int32(len(parts)))
+	}
+	putInt32(int32(len(all)))
+	if typ%shpZ != shpMultiPoint {
+		start := int32(0)
+		for _, part := range parts {
+			putInt32(start)
+			start += int32(len(part))
+		}
+	}
+	for _, p := range all {
+		putFloat64s(p.Lon(), p.Lat())
+	}
+	if hasZ {
+		zs := make([]float64, len(all))
+		zMin, zMax := 0.0, 0.0
+		for i, p := range all {
+			zs[i] = p.Elevation()
+			if i == 0 || zs[i] < zMin {
+				zMin = zs[i]
+			}
+			if i == 0 || zs[i] > zMax {
+				zMax = zs[i]
+			}
+		}
+		putFloat64s(zMin, zMax)
+		putFloat64s(zs...)
+	}
+	return buf.Bytes()
+}
+
+func readSHP(b []byte) ([]any, error) {
+	if len(b) < 100 {
+		return nil, errSHPShort
+	}
+	if code := binary.BigEndian.Uint32(b); code != shpFileCode {
+		return nil, fmt.Errorf("shp: invalid file code %d", code)
+	}
+	length := int(binary.BigEndian.Uint32(b[24:])) * 2
+	if length > len(b) {
+		return nil, errSHPShort
+	}
+
+	var out []any
+	for off := 100; off < length; {
+		if length-off < 8 {
+			return nil, errSHPShort
+		}
+		n := int(binary.BigEndian.Uint32(b[off+4:])) * 2
+		off += 8
+		if n < 4 || n > length-off {
+			return nil, errSHPShort
+		}
+		g, err := readShape(b[off : off+n])
+		if err != nil {
+			return nil, fmt.Errorf("shp: record %d: %w", len(out)+1, err)
+		}
+		out = append(out, g)
+		off += n
+	}
+	return out, nil
+}
+
+// shpReader reads little-endian shape record content.
+type shpReader struct {
+	b   []byte
+	err error
+}
+
+func (r *shpReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n > len(r.b) {
+		r.err = errSHPShort
+		return nil
+	}
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *shpReader) int32() int {
+	if v := r.next(4); v != nil {
+		return int(int32(binary.LittleEndian.Uint32(v)))
+	}
+	return 0
+}
+
+func (r *shpReader) float64() float64 {
+	if v := r.next(8); v != nil {
+		return math.Float64frombits(binary.LittleEndian.Uint64(v))
+	}
+	return 0
+}
+
+// count reads a count of items of size bytes each, checking they fit.
+func (r *shpReader) count(size int) int {
+	n := r.int32()
+	if r.err == nil && (n < 0 || n > len(r.b)/size) {
+		r.err = fmt.Errorf("invalid count %d", n)
+	}
+	return n
+}
+
+func (r *shpReader) positions(n int) []Position {
+	out := make([]Position, n)
+	for i := range out {
+		out[i] = Position{r.float64(), r.float64()}
+	}
+	return out
+}
+
+// elevations appends a Z range and values to ps.
+func (r *shpReader) elevations(ps []Position) {
+	r.next(16)
+	for i := range ps {
+		ps[i] = append(ps[i], r.float64())
+	}
+}
+
+func readShape(b []byte) (any, error) {
+	r := shpReader{b: b}
+	typ := r.int32()
+	var g any
+	switch typ {
+	case shpNull:
+	case shpPoint, shpPointM, shpPointZ:
+		p := Point{r.float64(), r.float64()}
+		if typ == shpPointZ {
+			p = append(p, r.float64())
+		}
+		g = p
+	case shpMultiPoint, shpMultiPointM, shpMultiPointZ:
+		r.next(32)
+		ps := r.positions(r.count(16))
+		if typ == shpMultiPointZ {
+			r.elevations(ps)
+		}
+		g = MultiPoint(ps)
+	case shpPolyLine, shpPolyLineM, shpPolyLineZ, shpPolygon, shpPolygonM, shpPolygonZ:
+		r.next(32)
+		numParts := r.count(4)
+		numPoints := r.int32()
+		starts := make([]int, numParts)
+		for i := range starts {
+			starts[i] = r.int32()
+		}
+		if r.err == nil && (numPoints < 0 || numPoints > len(r.b)/16) {
+			return nil, fmt.Errorf("invalid count %d", numPoints)
+		}
+		ps := r.positions(numPoints)
+		if typ == shpPolyLineZ || typ == shpPolygonZ {
+			r.elevations(ps)
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+
+		parts := make([][]Position, numParts)
+		for i, start := range starts {
+			end := numPoints
+			if i+1 < numParts {
+				end = starts[i+1]
+			}
+			if start < 0 || start > end || end > numPoints {
+				return nil, fmt.Errorf("invalid part index %d", start)
+			}
+			parts[i] = ps[start:end:end]
+		}
+
+		switch {
+		case typ%shpZ == shpPolygon:
+			g = esriPolygon(parts)
+		case len(parts) == 1:
+			g = LineString(parts[0])
+		default:
+			mls := make(MultiLineString, len(parts))
+			for i, part := range parts {
+				mls[i] = part
+			}
+			g = mls
+		}
+	default:
+		return nil, fmt.Errorf("unsupported shape type %d", typ)
+	}
+	return g, r.err
+}
+
+type dbfField struct {
+	name     string
+	typ      byte
+	length   int
+	decimals int
+}
+
+// readDBF decodes dBase records, with nil entries for deleted records.
+func readDBF(b []byte) ([]map[string]any, error) {
+	if len(b) < 32 {
+		return nil, errors.New("dbf: unexpected end of input")
+	}
+	n := int(binary.LittleEndian.Uint32(b[4:]))
+	headerLen := int(binary.LittleEndian.Uint16(b[8:]))
+	recordLen := int(binary.LittleEndian.Uint16(b[10:]))
+	if headerLen > len(b) || recordLen < 1 || n > (len(b)-headerLen)/recordLen {
+		return nil, errors.New("dbf: unexpected end of input")
+	}
+
+	var fields []dbfField
+	width := 1
+	for off := 32; off+32 <= headerLen && b[off] != dbfHeaderTerminator; off += 32 {
+		desc := b[off : off+32]
+		name := desc[:11]
+		if i := bytes.IndexByte(name, 0); i >= 0 {
+			name = name[:i]
+		}
+		f := dbfField{name: string(name), typ: desc[11], length: int(desc[16]), decimals: int(desc[17])}
+		fields = append(fields, f)
+		width += f.length
+	}
+	if width > recordLen {
+		return nil, errors.New("dbf: fields exceed record length")
+	}
+
+	out := make([]map[string]any, n)
+	for i := range out {
+		rec := b[headerLen+i*recordLen : headerLen+(i+1)*recordLen]
+		if rec[0] == '*' {
+			continue
+		}
+		props := make(map[string]any, len(fields))
+		off := 1
+		for _, f := range fields {
+			props[f.name] = f.decode(rec[off : off+f.length])
+			off += f.length
+		}
+		out[i] = props
+	}
+	return out, nil
+}
+
+func (f dbfField) decode(b []byte) any {
+	s := strings.TrimSpace(strings.TrimRight(string(b), "\x00"))
+	switch f.typ {
+	case 'N', 'F':
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil
+		}
+		return v
+	case 'L':
+		switch s {
+		case "T", "t", "Y", "y":
+			return true
+		case "F", "f", "N", "n":
+			return false
+		}
+		return nil
+	case 'D':
+		if t, err := time.Parse("20060102", s); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func writeDBF(features []Feature) ([]byte, error) {
+	keySet := map[string]bool{}
+	for _, ft := range features {
+		for k := range ft.Properties {
+			keySet[k] = true
+		}
+	}
+	keys := make([]string, 0, len(keySet))
+	for k := range keySet {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := make([]dbfField, len(keys))
+	names := map[string]string{}
+	recordLen := 1
+	for i, k := range keys {
+		name := truncateUTF8(k, 10)
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("dbf: properties %q and %q have the same field name", other, k)
+		}
+		names[name] = k
+
+		f, err := dbfFieldFor(name, k, features)
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = f
+		recordLen += f.length
+	}
+	if headerLen := 32 + 32*len(fields) + 1; headerLen > math.MaxUint16 {
+		return nil, fmt.Errorf("dbf: %d fields do not fit in the header", len(fields))
+	}
+	if recordLen > math.MaxUint16 {
+		return nil, fmt.Errorf("dbf: record length %d exceeds %d bytes", recordLen, math.MaxUint16)
+	}
+
+	var buf bytes.Buffer
+	header := make([]byte, 32)
+	header[0] = 0x03
+	copy(header[1:4], dbfLastUpdate[:])
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(features)))
+	binary.LittleEndian.PutUint16(header[8:], uint16(32+32*len(fields)+1))
+	binary.LittleEndian.PutUint16(header[10:], uint16(recordLen))
+	buf.Write(header)
+	for _, f := range fields {
+		desc := make([]byte, 32)
+		copy(desc, f.name)
+		desc[11] = f.typ
+		desc[16] = byte(f.length)
+		desc[17] = byte(f.decimals)
+		buf.Write(desc)
+	}
+	buf.WriteByte(dbfHeaderTerminator)
+
+	for _, ft := range features {
+		buf.WriteByte(' ')
+		for i, f := range fields {
+			s, err := f.encode(ft.Properties[keys[i]])
+			if err != nil {
+				return nil, fmt.Errorf("dbf: property %q: %w", keys[i], err)
+			}
+			buf.WriteString(s)
+		}
+	}
+	buf.WriteByte(dbfEOF)
+	return buf.Bytes(), nil
+}
+
+// dbfFieldFor chooses the type and size of the field for property key.
+func dbfFieldFor(name, key string, features []Feature) (dbfField, error) {
+	allBool, allNumber := true, true
+	for _, ft := range features {
+		switch v := ft.Properties[key]; v.(type) {
+		case nil:
+		case bool:
+			allNumber = false
+		default:
+			if _, ok := dbfNumber(v); !ok {
+				allNumber = false
+			}
+			allBool = false
+		}
+	}
+
+	f := dbfField{name: name, typ: 'C', length: 1}
+	switch {
+	case allBool:
+		f.typ = 'L'
+	case allNumber:
+		f.typ = 'N'
+		for _, ft := range features {
+			v, ok := dbfNumber(ft.Properties[key])
+			if !ok {
+				continue
+			}
+			s := strconv.FormatFloat(v, 'f', -1, 64)
+			if i := strings.IndexByte(s, '.'); i >= 0 && len(s)-i-1 > f.decimals {
+				f.decimals = minInt(len(s)-i-1, dbfMaxDecimals)
+			}
+		}
+		for _, ft := range features {
+			if v, ok := dbfNumber(ft.Properties[key]); ok {
+				f.length = maxInt(f.length, len(strconv.FormatFloat(v, 'f', f.decimals, 64)))
+			}
+		}
+		if f.length > dbfMaxFieldLength {
+			return dbfField{}, fmt.Errorf("dbf: property %q: number too wide", key)
+		}
+	default:
+		for _, ft := range features {
+			s, err := formatCSVValue(ft.Properties[key])
+			if err != nil {
+				return dbfField{}, fmt.Errorf("dbf: property %q: %w", key, err)
+			}
+			f.length = maxInt(f.length, minInt(len(s), dbfMaxFieldLength))
+		}
+	}
+	return f, nil
+}
+
+func (f dbfField) encode(v any) (string, error) {
+	switch f.typ {
+	case 'L':
+		switch v {
+		case true:
+			return "T", nil
+		case false:
+			return "F", nil
+		}
+		return "?", nil
+	case 'N':
+		n, ok := dbfNumber(v)
+		if !ok {
+			return strings.Repeat(" ", f.length), nil
+		}
+		s := strconv.FormatFloat(n, 'f', f.decimals, 64)
+		return strings.Repeat(" ", f.length-len(s)) + s, nil
+	default:
+		s, err := formatCSVValue(v)
+		if err != nil {
+			return "", err
+		}
+		s = truncateUTF8(s, f.length)
+		return s + strings.Repeat(" ", f.length-len(s)), nil
+	}
+}
+
+// truncateUTF8 shortens s to at most n bytes without splitting a rune.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func dbfNumber(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, !math.IsNaN(v) && !math.IsInf(v, 0)
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
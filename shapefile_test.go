@@ -0,0 +1,188 @@
+package joejson
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShapefile(t *testing.T) {
+	testCases := map[string]struct {
+		fc  FeatureCollection
+		typ int
+	}{
+		"Point": {
+			fc: FeatureCollection{Features: []Feature{
+				Feature{Properties: map[string]any{"name": "a", "n": 1.0, "ok": true}}.WithPoint(Point{1, 2}),
+				{Properties: map[string]any{"name": "b", "n": 2.25, "ok": false}},
+				Feature{Properties: map[string]any{"name": nil, "n": nil, "ok": nil}}.WithPoint(Point{-3, 4.5}),
+			}},
+			typ: shpPoint,
+		},
+		"PointZ": {
+			fc: FeatureCollection{Features: []Feature{
+				Feature{Properties: map[string]any{}}.WithPoint(Point{1, 2, 3}),
+			}},
+			typ: shpPointZ,
+		},
+		"MultiPointZ": {
+			fc: FeatureCollection{Features: []Feature{
+				Feature{Properties: map[string]any{}}.WithMultiPoint(MultiPoint{{1, 2, 3}, {4, 5, 6}}),
+			}},
+			typ: shpMultiPointZ,
+		},
+		"PolyLine": {
+			fc: FeatureCollection{Features: []Feature{
+				Feature{Properties: map[string]any{"tags": `["x"]`}}.WithLineString(LineString{{0, 0}, {1, 1}}),
+				Feature{Properties: map[string]any{"tags": "y"}}.WithMultiLineString(MultiLineString{{{0, 0}, {1, 1}}, {{2, 2}, {3, 3}, {4, 2}}}),
+			}},
+			typ: shpPolyLine,
+		},
+		"Polygon": {
+			fc: FeatureCollection{Features: []Feature{
+				Feature{Properties: map[string]any{}}.WithPolygon(Polygon{
+					{{0, 0}, {4, 0}, {4, 4}, {0, 4}, {0, 0}},
+					{{1, 1}, {1, 2}, {2, 2}, {2, 1}, {1, 1}},
+				}),
+				Feature{Properties: map[string]any{}}.WithMultiPolygon(MultiPolygon{
+					{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}},
+					{{{10, 10}, {11, 10}, {11, 11}, {10, 10}}},
+				}),
+			}},
+			typ: shpPolygon,
+		},
+	}
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			s, err := tt.fc.MarshalShapefile()
+			assert.NoError(t, err)
+			assert.Equal(t, uint32(tt.typ), binary.LittleEndian.Uint32(s.SHP[32:]))
+			assert.Equal(t, uint32(len(s.SHP)/2), binary.BigEndian.Uint32(s.SHP[24:]))
+			assert.Equal(t, uint32(len(s.SHX)/2), binary.BigEndian.Uint32(s.SHX[24:]))
+			assert.Equal(t, 100+8*len(tt.fc.Features), len(s.SHX))
+			assert.Equal(t, uint32(50), binary.BigEndian.Uint32(s.SHX[100:]))
+			assert.Equal(t, wgs84PRJ, string(s.PRJ))
+			assert.Equal(t, "UTF-8", string(s.CPG))
+
+			got, err := UnmarshalShapefile(s)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.fc, got)
+		})
+	}
+
+	t.Run("bounds", func(t *testing.T) {
+		s, err := testCases["Point"].fc.MarshalShapefile()
+		assert.NoError(t, err)
+		var box [4]float64
+		assert.NoError(t, binary.Read(bytes.NewReader(s.SHP[36:68]), binary.LittleEndian, &box))
+		assert.Equal(t, [4]float64{-3, 2, 1, 4.5}, box)
+	})
+
+	t.Run("without dbf", func(t *testing.T) {
+		s, err := testCases["PointZ"].fc.MarshalShapefile()
+		assert.NoError(t, err)
+		got, err := UnmarshalShapefile(Shapefile{SHP: s.SHP})
+		assert.NoError(t, err)
+		assert.Equal(t, FeatureCollection{Features: []Feature{Feature{}.WithPoint(Point{1, 2, 3})}}, got)
+	})
+
+	t.Run("deleted record", func(t *testing.T) {
+		s, err := testCases["Point"].fc.MarshalShapefile()
+		assert.NoError(t, err)
+		headerLen := binary.LittleEndian.Uint16(s.DBF[8:])
+		s.DBF[headerLen] = '*'
+		got, err := UnmarshalShapefile(s)
+		assert.NoError(t, err)
+		assert.Equal(t, testCases["Point"].fc.Features[1:], got.Features)
+	})
+
+	t.Run("deterministic", func(t *testing.T) {
+		a, err := testCases["Point"].fc.MarshalShapefile()
+		assert.NoError(t, err)
+		b, err := testCases["Point"].fc.MarshalShapefile()
+		assert.NoError(t, err)
+		assert.Equal(t, a, b)
+		assert.Equal(t, []byte{70, 1, 1}, a.DBF[1:4])
+	})
+
+	t.Run("truncated at a rune boundary", func(t *testing.T) {
+		long := "a" + strings.Repeat("é", 150)
+		fc := FeatureCollection{Features: []Feature{
+			{Properties: map[string]any{"abcdefghi€x": long}},
+		}}
+		s, err := fc.MarshalShapefile()
+		assert.NoError(t, err)
+		got, err := UnmarshalShapefile(s)
+		assert.NoError(t, err)
+		v := got.Features[0].Properties["abcdefghi"].(string)
+		assert.True(t, utf8.ValidString(v))
+		assert.Equal(t, long[:253], v)
+	})
+
+	t.Run("zip", func(t *testing.T) {
+		s, err := testCases["Polygon"].fc.MarshalShapefile()
+		assert.NoError(t, err)
+
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for name, b := range map[string][]byte{"data/Parcels.SHP": s.SHP, "data/parcels.dbf": s.DBF, "data/parcels.cpg": s.CPG} {
+			w, err := zw.Create(name)
+			assert.NoError(t, err)
+			_, err = w.Write(b)
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, zw.Close())
+
+		got, err := UnmarshalShapefileZip(buf.Bytes())
+		assert.NoError(t, err)
+		assert.Equal(t, testCases["Polygon"].fc, got)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := FeatureCollection{Features: []Feature{
+			Feature{}.WithPoint(Point{1, 2}),
+			Feature{}.WithLineString(LineString{{1, 2}, {3, 4}}),
+		}}.MarshalShapefile()
+		assert.EqualError(t, err, `shp: feature 1: geometry type "LineString" does not match earlier features`)
+
+		_, err = FeatureCollection{Features: []Feature{
+			{Properties: map[string]any{"population_2020": 1.0, "population_2021": 2.0}},
+		}}.MarshalShapefile()
+		assert.EqualError(t, err, `dbf: properties "population_2020" and "population_2021" have the same field name`)
+
+		wide := map[string]any{}
+		for i := 0; i < 260; i++ {
+			wide[fmt.Sprintf("f%d", i)] = strings.Repeat("x", 254)
+		}
+		_, err = FeatureCollection{Features: []Feature{{Properties: wide}}}.MarshalShapefile()
+		assert.EqualError(t, err, "dbf: record length 66041 exceeds 65535 bytes")
+
+		many := map[string]any{}
+		for i := 0; i < 2047; i++ {
+			many[fmt.Sprintf("f%d", i)] = true
+		}
+		_, err = FeatureCollection{Features: []Feature{{Properties: many}}}.MarshalShapefile()
+		assert.EqualError(t, err, "dbf: 2047 fields do not fit in the header")
+		delete(many, "f0")
+		_, err = FeatureCollection{Features: []Feature{{Properties: many}}}.MarshalShapefile()
+		assert.NoError(t, err)
+
+		_, err = UnmarshalShapefile(Shapefile{SHP: make([]byte, 100)})
+		assert.EqualError(t, err, "shp: invalid file code 0")
+
+		s, err := testCases["PolyLine"].fc.MarshalShapefile()
+		assert.NoError(t, err)
+		_, err = UnmarshalShapefile(Shapefile{SHP: s.SHP[:len(s.SHP)-1]})
+		assert.ErrorIs(t, err, errSHPShort)
+
+		s.DBF = s.DBF[:32]
+		_, err = UnmarshalShapefile(s)
+		assert.EqualError(t, err, "dbf: unexpected end of input")
+	})
+}
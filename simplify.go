@@ -0,0 +1,404 @@
+package joejson
+
+import (
+	"container/heap"
+	"math"
+)
+
+// SimplifyAlgorithm selects how positions are discarded by Simplify.
+type SimplifyAlgorithm int
+
+const (
+	// DouglasPeucker discards positions closer than the tolerance to the simplified line.
+	DouglasPeucker SimplifyAlgorithm = iota
+	// VisvalingamWhyatt discards positions whose effective triangle area is below the tolerance.
+	VisvalingamWhyatt
+)
+
+// SimplifyOptions configures Simplify.
+type SimplifyOptions struct {
+	// Algorithm defaults to DouglasPeucker.
+	Algorithm SimplifyAlgorithm
+	// PreserveTopology keeps every LinearRing closed with at least four positions
+	// and prevents simplified segments from crossing one another.
+	// Without it, rings collapsing below four positions are dropped.
+	PreserveTopology bool
+}
+
+// Simplify reduces the number of positions in the LineString, always keeping its endpoints.
+func (g LineString) Simplify(tolerance float64, opts SimplifyOptions) LineString {
+	parts := simplifyParts([][]Position{g}, false, tolerance, opts)
+	return parts[0]
+}
+
+// Simplify reduces the number of positions in each LineString.
+func (g MultiLineString) Simplify(tolerance float64, opts SimplifyOptions) MultiLineString {
+	parts := make([][]Position, len(g))
+	for i, ls := range g {
+		parts[i] = ls
+	}
+	out := make(MultiLineString, 0, len(g))
+	for _, part := range simplifyParts(parts, false, tolerance, opts) {
+		out = append(out, part)
+	}
+	return out
+}
+
+// Simplify reduces the number of positions in each LinearRing.
+// Without PreserveTopology, collapsed holes are dropped and a collapsed exterior ring yields an empty Polygon.
+func (p Polygon) Simplify(tolerance float64, opts SimplifyOptions) Polygon {
+	parts := make([][]Position, len(p))
+	for i, lr := range p {
+		parts[i] = lr
+	}
+	simplified := simplifyParts(parts, true, tolerance, opts)
+	if len(simplified) == 0 || simplified[0] == nil {
+		return nil
+	}
+
+	out := make(Polygon, 0, len(p))
+	for _, part := range simplified {
+		if part != nil {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// Simplify reduces the number of positions in each Polygon, dropping any that collapse.
+func (p MultiPolygon) Simplify(tolerance float64, opts SimplifyOptions) MultiPolygon {
+	out := make(MultiPolygon, 0, len(p))
+	for _, pl := range p {
+		if s := pl.Simplify(tolerance, opts); len(s) > 0 {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Simplify reduces the number of positions in each member, dropping any that collapse.
+func (g GeometryCollection) Simplify(tolerance float64, opts SimplifyOptions) GeometryCollection {
+	out := make(GeometryCollection, 0, len(g))
+	for _, m := range g {
+		if s := simplifyGeometry(m.geometry, tolerance, opts); s != nil {
+			out = append(out, GeometryCollectionMember{s})
+		}
+	}
+	return out
+}
+
+// Simplify returns a copy of the Feature with a simplified Geometry.
+func (f Feature) Simplify(tolerance float64, opts SimplifyOptions) Feature {
+	f.geometry = simplifyGeometry(f.geometry, tolerance, opts)
+	return f
+}
+
+// Simplify returns a copy of the FeatureCollection with every Feature's Geometry simplified.
+func (f FeatureCollection) Simplify(tolerance float64, opts SimplifyOptions) FeatureCollection {
+	features := make([]Feature, len(f.Features))
+	for i, ft := range f.Features {
+		features[i] = ft.Simplify(tolerance, opts)
+	}
+	f.Features = features
+	return f
+}
+
+// simplifyGeometry simplifies any geometry type, returning nil if it collapsed entirely.
+func simplifyGeometry(g any, tolerance float64, opts SimplifyOptions) any {
+	switch g := g.(type) {
+	case LineString:
+		return g.Simplify(tolerance, opts)
+	case MultiLineString:
+		return g.Simplify(tolerance, opts)
+	case Polygon:
+		if s := g.Simplify(tolerance, opts); len(s) > 0 {
+			return s
+		}
+		return nil
+	case MultiPolygon:
+		if s := g.Simplify(tolerance, opts); len(s) > 0 {
+			return s
+		}
+		return nil
+	case GeometryCollection:
+		return g.Simplify(tolerance, opts)
+	default:
+		return g
+	}
+}
+
+// simplifyParts simplifies a set of lines or closed rings belonging to one geometry.
+// A nil entry in the result marks a ring which collapsed.
+func simplifyParts(parts [][]Position, closed bool, tolerance float64, opts SimplifyOptions) [][]Position {
+	minLen := 2
+	if closed {
+		minLen = 4
+	}
+
+	masks := make([][]bool, len(parts))
+	for i, part := range parts {
+		switch opts.Algorithm {
+		case VisvalingamWhyatt:
+			masks[i] = visvalingamWhyatt(part, tolerance)
+		default:
+			masks[i] = douglasPeucker(part, tolerance)
+		}
+		if opts.PreserveTopology {
+			for countKept(masks[i]) < minInt(minLen, len(part)) {
+				restoreFarthest(part, masks[i])
+			}
+		}
+	}
+
+	if opts.PreserveTopology {
+		for restoreCrossings(parts, masks) {
+		}
+	}
+
+	out := make([][]Position, len(parts))
+	for i, part := range parts {
+		if closed && countKept(masks[i]) < minLen {
+			continue
+		}
+		kept := make([]Position, 0, countKept(masks[i]))
+		for j, keep := range masks[i] {
+			if keep {
+				kept = append(kept, part[j])
+			}
+		}
+		out[i] = kept
+	}
+	return out
+}
+
+// douglasPeucker marks the positions kept by the Ramer-Douglas-Peucker algorithm.
+func douglasPeucker(pts []Position, tolerance float64) []bool {
+	keep := make([]bool, len(pts))
+	if len(pts) == 0 {
+		return keep
+	}
+	keep[0], keep[len(pts)-1] = true, true
+
+	type span struct{ a, b int }
+	stack := []span{{0, len(pts) - 1}}
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		farthest, dist := -1, tolerance
+		for i := s.a + 1; i < s.b; i++ {
+			if d := segmentDistance(pts[i], pts[s.a], pts[s.b]); d > dist {
+				farthest, dist = i, d
+			}
+		}
+		if farthest >= 0 {
+			keep[farthest] = true
+			stack = append(stack, span{s.a, farthest}, span{farthest, s.b})
+		}
+	}
+	return keep
+}
+
+// visvalingamWhyatt marks the positions kept by the Visvalingam-Whyatt algorithm.
+func visvalingamWhyatt(pts []Position, tolerance float64) []bool {
+	keep := make([]bool, len(pts))
+	for i := range keep {
+		keep[i] = true
+	}
+	if len(pts) < 3 {
+		return keep
+	}
+
+	prev := make([]int, len(pts))
+	next := make([]int, len(pts))
+	items := make([]*vwItem, len(pts))
+	q := &vwQueue{}
+	for i := range pts {
+		prev[i], next[i] = i-1, i+1
+		if i == 0 || i == len(pts)-1 {
+			continue
+		}
+		items[i] = &vwItem{index: i, area: triangleArea(pts[i-1], pts[i], pts[i+1])}
+		heap.Push(q, items[i])
+	}
+
+	for q.Len() > 0 {
+		item := heap.Pop(q).(*vwItem)
+		if item.area >= tolerance {
+			break
+		}
+		i := item.index
+		keep[i] = false
+		p, n := prev[i], next[i]
+		next[p], prev[n] = n, p
+
+		// neighbours never drop below the area of an already removed position.
+		for _, j := range []int{p, n} {
+			if items[j] == nil {
+				continue
+			}
+			area := triangleArea(pts[prev[j]], pts[j], pts[next[j]])
+			items[j].area = math.Max(area, item.area)
+			heap.Fix(q, items[j].heapIndex)
+		}
+	}
+	return keep
+}
+
+type vwItem struct {
+	index     int
+	area      float64
+	heapIndex int
+}
+
+// vwQueue is a min-heap of positions ordered by effective area.
+type vwQueue []*vwItem
+
+func (q vwQueue) Len() int           { return len(q) }
+func (q vwQueue) Less(i, j int) bool { return q[i].area < q[j].area }
+func (q vwQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].heapIndex, q[j].heapIndex = i, j
+}
+func (q *vwQueue) Push(x any) {
+	item := x.(*vwItem)
+	item.heapIndex = len(*q)
+	*q = append(*q, item)
+}
+func (q *vwQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+func countKept(mask []bool) int {
+	n := 0
+	for _, keep := range mask {
+		if keep {
+			n++
+		}
+	}
+	return n
+}
+
+// restoreFarthest keeps the discarded position farthest from the simplified line.
+func restoreFarthest(pts []Position, mask []bool) {
+	best, bestDist := -1, -1.0
+	a := -1
+	for b, keep := range mask {
+		if !keep {
+			continue
+		}
+		if a >= 0 {
+			if i, d := farthestBetween(pts, a, b); i >= 0 && d > bestDist {
+				best, bestDist = i, d
+			}
+		}
+		a = b
+	}
+	if best >= 0 {
+		mask[best] = true
+	}
+}
+
+// farthestBetween finds the position strictly between a and b farthest from segment a-b.
+func farthestBetween(pts []Position, a, b int) (int, float64) {
+	best, bestDist := -1, -1.0
+	for i := a + 1; i < b; i++ {
+		if d := segmentDistance(pts[i], pts[a], pts[b]); d > bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best, bestDist
+}
+
+// restoreCrossings restores positions under simplified segments which cross
+// any other simplified segment, reporting whether anything changed.
+func restoreCrossings(parts [][]Position, masks [][]bool) bool {
+	type segment struct {
+		part, a, b int
+	}
+	var segments []segment
+	for i, mask := range masks {
+		a := -1
+		for b, keep := range mask {
+			if !keep {
+				continue
+			}
+			if a >= 0 {
+				segments = append(segments, segment{i, a, b})
+			}
+			a = b
+		}
+	}
+
+	changed := false
+	for i, s := range segments {
+		for _, o := range segments[i+1:] {
+			if s.part == o.part && (s.b == o.a || o.b == s.a) {
+				continue
+			}
+			if s.part == o.part && s.a == 0 && o.b == len(parts[o.part])-1 && positionsEqual(parts[s.part][0], parts[s.part][o.b]) {
+				continue
+			}
+			if !segmentsIntersect(parts[s.part][s.a], parts[s.part][s.b], parts[o.part][o.a], parts[o.part][o.b]) {
+				continue
+			}
+			for _, seg := range []segment{s, o} {
+				if idx, _ := farthestBetween(parts[seg.part], seg.a, seg.b); idx >= 0 && !masks[seg.part][idx] {
+					masks[seg.part][idx] = true
+					changed = true
+				}
+			}
+		}
+	}
+	return changed
+}
+
+// segmentDistance is the planar distance from p to segment a-b.
+func segmentDistance(p, a, b Position) float64 {
+	dx, dy := b.Lon()-a.Lon(), b.Lat()-a.Lat()
+	if dx == 0 && dy == 0 {
+		return math.Hypot(p.Lon()-a.Lon(), p.Lat()-a.Lat())
+	}
+	t := ((p.Lon()-a.Lon())*dx + (p.Lat()-a.Lat())*dy) / (dx*dx + dy*dy)
+	t = math.Max(0, math.Min(1, t))
+	return math.Hypot(p.Lon()-(a.Lon()+t*dx), p.Lat()-(a.Lat()+t*dy))
+}
+
+// triangleArea is the planar area of triangle a-b-c.
+func triangleArea(a, b, c Position) float64 {
+	return math.Abs(cross(a, b, c)) / 2
+}
+
+// cross is the z component of (b-a) x (c-a); positive when a-b-c turns counterclockwise.
+func cross(a, b, c Position) float64 {
+	return (b.Lon()-a.Lon())*(c.Lat()-a.Lat()) - (b.Lat()-a.Lat())*(c.Lon()-a.Lon())
+}
+
+// segmentsIntersect reports whether segments p1-p2 and p3-p4 share any point.
+func segmentsIntersect(p1, p2, p3, p4 Position) bool {
+	d1 := cross(p3, p4, p1)
+	d2 := cross(p3, p4, p2)
+	d3 := cross(p1, p2, p3)
+	d4 := cross(p1, p2, p4)
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return true
+	}
+	return (d1 == 0 && onSegment(p1, p3, p4)) ||
+		(d2 == 0 && onSegment(p2, p3, p4)) ||
+		(d3 == 0 && onSegment(p3, p1, p2)) ||
+		(d4 == 0 && onSegment(p4, p1, p2))
+}
+
+// onSegment reports whether p, known to be collinear with a-b, lies within its extent.
+func onSegment(p, a, b Position) bool {
+	return math.Min(a.Lon(), b.Lon()) <= p.Lon() && p.Lon() <= math.Max(a.Lon(), b.Lon()) &&
+		math.Min(a.Lat(), b.Lat()) <= p.Lat() && p.Lat() <= math.Max(a.Lat(), b.Lat())
+}
+
+// positionsEqual compares the horizontal coordinates of two Positions.
+func positionsEqual(a, b Position) bool {
+	return a.Lon() == b.Lon() && a.Lat() == b.Lat()
+}
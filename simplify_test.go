@@ -0,0 +1,80 @@
+package joejson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimplify(t *testing.T) {
+	line := LineString{{0, 0}, {1, 0.1}, {2, -0.1}, {3, 5}, {4, 6}, {5, 7}, {6, 8.1}, {7, 9}, {8, 9}, {9, 9}}
+	square := LinearRing{{0, 0}, {5, 0.01}, {10, 0}, {10, 10}, {5, 10.01}, {0, 10}, {0, 0}}
+	sliver := LinearRing{{2, 2}, {2.1, 2}, {2.1, 2.1}, {2, 2}}
+
+	testCases := map[string]struct {
+		geometry any
+		opts     SimplifyOptions
+		want     any
+	}{
+		"LineString Douglas-Peucker": {
+			geometry: line,
+			want:     LineString{{0, 0}, {2, -0.1}, {3, 5}, {7, 9}, {9, 9}},
+		},
+		"LineString Visvalingam-Whyatt": {
+			geometry: line,
+			opts:     SimplifyOptions{Algorithm: VisvalingamWhyatt},
+			want:     LineString{{0, 0}, {2, -0.1}, {3, 5}, {7, 9}, {9, 9}},
+		},
+		"Polygon drops collapsed hole": {
+			geometry: Polygon{square, sliver},
+			want:     Polygon{{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}},
+		},
+		"Polygon preserving topology keeps hole": {
+			geometry: Polygon{square, sliver},
+			opts:     SimplifyOptions{PreserveTopology: true},
+			want:     Polygon{{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}, {{2, 2}, {2.1, 2}, {2.1, 2.1}, {2, 2}}},
+		},
+		"Polygon collapses": {
+			geometry: Polygon{sliver},
+			want:     nil,
+		},
+		"Polygon preserving topology never collapses": {
+			geometry: Polygon{sliver},
+			opts:     SimplifyOptions{PreserveTopology: true},
+			want:     Polygon{sliver},
+		},
+		"MultiPolygon drops collapsed Polygons": {
+			geometry: MultiPolygon{{square}, {sliver}},
+			want:     MultiPolygon{{{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}}},
+		},
+		"GeometryCollection": {
+			geometry: GeometryCollection{}.AppendPoint(Point{1, 2}).AppendPolygon(Polygon{sliver}).AppendLineString(line),
+			want: GeometryCollection{}.AppendPoint(Point{1, 2}).AppendLineString(
+				LineString{{0, 0}, {2, -0.1}, {3, 5}, {7, 9}, {9, 9}},
+			),
+		},
+	}
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, simplifyGeometry(tt.geometry, 0.5, tt.opts))
+		})
+	}
+}
+
+func TestSimplifyPreserveTopology(t *testing.T) {
+	// the hole straddles the exterior's spike, which unconstrained
+	// simplification would flatten straight through it.
+	pl := Polygon{
+		{{0, 0}, {4, 0}, {5, -0.8}, {6, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}},
+		{{4.8, -0.3}, {5.2, -0.3}, {5.2, 0.3}, {4.8, 0.3}, {4.8, -0.3}},
+	}
+
+	got := pl.Simplify(1, SimplifyOptions{})
+	assert.Equal(t, LinearRing{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}, got[0])
+
+	got = pl.Simplify(1, SimplifyOptions{PreserveTopology: true})
+	assert.Len(t, got, 2)
+	assert.Contains(t, got[0], Position{5, -0.8})
+	assert.GreaterOrEqual(t, len(got[1]), 4)
+	assert.Equal(t, got[1][0], got[1][len(got[1])-1])
+}
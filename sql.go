@@ -0,0 +1,247 @@
+package joejson
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// Value implements driver.Valuer, encoding the Point as WKB, or NULL when it is nil.
+func (p Point) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+	return p.MarshalWKB()
+}
+
+// Scan implements sql.Scanner, accepting GeoJSON, WKT, EWKT, WKB, EWKB or hex encoded EWKB.
+func (p *Point) Scan(src any) error {
+	g, err := scanGeometryAs(src, GeometryTypePoint)
+	if err != nil || g == nil {
+		*p = nil
+		return err
+	}
+	*p = g.(Point)
+	return nil
+}
+
+// Value implements driver.Valuer, encoding the MultiPoint as WKB, or NULL when it is nil.
+func (g MultiPoint) Value() (driver.Value, error) {
+	if g == nil {
+		return nil, nil
+	}
+	return g.MarshalWKB()
+}
+
+// Scan implements sql.Scanner, accepting GeoJSON, WKT, EWKT, WKB, EWKB or hex encoded EWKB.
+func (g *MultiPoint) Scan(src any) error {
+	geom, err := scanGeometryAs(src, GeometryTypeMultiPoint)
+	if err != nil || geom == nil {
+		*g = nil
+		return err
+	}
+	*g = geom.(MultiPoint)
+	return nil
+}
+
+// Value implements driver.Valuer, encoding the LineString as WKB, or NULL when it is nil.
+func (g LineString) Value() (driver.Value, error) {
+	if g == nil {
+		return nil, nil
+	}
+	return g.MarshalWKB()
+}
+
+// Scan implements sql.Scanner, accepting GeoJSON, WKT, EWKT, WKB, EWKB or hex encoded EWKB.
+func (g *LineString) Scan(src any) error {
+	geom, err := scanGeometryAs(src, GeometryTypeLineString)
+	if err != nil || geom == nil {
+		*g = nil
+		return err
+	}
+	*g = geom.(LineString)
+	return nil
+}
+
+// Value implements driver.Valuer, encoding the MultiLineString as WKB, or NULL when it is nil.
+func (g MultiLineString) Value() (driver.Value, error) {
+	if g == nil {
+		return nil, nil
+	}
+	return g.MarshalWKB()
+}
+
+// Scan implements sql.Scanner, accepting GeoJSON, WKT, EWKT, WKB, EWKB or hex encoded EWKB.
+func (g *MultiLineString) Scan(src any) error {
+	geom, err := scanGeometryAs(src, GeometryTypeMultiLineString)
+	if err != nil || geom == nil {
+		*g = nil
+		return err
+	}
+	*g = geom.(MultiLineString)
+	return nil
+}
+
+// Value implements driver.Valuer, encoding the Polygon as WKB, or NULL when it is nil.
+func (p Polygon) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+	return p.MarshalWKB()
+}
+
+// Scan implements sql.Scanner, accepting GeoJSON, WKT, EWKT, WKB, EWKB or hex encoded EWKB.
+func (p *Polygon) Scan(src any) error {
+	g, err := scanGeometryAs(src, GeometryTypePolygon)
+	if err != nil || g == nil {
+		*p = nil
+		return err
+	}
+	*p = g.(Polygon)
+	return nil
+}
+
+// Value implements driver.Valuer, encoding the MultiPolygon as WKB, or NULL when it is nil.
+func (p MultiPolygon) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+	return p.MarshalWKB()
+}
+
+// Scan implements sql.Scanner, accepting GeoJSON, WKT, EWKT, WKB, EWKB or hex encoded EWKB.
+func (p *MultiPolygon) Scan(src any) error {
+	g, err := scanGeometryAs(src, GeometryTypeMultiPolygon)
+	if err != nil || g == nil {
+		*p = nil
+		return err
+	}
+	*p = g.(MultiPolygon)
+	return nil
+}
+
+// Value implements driver.Valuer, encoding the GeometryCollection as WKB, or NULL when it is nil.
+func (g GeometryCollection) Value() (driver.Value, error) {
+	if g == nil {
+		return nil, nil
+	}
+	return g.MarshalWKB()
+}
+
+// Scan implements sql.Scanner, accepting GeoJSON, WKT, EWKT, WKB, EWKB or hex encoded EWKB.
+func (g *GeometryCollection) Scan(src any) error {
+	geom, err := scanGeometryAs(src, GeometryTypeGeometryCollection)
+	if err != nil || geom == nil {
+		*g = nil
+		return err
+	}
+	*g = geom.(GeometryCollection)
+	return nil
+}
+
+// Value implements driver.Valuer, encoding the Feature as GeoJSON text.
+func (f Feature) Value() (driver.Value, error) {
+	b, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner, accepting a GeoJSON Feature, or any geometry
+// accepted by the geometry types' Scan which becomes the Feature's Geometry.
+func (f *Feature) Scan(src any) error {
+	b, err := scanBytes(src)
+	if err != nil || b == nil {
+		*f = Feature{}
+		return err
+	}
+
+	if trimmed := bytes.TrimSpace(b); len(trimmed) > 0 && trimmed[0] == '{' {
+		var tmp struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(trimmed, &tmp); err != nil {
+			return err
+		}
+		if tmp.Type == TypeFeature {
+			*f = Feature{}
+			return json.Unmarshal(trimmed, f)
+		}
+	}
+
+	g, err := scanGeometry(b)
+	if err != nil {
+		return err
+	}
+	*f = Feature{geometry: g}
+	return nil
+}
+
+// scanBytes copies a []byte or string source, returning nil for NULL.
+func scanBytes(src any) ([]byte, error) {
+	switch src := src.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return append([]byte{}, src...), nil
+	case string:
+		return []byte(src), nil
+	default:
+		return nil, fmt.Errorf("cannot scan %T into a geometry", src)
+	}
+}
+
+func scanGeometryAs(src any, typ string) (any, error) {
+	b, err := scanBytes(src)
+	if err != nil || b == nil {
+		return nil, err
+	}
+	g, err := scanGeometry(b)
+	if err != nil {
+		return nil, err
+	}
+	if got := geometryType(g); got != typ {
+		return nil, fmt.Errorf("invalid type %q, expected %q", got, typ)
+	}
+	return g, nil
+}
+
+// scanGeometry detects and decodes GeoJSON, WKT, EWKT, WKB, EWKB or hex encoded EWKB.
+func scanGeometry(b []byte) (any, error) {
+	if len(b) > 0 && (b[0] == 0 || b[0] == 1) {
+		g, _, err := UnmarshalEWKB(b)
+		return g.geometry, err
+	}
+
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '{' {
+		return unmarshalGeometry(b)
+	}
+	if isHexWKB(b) {
+		raw := make([]byte, hex.DecodedLen(len(b)))
+		if _, err := hex.Decode(raw, b); err != nil {
+			return nil, err
+		}
+		g, _, err := UnmarshalEWKB(raw)
+		return g.geometry, err
+	}
+
+	g, _, err := UnmarshalEWKT(b)
+	return g.geometry, err
+}
+
+// isHexWKB reports whether b looks like hex encoded WKB, as PostGIS returns geometry columns in text mode.
+func isHexWKB(b []byte) bool {
+	if len(b) < 10 || len(b)%2 != 0 || b[0] != '0' || (b[1] != '0' && b[1] != '1') {
+		return false
+	}
+	for _, c := range b {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,215 @@
+package joejson
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDriver is an in-memory database/sql driver storing a single column.
+// "INSERT" appends its argument and any other query returns every stored value.
+type fakeDriver struct {
+	rows []driver.Value
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{d}, nil }
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return fakeStmt{c.d}, nil }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+type fakeStmt struct{ d *fakeDriver }
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.rows = append(s.d.rows, args...)
+	return driver.RowsAffected(len(args)), nil
+}
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return &fakeRows{rows: s.d.rows}, nil
+}
+
+type fakeRows struct {
+	rows []driver.Value
+	i    int
+}
+
+func (r *fakeRows) Columns() []string { return []string{"geom"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.i >= len(r.rows) {
+		return io.EOF
+	}
+	dest[0] = r.rows[r.i]
+	r.i++
+	return nil
+}
+
+var fake = &fakeDriver{}
+
+func init() {
+	sql.Register("joejson-fake", fake)
+}
+
+func TestSQL(t *testing.T) {
+	db, err := sql.Open("joejson-fake", "")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	exec := func(v any) {
+		fake.rows = nil
+		_, err := db.Exec("INSERT", v)
+		assert.NoError(t, err)
+	}
+	scan := func(dest any) error {
+		return db.QueryRow("SELECT").Scan(dest)
+	}
+
+	t.Run("geometries round trip as WKB", func(t *testing.T) {
+		geometries := []any{
+			Point{1, 2},
+			MultiPoint{{1, 2}, {3, 4}},
+			LineString{{1, 2}, {3, 4}},
+			MultiLineString{{{1, 2}, {3, 4}}},
+			Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}},
+			MultiPolygon{{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}}},
+			GeometryCollection{}.AppendPoint(Point{1, 2, 3}),
+		}
+		dests := []any{
+			&Point{}, &MultiPoint{}, &LineString{}, &MultiLineString{}, &Polygon{}, &MultiPolygon{}, &GeometryCollection{},
+		}
+		for i, g := range geometries {
+			exec(g)
+			assert.IsType(t, []byte{}, fake.rows[0])
+			assert.NoError(t, scan(dests[i]))
+			assert.Equal(t, g, deref(dests[i]))
+		}
+	})
+
+	t.Run("nil geometries are NULL", func(t *testing.T) {
+		geometries := []any{
+			Point(nil), MultiPoint(nil), LineString(nil), MultiLineString(nil),
+			Polygon(nil), MultiPolygon(nil), GeometryCollection(nil),
+		}
+		dests := []any{
+			&Point{1, 2}, &MultiPoint{}, &LineString{}, &MultiLineString{}, &Polygon{}, &MultiPolygon{}, &GeometryCollection{},
+		}
+		for i, g := range geometries {
+			exec(g)
+			assert.Nil(t, fake.rows[0])
+			assert.NoError(t, scan(dests[i]))
+			assert.Equal(t, g, deref(dests[i]))
+		}
+	})
+
+	t.Run("Feature round trips as GeoJSON", func(t *testing.T) {
+		ft := Feature{ID: "a", Properties: map[string]any{"k": "v"}}.WithPoint(Point{1, 2})
+		exec(ft)
+		assert.IsType(t, "", fake.rows[0])
+
+		var got Feature
+		assert.NoError(t, scan(&got))
+		assert.Equal(t, ft, got)
+	})
+
+	ewkb, _ := MarshalEWKB(LineString{{1, 2}, {3, 4}}, 4326, binary.BigEndian)
+	scanCases := map[string]struct {
+		src  any
+		dest any
+		want any
+		err  string
+	}{
+		"GeoJSON": {
+			src:  `{"type":"LineString","coordinates":[[1,2],[3,4]]}`,
+			dest: &LineString{},
+			want: LineString{{1, 2}, {3, 4}},
+		},
+		"WKT": {
+			src:  []byte("LINESTRING (1 2, 3 4)"),
+			dest: &LineString{},
+			want: LineString{{1, 2}, {3, 4}},
+		},
+		"EWKT": {
+			src:  "SRID=4326;LINESTRING (1 2, 3 4)",
+			dest: &LineString{},
+			want: LineString{{1, 2}, {3, 4}},
+		},
+		"EWKB": {
+			src:  ewkb,
+			dest: &LineString{},
+			want: LineString{{1, 2}, {3, 4}},
+		},
+		"hex EWKB": {
+			src:  hex.EncodeToString(ewkb),
+			dest: &LineString{},
+			want: LineString{{1, 2}, {3, 4}},
+		},
+		"NULL": {
+			src:  nil,
+			dest: &LineString{{1, 2}},
+			want: LineString(nil),
+		},
+		"Feature from WKT": {
+			src:  "POINT (1 2)",
+			dest: &Feature{},
+			want: Feature{}.WithPoint(Point{1, 2}),
+		},
+		"Feature from GeoJSON geometry": {
+			src:  `{"type":"Point","coordinates":[1,2]}`,
+			dest: &Feature{},
+			want: Feature{}.WithPoint(Point{1, 2}),
+		},
+		"type mismatch": {
+			src:  "POINT (1 2)",
+			dest: &Polygon{},
+			err:  `sql: Scan error on column index 0, name "geom": invalid type "Point", expected "Polygon"`,
+		},
+		"unsupported source": {
+			src:  int64(1),
+			dest: &Point{},
+			err:  `sql: Scan error on column index 0, name "geom": cannot scan int64 into a geometry`,
+		},
+	}
+	for name, tt := range scanCases {
+		t.Run(name, func(t *testing.T) {
+			fake.rows = []driver.Value{tt.src}
+			err := scan(tt.dest)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, deref(tt.dest))
+		})
+	}
+}
+
+func deref(v any) any {
+	switch v := v.(type) {
+	case *Point:
+		return *v
+	case *MultiPoint:
+		return *v
+	case *LineString:
+		return *v
+	case *MultiLineString:
+		return *v
+	case *Polygon:
+		return *v
+	case *MultiPolygon:
+		return *v
+	case *GeometryCollection:
+		return *v
+	case *Feature:
+		return *v
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+package joejson
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+const (
+	// maxTileZoom is the deepest zoom level whose tile indexes fit in an int32.
+	maxTileZoom = 30
+	// tileMaxCover bounds the number of tiles a geometry's bounds may span.
+	tileMaxCover = 1 << 20
+)
+
+// Tile is a slippy map tile: column X and row Y, counted from the
+// north-west corner of the Web Mercator world, at zoom level Z.
+type Tile struct {
+	Z, X, Y int
+}
+
+// String formats the tile as z/x/y.
+func (t Tile) String() string {
+	return strconv.Itoa(t.Z) + "/" + strconv.Itoa(t.X) + "/" + strconv.Itoa(t.Y)
+}
+
+// PositionTile returns the tile containing p at zoom z. Latitudes beyond the
+// Web Mercator range fall in the first or last row. A longitude or latitude
+// that is not finite is an error.
+func PositionTile(p Position, z int) (Tile, error) {
+	if err := checkZoom(z); err != nil {
+		return Tile{}, err
+	}
+	if !isFinite(p.Lon()) || !isFinite(p.Lat()) {
+		return Tile{}, fmt.Errorf("tile: position %v is not finite", p)
+	}
+	x, y := tileCoords(p, z)
+	return clampTile(z, int(math.Floor(x)), int(math.Floor(y))), nil
+}
+
+// TilePosition returns the north-west corner of tile z/x/y.
+func TilePosition(z, x, y int) (Position, error) {
+	if err := checkTile(z, x, y); err != nil {
+		return nil, err
+	}
+	return tileCorner(z, float64(x), float64(y)), nil
+}
+
+// TileBounds returns tile z/x/y as a rectangular Polygon.
+func TileBounds(z, x, y int) (Polygon, error) {
+	if err := checkTile(z, x, y); err != nil {
+		return nil, err
+	}
+	return tileBounds(Tile{z, x, y}).polygon(), nil
+}
+
+// Tiles returns the tiles at zoom z the LineString passes through, ordered by
+// column then row. Segments are straight in Web Mercator, as when rendered.
+// Bounds spanning more than 2^20 tiles at zoom z are an error.
+func (g LineString) Tiles(z int) ([]Tile, error) {
+	return geometryTiles(g, z)
+}
+
+// Tiles returns the tiles at zoom z the Polygon touches, ordered by column
+// then row: those its rings pass through and those inside it.
+func (p Polygon) Tiles(z int) ([]Tile, error) {
+	return geometryTiles(p, z)
+}
+
+// SplitByTile groups the FeatureCollection's Features by the tiles at zoom z
+// their Geometry touches. Features are not clipped, so one spanning several
+// tiles appears in each. Features without a Geometry are left out, and one
+// whose bounds span more than 2^20 tiles at zoom z is an error.
+func (f FeatureCollection) SplitByTile(z int) (map[Tile]FeatureCollection, error) {
+	if err := checkZoom(z); err != nil {
+		return nil, err
+	}
+	for _, ft := range f.Features {
+		if err := checkTileSpan(ft.geometry, z); err != nil {
+			return nil, err
+		}
+	}
+	out := make(map[Tile]FeatureCollection)
+	for _, ft := range f.Features {
+		set := make(map[Tile]struct{})
+		addGeometryTiles(set, ft.geometry, z)
+		for t := range set {
+			fc := out[t]
+			fc.Features = append(fc.Features, ft)
+			out[t] = fc
+		}
+	}
+	return out, nil
+}
+
+func checkZoom(z int) error {
+	if z < 0 || z > maxTileZoom {
+		return fmt.Errorf("tile: invalid zoom %d", z)
+	}
+	return nil
+}
+
+func checkTile(z, x, y int) error {
+	if err := checkZoom(z); err != nil {
+		return err
+	}
+	if n := 1 << z; x < 0 || x >= n || y < 0 || y >= n {
+		return fmt.Errorf("tile: %d/%d/%d out of range", z, x, y)
+	}
+	return nil
+}
+
+// checkTileSpan rejects positions that are not finite and bounds spanning
+// more than tileMaxCover tiles, before any tiles are walked.
+func checkTileSpan(g any, z int) error {
+	var bad Position
+	eachPosition(g, func(p Position) {
+		if bad == nil && (!isFinite(p.Lon()) || !isFinite(p.Lat())) {
+			bad = p
+		}
+	})
+	if bad != nil {
+		return fmt.Errorf("tile: position %v is not finite", bad)
+	}
+	b, ok := geometryBounds(g)
+	if !ok {
+		return nil
+	}
+	x0, y0 := tileCoords(Position{b.minX, b.maxY}, z)
+	x1, y1 := tileCoords(Position{b.maxX, b.minY}, z)
+	nw := clampTile(z, int(math.Floor(x0)), int(math.Floor(y0)))
+	se := clampTile(z, int(math.Floor(x1)), int(math.Floor(y1)))
+	if n := int64(se.X-nw.X+1) * int64(se.Y-nw.Y+1); n > tileMaxCover {
+		return fmt.Errorf("tile: cover needs %d tiles at zoom %d", n, z)
+	}
+	return nil
+}
+
+func isFinite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// tileCoords maps p to fractional tile coordinates at zoom z.
+func tileCoords(p Position, z int) (float64, float64) {
+	n := float64(int(1) << z)
+	lat := math.Max(-webMercatorMaxLat, math.Min(webMercatorMaxLat, p.Lat())) * math.Pi / 180
+	x := (p.Lon() + 180) / 360 * n
+	y := (1 - math.Asinh(math.Tan(lat))/math.Pi) / 2 * n
+	return x, y
+}
+
+// tileCorner is the inverse of tileCoords.
+func tileCorner(z int, x, y float64) Position {
+	n := float64(int(1) << z)
+	lat := math.Atan(math.Sinh(math.Pi*(1-2*y/n))) * 180 / math.Pi
+	return Position{x/n*360 - 180, lat}
+}
+
+func tileBounds(t Tile) bounds {
+	nw := tileCorner(t.Z, float64(t.X), float64(t.Y))
+	se := tileCorner(t.Z, float64(t.X+1), float64(t.Y+1))
+	return bounds{nw.Lon(), se.Lat(), se.Lon(), nw.Lat()}
+}
+
+// clampTile keeps positions on the world's east and south edges in the
+// last column and row.
+func clampTile(z, x, y int) Tile {
+	n := 1 << z
+	return Tile{z, maxInt(0, minInt(x, n-1)), maxInt(0, minInt(y, n-1))}
+}
+
+func geometryTiles(g any, z int) ([]Tile, error) {
+	if err := checkZoom(z); err != nil {
+		return nil, err
+	}
+	if err := checkTileSpan(g, z); err != nil {
+		return nil, err
+	}
+	set := make(map[Tile]struct{})
+	addGeometryTiles(set, g, z)
+	out := make([]Tile, 0, len(set))
+	for t := range set {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].X != out[j].X {
+			return out[i].X < out[j].X
+		}
+		return out[i].Y < out[j].Y
+	})
+	return out, nil
+}
+
+// addGeometryTiles adds the tiles g touches to set.
+func addGeometryTiles(set map[Tile]struct{}, g any, z int) {
+	switch g := g.(type) {
+	case Point:
+		if len(g) > 0 {
+			addPathTiles(set, []Position{Position(g)}, z)
+		}
+	case MultiPoint:
+		for _, p := range g {
+			addPathTiles(set, []Position{p}, z)
+		}
+	case LineString:
+		addPathTiles(set, g, z)
+	case MultiLineString:
+		for _, ls := range g {
+			addPathTiles(set, ls, z)
+		}
+	case Polygon:
+		addPolygonTiles(set, g, z)
+	case MultiPolygon:
+		for _, p := range g {
+			addPolygonTiles(set, p, z)
+		}
+	case GeometryCollection:
+		for _, m := range g {
+			addGeometryTiles(set, m.geometry, z)
+		}
+	}
+}
+
+// addPathTiles adds every tile the path passes through, walking each
+// segment across the tile grid one column or row at a time.
+func addPathTiles(set map[Tile]struct{}, ps []Position, z int) {
+	add := func(x, y int) {
+		set[clampTile(z, x, y)] = struct{}{}
+	}
+	for i, p := range ps {
+		x0, y0 := tileCoords(p, z)
+		tx, ty := int(math.Floor(x0)), int(math.Floor(y0))
+		if i == 0 {
+			add(tx, ty)
+		}
+		if i == len(ps)-1 {
+			break
+		}
+		x1, y1 := tileCoords(ps[i+1], z)
+		endX, endY := int(math.Floor(x1)), int(math.Floor(y1))
+
+		stepX, nextX, deltaX := gridStep(x0, x1)
+		stepY, nextY, deltaY := gridStep(y0, y1)
+		for tx != endX || ty != endY {
+			if ty == endY || (tx != endX && nextX < nextY) {
+				tx += stepX
+				nextX += deltaX
+			} else {
+				ty += stepY
+				nextY += deltaY
+			}
+			add(tx, ty)
+		}
+	}
+}
+
+// gridStep returns the direction along one axis from a to b, the fraction of
+// the way at which the first cell boundary is crossed, and the fraction
+// between successive boundaries.
+func gridStep(a, b float64) (int, float64, float64) {
+	d := b - a
+	switch {
+	case d > 0:
+		return 1, (math.Floor(a) + 1 - a) / d, 1 / d
+	case d < 0:
+		return -1, (a - math.Floor(a)) / -d, 1 / -d
+	default:
+		return 0, math.Inf(1), math.Inf(1)
+	}
+}
+
+// addPolygonTiles adds the tiles the rings pass through and the tiles whose
+// centres lie inside the polygon, found by scanning each row's centre line.
+func addPolygonTiles(set map[Tile]struct{}, p Polygon, z int) {
+	rings := make([][][2]float64, len(p))
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for i, ring := range p {
+		addPathTiles(set, ring, z)
+		rings[i] = make([][2]float64, len(ring))
+		for j, pos := range ring {
+			x, y := tileCoords(pos, z)
+			rings[i][j] = [2]float64{x, y}
+			minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+		}
+	}
+	if minY > maxY {
+		return
+	}
+
+	n := 1 << z
+	for row := maxInt(0, int(math.Floor(minY))); row <= minInt(n-1, int(math.Floor(maxY))); row++ {
+		cy := float64(row) + 0.5
+		var xs []float64
+		for _, ring := range rings {
+			for j := range ring {
+				a, b := ring[j], ring[(j+1)%len(ring)]
+				if (a[1] > cy) != (b[1] > cy) {
+					xs = append(xs, a[0]+(cy-a[1])/(b[1]-a[1])*(b[0]-a[0]))
+				}
+			}
+		}
+		sort.Float64s(xs)
+		for k := 0; k+1 < len(xs); k += 2 {
+			first := maxInt(0, int(math.Ceil(xs[k]-0.5)))
+			last := minInt(n-1, int(math.Floor(xs[k+1]-0.5)))
+			for col := first; col <= last; col++ {
+				set[Tile{z, col, row}] = struct{}{}
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package joejson
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPositionTile(t *testing.T) {
+	testCases := map[string]struct {
+		p    Position
+		z    int
+		want Tile
+	}{
+		"world":      {Position{10, 10}, 0, Tile{0, 0, 0}},
+		"origin":     {Position{0, 0}, 1, Tile{1, 1, 1}},
+		"berlin":     {Position{13.4, 52.5}, 10, Tile{10, 550, 335}},
+		"south east": {Position{180, -90}, 2, Tile{2, 3, 3}},
+		"north west": {Position{-180, 90}, 2, Tile{2, 0, 0}},
+	}
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := PositionTile(tt.p, tt.z)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("TilePosition", func(t *testing.T) {
+		got, err := TilePosition(1, 1, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, Position{0, 0}, got)
+
+		got, err = TilePosition(0, 0, 0)
+		assert.NoError(t, err)
+		assertPositionsInDelta(t, Point{-180, webMercatorMaxLat}, Point(got))
+	})
+
+	t.Run("TileBounds", func(t *testing.T) {
+		got, err := TileBounds(1, 0, 0)
+		assert.NoError(t, err)
+		assertPositionsInDelta(t, Polygon{{
+			{-180, 0}, {0, 0}, {0, webMercatorMaxLat}, {-180, webMercatorMaxLat}, {-180, 0},
+		}}, got)
+	})
+
+	t.Run("String", func(t *testing.T) {
+		assert.Equal(t, "10/550/335", Tile{10, 550, 335}.String())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := PositionTile(Position{0, 0}, 31)
+		assert.EqualError(t, err, "tile: invalid zoom 31")
+		_, err = PositionTile(Position{math.NaN(), 0}, 3)
+		assert.EqualError(t, err, "tile: position [NaN 0] is not finite")
+		_, err = PositionTile(Position{0, math.Inf(-1)}, 3)
+		assert.EqualError(t, err, "tile: position [0 -Inf] is not finite")
+		_, err = TileBounds(1, 2, 0)
+		assert.EqualError(t, err, "tile: 1/2/0 out of range")
+		_, err = LineString{{0, 0}}.Tiles(-1)
+		assert.EqualError(t, err, "tile: invalid zoom -1")
+		_, err = LineString{{-170, 0}, {170, 0}}.Tiles(24)
+		assert.EqualError(t, err, "tile: cover needs 15845150 tiles at zoom 24")
+		_, err = Polygon{{{0, 0}, {math.NaN(), 1}, {1, 1}, {0, 0}}}.Tiles(3)
+		assert.EqualError(t, err, "tile: position [NaN 1] is not finite")
+		_, err = LineString{{0, 0}, {math.Inf(1), 0}}.Tiles(3)
+		assert.EqualError(t, err, "tile: position [+Inf 0] is not finite")
+	})
+}
+
+func TestTiles(t *testing.T) {
+	t.Run("LineString", func(t *testing.T) {
+		got, err := LineString{{-170, 0.1}, {-10, 0.1}}.Tiles(2)
+		assert.NoError(t, err)
+		assert.Equal(t, []Tile{{2, 0, 1}, {2, 1, 1}}, got)
+
+		got, err = LineString{{-170, 80}, {170, -80}}.Tiles(2)
+		assert.NoError(t, err)
+		assert.Len(t, got, 7)
+		assert.Equal(t, Tile{2, 0, 0}, got[0])
+		assert.Equal(t, Tile{2, 3, 3}, got[6])
+
+		got, err = LineString{{170, -80}, {-170, 80}}.Tiles(2)
+		assert.NoError(t, err)
+		assert.Len(t, got, 7)
+	})
+
+	t.Run("Polygon", func(t *testing.T) {
+		p := Polygon{{{-170, -60}, {170, -60}, {170, 60}, {-170, 60}, {-170, -60}}}
+		got, err := p.Tiles(2)
+		assert.NoError(t, err)
+		assert.Equal(t, []Tile{
+			{2, 0, 1}, {2, 0, 2}, {2, 1, 1}, {2, 1, 2}, {2, 2, 1}, {2, 2, 2}, {2, 3, 1}, {2, 3, 2},
+		}, got)
+	})
+
+	t.Run("Polygon with hole", func(t *testing.T) {
+		p := Polygon{
+			{{-170, -80}, {170, -80}, {170, 80}, {-170, 80}, {-170, -80}},
+			{{-80, -60}, {-80, 60}, {80, 60}, {80, -60}, {-80, -60}},
+		}
+		got, err := p.Tiles(3)
+		assert.NoError(t, err)
+		assert.Len(t, got, 60)
+		for _, tile := range []Tile{{3, 3, 3}, {3, 3, 4}, {3, 4, 3}, {3, 4, 4}} {
+			assert.NotContains(t, got, tile)
+		}
+	})
+
+	t.Run("small Polygon", func(t *testing.T) {
+		p := Polygon{{{1, 1}, {2, 1}, {2, 2}, {1, 1}}}
+		got, err := p.Tiles(1)
+		assert.NoError(t, err)
+		assert.Equal(t, []Tile{{1, 1, 0}}, got)
+	})
+}
+
+func TestSplitByTile(t *testing.T) {
+	a := Feature{ID: "a"}.WithPoint(Point{-90, 45})
+	b := Feature{ID: "b"}.WithPoint(Point{90, -45})
+	c := Feature{ID: "c"}.WithLineString(LineString{{-90, 45}, {90, 45}})
+	fc := FeatureCollection{Features: []Feature{a, b, c, {ID: "d"}}}
+
+	got, err := fc.SplitByTile(1)
+	assert.NoError(t, err)
+	assert.Equal(t, map[Tile]FeatureCollection{
+		{1, 0, 0}: {Features: []Feature{a, c}},
+		{1, 1, 0}: {Features: []Feature{c}},
+		{1, 1, 1}: {Features: []Feature{b}},
+	}, got)
+
+	_, err = fc.SplitByTile(40)
+	assert.EqualError(t, err, "tile: invalid zoom 40")
+	_, err = fc.SplitByTile(30)
+	assert.EqualError(t, err, "tile: cover needs 536870913 tiles at zoom 30")
+}
@@ -0,0 +1,675 @@
+package joejson
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// TypeTopology is the value for a Topology's 'type' member.
+const TypeTopology = "Topology"
+
+// Topology is a TopoJSON topology, sharing arcs between geometries.
+// https://github.com/topojson/topojson-specification
+type Topology struct {
+	// Objects are the named geometry objects.
+	Objects map[string]TopologyGeometry
+	// Arcs are the shared line segments referenced by the objects. When
+	// Transform is set they are quantized and delta encoded.
+	Arcs [][]Position
+	// Transform is the optional quantization transform.
+	Transform *TopologyTransform
+}
+
+// TopologyTransform maps quantized integer positions back to coordinates.
+type TopologyTransform struct {
+	Scale     [2]float64 `json:"scale"`
+	Translate [2]float64 `json:"translate"`
+}
+
+// TopologyGeometry is a TopoJSON geometry object.
+type TopologyGeometry struct {
+	// Type is a geometry type, or empty for a null geometry.
+	Type       string
+	ID         any
+	Properties map[string]any
+	// Arcs holds arc indexes, with ^i referencing arc i reversed:
+	// []int for LineString, [][]int for MultiLineString and Polygon,
+	// and [][][]int for MultiPolygon.
+	Arcs any
+	// Coordinates holds a Position for Point or []Position for MultiPoint.
+	Coordinates any
+	// Geometries are the members of a GeometryCollection.
+	Geometries []TopologyGeometry
+}
+
+// TopologyOptions configures FeatureCollection.Topology.
+type TopologyOptions struct {
+	// Quantization is the number of distinct values per axis, typically 1e4 to 1e6.
+	// Zero disables quantization and delta encoding.
+	Quantization int
+}
+
+// Topology converts the FeatureCollection into a TopoJSON Topology holding a
+// single GeometryCollection object called name. Lines and rings are cut where
+// they meet, and identical arcs are shared.
+func (f FeatureCollection) Topology(name string, opts TopologyOptions) Topology {
+	b := topologyBuilder{arcIndex: make(map[string]int)}
+
+	features := f.Features
+	var transform *TopologyTransform
+	if opts.Quantization > 1 {
+		features, transform = quantizeFeatures(f.Features, opts.Quantization)
+	}
+
+	for _, ft := range features {
+		b.collect(ft.geometry)
+	}
+	b.findJunctions()
+
+	collection := TopologyGeometry{
+		Type:       GeometryTypeGeometryCollection,
+		Geometries: make([]TopologyGeometry, len(features)),
+	}
+	for i, ft := range features {
+		g := b.geometry(ft.geometry)
+		g.ID = ft.ID
+		g.Properties = ft.Properties
+		collection.Geometries[i] = g
+	}
+
+	arcs := b.arcs
+	if transform != nil {
+		arcs = make([][]Position, len(b.arcs))
+		for i, arc := range b.arcs {
+			arcs[i] = deltaEncode(arc)
+		}
+	}
+
+	return Topology{
+		Objects:   map[string]TopologyGeometry{name: collection},
+		Arcs:      arcs,
+		Transform: transform,
+	}
+}
+
+// FeatureCollection converts the named object back into a FeatureCollection.
+// A GeometryCollection object yields one Feature per member geometry.
+func (t Topology) FeatureCollection(name string) (FeatureCollection, error) {
+	obj, ok := t.Objects[name]
+	if !ok {
+		return FeatureCollection{}, fmt.Errorf("topology has no object %q", name)
+	}
+
+	d := topologyDecoder{transform: t.Transform, arcs: make([][]Position, len(t.Arcs))}
+	for i, arc := range t.Arcs {
+		d.arcs[i] = d.decodeArc(arc)
+	}
+
+	members := []TopologyGeometry{obj}
+	if obj.Type == GeometryTypeGeometryCollection {
+		members = obj.Geometries
+	}
+
+	fc := FeatureCollection{Features: make([]Feature, len(members))}
+	for i, m := range members {
+		g, err := d.geometry(m)
+		if err != nil {
+			return FeatureCollection{}, err
+		}
+		fc.Features[i] = Feature{ID: m.ID, Properties: m.Properties, geometry: g}
+	}
+	return fc, nil
+}
+
+// MarshalJSON is a custom JSON marshaller.
+func (t Topology) MarshalJSON() ([]byte, error) {
+	arcs := t.Arcs
+	if arcs == nil {
+		arcs = [][]Position{}
+	}
+	return json.Marshal(&struct {
+		Type      string                      `json:"type"`
+		Transform *TopologyTransform          `json:"transform,omitempty"`
+		Objects   map[string]TopologyGeometry `json:"objects"`
+		Arcs      [][]Position                `json:"arcs"`
+	}{
+		TypeTopology,
+		t.Transform,
+		t.Objects,
+		arcs,
+	})
+}
+
+// UnmarshalJSON is a custom JSON unmarshaller.
+func (t *Topology) UnmarshalJSON(b []byte) error {
+	var tmp struct {
+		Type      string                      `json:"type"`
+		Transform *TopologyTransform          `json:"transform"`
+		Objects   map[string]TopologyGeometry `json:"objects"`
+		Arcs      [][]Position                `json:"arcs"`
+	}
+
+	if err := json.Unmarshal(b, &tmp); err != nil {
+		return err
+	}
+
+	if tmp.Type != TypeTopology {
+		return fmt.Errorf("invalid type %q, expected %q", tmp.Type, TypeTopology)
+	}
+
+	t.Objects = tmp.Objects
+	t.Arcs = tmp.Arcs
+	t.Transform = tmp.Transform
+	return nil
+}
+
+// MarshalJSON is a custom JSON marshaller.
+func (g TopologyGeometry) MarshalJSON() ([]byte, error) {
+	var typ any
+	if g.Type != "" {
+		typ = g.Type
+	}
+	var geometries []TopologyGeometry
+	if g.Type == GeometryTypeGeometryCollection {
+		geometries = g.Geometries
+		if geometries == nil {
+			geometries = []TopologyGeometry{}
+		}
+	}
+	return json.Marshal(&struct {
+		Type        any                `json:"type"`
+		ID          any                `json:"id,omitempty"`
+		Properties  map[string]any     `json:"properties,omitempty"`
+		Arcs        any                `json:"arcs,omitempty"`
+		Coordinates any                `json:"coordinates,omitempty"`
+		Geometries  []TopologyGeometry `json:"geometries,omitempty"`
+	}{
+		typ,
+		g.ID,
+		g.Properties,
+		g.Arcs,
+		g.Coordinates,
+		geometries,
+	})
+}
+
+// UnmarshalJSON is a custom JSON unmarshaller.
+func (g *TopologyGeometry) UnmarshalJSON(b []byte) error {
+	var tmp struct {
+		Type        *string            `json:"type"`
+		ID          any                `json:"id"`
+		Properties  map[string]any     `json:"properties"`
+		Arcs        json.RawMessage    `json:"arcs"`
+		Coordinates json.RawMessage    `json:"coordinates"`
+		Geometries  []TopologyGeometry `json:"geometries"`
+	}
+
+	if err := json.Unmarshal(b, &tmp); err != nil {
+		return err
+	}
+
+	*g = TopologyGeometry{ID: tmp.ID, Properties: tmp.Properties}
+	if tmp.Type == nil {
+		return nil
+	}
+	g.Type = *tmp.Type
+
+	var err error
+	switch g.Type {
+	case GeometryTypePoint:
+		var c Position
+		err = json.Unmarshal(tmp.Coordinates, &c)
+		g.Coordinates = c
+	case GeometryTypeMultiPoint:
+		var c []Position
+		err = json.Unmarshal(tmp.Coordinates, &c)
+		g.Coordinates = c
+	case GeometryTypeLineString:
+		var a []int
+		err = json.Unmarshal(tmp.Arcs, &a)
+		g.Arcs = a
+	case GeometryTypeMultiLineString, GeometryTypePolygon:
+		var a [][]int
+		err = json.Unmarshal(tmp.Arcs, &a)
+		g.Arcs = a
+	case GeometryTypeMultiPolygon:
+		var a [][][]int
+		err = json.Unmarshal(tmp.Arcs, &a)
+		g.Arcs = a
+	case GeometryTypeGeometryCollection:
+		g.Geometries = tmp.Geometries
+	default:
+		return fmt.Errorf("unknown geometry type: %q", g.Type)
+	}
+	return err
+}
+
+// quantizeFeatures snaps every Position to an n by n grid over the features' bounds.
+func quantizeFeatures(features []Feature, n int) ([]Feature, *TopologyTransform) {
+	b := emptyBounds()
+	for _, ft := range features {
+		if fb, ok := geometryBounds(ft.geometry); ok {
+			b = b.extend(fb)
+		}
+	}
+	if b.isEmpty() {
+		return features, nil
+	}
+
+	kx, ky := 1.0, 1.0
+	if b.maxX > b.minX {
+		kx = (b.maxX - b.minX) / float64(n-1)
+	}
+	if b.maxY > b.minY {
+		ky = (b.maxY - b.minY) / float64(n-1)
+	}
+	transform := &TopologyTransform{Scale: [2]float64{kx, ky}, Translate: [2]float64{b.minX, b.minY}}
+
+	quantize := func(p Position) Position {
+		return withXY(p, math.Round((p.Lon()-b.minX)/kx), math.Round((p.Lat()-b.minY)/ky))
+	}
+	out := make([]Feature, len(features))
+	for i, ft := range features {
+		ft.geometry = dropRepeatedPositions(mapPositions(ft.geometry, quantize))
+		out[i] = ft
+	}
+	return out, transform
+}
+
+// dropRepeatedPositions removes consecutive duplicates from lines and rings,
+// which quantization may introduce. Rings left with fewer than four
+// Positions are dropped, and so is a Polygon whose exterior ring is, giving
+// nil when nothing is left.
+func dropRepeatedPositions(g any) any {
+	dedupe := func(ps []Position) []Position {
+		out := ps[:0:0]
+		for i, p := range ps {
+			if i == 0 || !positionsEqual(p, ps[i-1]) {
+				out = append(out, p)
+			}
+		}
+		return out
+	}
+	polygon := func(p Polygon) Polygon {
+		out := make(Polygon, 0, len(p))
+		for i, lr := range p {
+			lr = dedupe(lr)
+			if len(lr) < 4 {
+				if i == 0 {
+					return nil
+				}
+				continue
+			}
+			out = append(out, lr)
+		}
+		return out
+	}
+	switch g := g.(type) {
+	case LineString:
+		return LineString(dedupe(g))
+	case MultiLineString:
+		for i, ls := range g {
+			g[i] = dedupe(ls)
+		}
+	case Polygon:
+		if pl := polygon(g); pl != nil {
+			return pl
+		}
+		return nil
+	case MultiPolygon:
+		out := make(MultiPolygon, 0, len(g))
+		for _, pl := range g {
+			if pl = polygon(pl); pl != nil {
+				out = append(out, pl)
+			}
+		}
+		if len(out) == 0 && len(g) > 0 {
+			return nil
+		}
+		return out
+	case GeometryCollection:
+		out := make(GeometryCollection, 0, len(g))
+		for _, m := range g {
+			if m := dropRepeatedPositions(m.geometry); m != nil {
+				out = append(out, GeometryCollectionMember{m})
+			}
+		}
+		return out
+	}
+	return g
+}
+
+func deltaEncode(arc []Position) []Position {
+	out := make([]Position, len(arc))
+	var x, y float64
+	for i, p := range arc {
+		out[i] = withXY(p, p.Lon()-x, p.Lat()-y)
+		x, y = p.Lon(), p.Lat()
+	}
+	return out
+}
+
+type topologyPoint [2]float64
+
+func topologyKey(p Position) topologyPoint {
+	return topologyPoint{p.Lon(), p.Lat()}
+}
+
+// topologyBuilder extracts and deduplicates arcs from lines and rings.
+type topologyBuilder struct {
+	lines, rings [][]Position
+	junctions    map[topologyPoint]bool
+	arcs         [][]Position
+	arcIndex     map[string]int
+}
+
+// collect gathers every line and ring for junction detection.
+func (b *topologyBuilder) collect(g any) {
+	switch g := g.(type) {
+	case LineString:
+		b.lines = append(b.lines, g)
+	case MultiLineString:
+		for _, ls := range g {
+			b.lines = append(b.lines, ls)
+		}
+	case Polygon:
+		for _, lr := range g {
+			b.rings = append(b.rings, lr)
+		}
+	case MultiPolygon:
+		for _, pl := range g {
+			b.collect(pl)
+		}
+	case GeometryCollection:
+		for _, m := range g {
+			b.collect(m.geometry)
+		}
+	}
+}
+
+// findJunctions marks positions where lines or rings meet or diverge: line
+// endpoints, and positions seen with different neighbours.
+func (b *topologyBuilder) findJunctions() {
+	b.junctions = make(map[topologyPoint]bool)
+	neighbours := make(map[topologyPoint][2]topologyPoint)
+	visit := func(p, prev, next topologyPoint) {
+		if prev[0] > next[0] || (prev[0] == next[0] && prev[1] > next[1]) {
+			prev, next = next, prev
+		}
+		pair := [2]topologyPoint{prev, next}
+		if seen, ok := neighbours[p]; !ok {
+			neighbours[p] = pair
+		} else if seen != pair {
+			b.junctions[p] = true
+		}
+	}
+
+	for _, line := range b.lines {
+		if len(line) == 0 {
+			continue
+		}
+		b.junctions[topologyKey(line[0])] = true
+		b.junctions[topologyKey(line[len(line)-1])] = true
+		for i := 1; i < len(line)-1; i++ {
+			visit(topologyKey(line[i]), topologyKey(line[i-1]), topologyKey(line[i+1]))
+		}
+	}
+	for _, ring := range b.rings {
+		pts := openRing(ring)
+		for i := range pts {
+			prev := pts[(i+len(pts)-1)%len(pts)]
+			next := pts[(i+1)%len(pts)]
+			visit(topologyKey(pts[i]), topologyKey(prev), topologyKey(next))
+		}
+	}
+}
+
+// openRing drops the closing Position of a ring.
+func openRing(ring []Position) []Position {
+	if len(ring) > 1 && positionsEqual(ring[0], ring[len(ring)-1]) {
+		return ring[:len(ring)-1]
+	}
+	return ring
+}
+
+func (b *topologyBuilder) geometry(g any) TopologyGeometry {
+	switch g := g.(type) {
+	case Point:
+		return TopologyGeometry{Type: GeometryTypePoint, Coordinates: Position(g)}
+	case MultiPoint:
+		return TopologyGeometry{Type: GeometryTypeMultiPoint, Coordinates: []Position(g)}
+	case LineString:
+		return TopologyGeometry{Type: GeometryTypeLineString, Arcs: b.line(g)}
+	case MultiLineString:
+		arcs := make([][]int, len(g))
+		for i, ls := range g {
+			arcs[i] = b.line(ls)
+		}
+		return TopologyGeometry{Type: GeometryTypeMultiLineString, Arcs: arcs}
+	case Polygon:
+		return TopologyGeometry{Type: GeometryTypePolygon, Arcs: b.polygon(g)}
+	case MultiPolygon:
+		arcs := make([][][]int, len(g))
+		for i, pl := range g {
+			arcs[i] = b.polygon(pl)
+		}
+		return TopologyGeometry{Type: GeometryTypeMultiPolygon, Arcs: arcs}
+	case GeometryCollection:
+		geometries := make([]TopologyGeometry, len(g))
+		for i, m := range g {
+			geometries[i] = b.geometry(m.geometry)
+		}
+		return TopologyGeometry{Type: GeometryTypeGeometryCollection, Geometries: geometries}
+	default:
+		return TopologyGeometry{}
+	}
+}
+
+func (b *topologyBuilder) polygon(p Polygon) [][]int {
+	rings := make([][]int, len(p))
+	for i, lr := range p {
+		rings[i] = b.ring(lr)
+	}
+	return rings
+}
+
+// line cuts a line at every junction.
+func (b *topologyBuilder) line(ps []Position) []int {
+	refs := []int{}
+	start := 0
+	for i := 1; i < len(ps); i++ {
+		if i == len(ps)-1 || b.junctions[topologyKey(ps[i])] {
+			refs = append(refs, b.arc(ps[start:i+1]))
+			start = i
+		}
+	}
+	if len(ps) == 1 {
+		refs = append(refs, b.arc(ps))
+	}
+	return refs
+}
+
+// ring rotates a ring to start at a junction, or at its smallest position
+// when it has none so identical rings match, then cuts it at every junction.
+func (b *topologyBuilder) ring(lr []Position) []int {
+	pts := openRing(lr)
+	if len(pts) == 0 {
+		return []int{}
+	}
+
+	start := -1
+	for i, p := range pts {
+		if b.junctions[topologyKey(p)] {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		start = 0
+		for i, p := range pts {
+			if p.Lon() < pts[start].Lon() || (p.Lon() == pts[start].Lon() && p.Lat() < pts[start].Lat()) {
+				start = i
+			}
+		}
+	}
+
+	rotated := make([]Position, 0, len(pts)+1)
+	rotated = append(rotated, pts[start:]...)
+	rotated = append(rotated, pts[:start]...)
+	rotated = append(rotated, pts[start])
+	return b.line(rotated)
+}
+
+// arc returns a reference to an existing arc with the same positions in
+// either direction, or adds a new one.
+func (b *topologyBuilder) arc(ps []Position) int {
+	if i, ok := b.arcIndex[arcKey(ps, false)]; ok {
+		return i
+	}
+	if i, ok := b.arcIndex[arcKey(ps, true)]; ok {
+		return ^i
+	}
+	i := len(b.arcs)
+	b.arcs = append(b.arcs, append([]Position{}, ps...))
+	b.arcIndex[arcKey(ps, false)] = i
+	return i
+}
+
+func arcKey(ps []Position, reverse bool) string {
+	var sb strings.Builder
+	for i := range ps {
+		p := ps[i]
+		if reverse {
+			p = ps[len(ps)-1-i]
+		}
+		sb.WriteString(strconv.FormatUint(math.Float64bits(p.Lon()), 36))
+		sb.WriteByte(',')
+		sb.WriteString(strconv.FormatUint(math.Float64bits(p.Lat()), 36))
+		sb.WriteByte(';')
+	}
+	return sb.String()
+}
+
+// topologyDecoder rebuilds geometries from arcs.
+type topologyDecoder struct {
+	transform *TopologyTransform
+	arcs      [][]Position
+}
+
+func (d topologyDecoder) position(p Position) Position {
+	if d.transform == nil {
+		return p
+	}
+	return withXY(p,
+		p.Lon()*d.transform.Scale[0]+d.transform.Translate[0],
+		p.Lat()*d.transform.Scale[1]+d.transform.Translate[1],
+	)
+}
+
+func (d topologyDecoder) decodeArc(arc []Position) []Position {
+	if d.transform == nil {
+		return arc
+	}
+	out := make([]Position, len(arc))
+	var x, y float64
+	for i, p := range arc {
+		x, y = x+p.Lon(), y+p.Lat()
+		out[i] = d.position(withXY(p, x, y))
+	}
+	return out
+}
+
+// stitch concatenates referenced arcs, dropping each arc's repeated first position.
+func (d topologyDecoder) stitch(refs []int) ([]Position, error) {
+	out := []Position{}
+	for _, ref := range refs {
+		i := ref
+		if ref < 0 {
+			i = ^ref
+		}
+		if i >= len(d.arcs) {
+			return nil, fmt.Errorf("topology arc %d out of range", ref)
+		}
+		arc := d.arcs[i]
+		for j := range arc {
+			p := arc[j]
+			if ref < 0 {
+				p = arc[len(arc)-1-j]
+			}
+			if j == 0 && len(out) > 0 {
+				continue
+			}
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (d topologyDecoder) geometry(g TopologyGeometry) (any, error) {
+	switch g.Type {
+	case "":
+		return nil, nil
+	case GeometryTypePoint:
+		c, _ := g.Coordinates.(Position)
+		return Point(d.position(c)), nil
+	case GeometryTypeMultiPoint:
+		c, _ := g.Coordinates.([]Position)
+		return MultiPoint(mapPositionSlice(c, d.position)), nil
+	case GeometryTypeLineString:
+		refs, _ := g.Arcs.([]int)
+		ps, err := d.stitch(refs)
+		return LineString(ps), err
+	case GeometryTypeMultiLineString:
+		refs, _ := g.Arcs.([][]int)
+		out := make(MultiLineString, len(refs))
+		for i, r := range refs {
+			ps, err := d.stitch(r)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = ps
+		}
+		return out, nil
+	case GeometryTypePolygon:
+		refs, _ := g.Arcs.([][]int)
+		return d.polygon(refs)
+	case GeometryTypeMultiPolygon:
+		refs, _ := g.Arcs.([][][]int)
+		out := make(MultiPolygon, len(refs))
+		for i, r := range refs {
+			pl, err := d.polygon(r)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = pl
+		}
+		return out, nil
+	case GeometryTypeGeometryCollection:
+		out := make(GeometryCollection, len(g.Geometries))
+		for i, m := range g.Geometries {
+			geom, err := d.geometry(m)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = GeometryCollectionMember{geom}
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unknown geometry type: %q", g.Type)
+	}
+}
+
+func (d topologyDecoder) polygon(refs [][]int) (Polygon, error) {
+	out := make(Polygon, len(refs))
+	for i, r := range refs {
+		ps, err := d.stitch(r)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = ps
+	}
+	return out, nil
+}
@@ -0,0 +1,92 @@
+package joejson
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopology(t *testing.T) {
+	fc := FeatureCollection{Features: []Feature{
+		Feature{ID: "a", Properties: map[string]any{"name": "A"}}.WithPolygon(
+			Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}},
+		),
+		Feature{ID: "b"}.WithPolygon(
+			Polygon{{{1, 0}, {2, 0}, {2, 1}, {1, 1}, {1, 0}}},
+		),
+		Feature{ID: 3.0}.WithLineString(LineString{{0, 2}, {1, 2}, {2, 2}}),
+		Feature{}.WithPoint(Point{0.5, 0.5}),
+		Feature{}.WithGeometryCollection(GeometryCollection{}.AppendMultiPoint(MultiPoint{{2, 0}})),
+	}}
+
+	t.Run("shares arcs", func(t *testing.T) {
+		topo := fc.Topology("shapes", TopologyOptions{})
+		assert.Equal(t, [][]Position{
+			{{1, 0}, {1, 1}},
+			{{1, 1}, {0, 1}, {0, 0}, {1, 0}},
+			{{1, 0}, {2, 0}, {2, 1}, {1, 1}},
+			{{0, 2}, {1, 2}, {2, 2}},
+		}, topo.Arcs)
+
+		obj := topo.Objects["shapes"]
+		assert.Equal(t, [][]int{{0, 1}}, obj.Geometries[0].Arcs)
+		assert.Equal(t, [][]int{{2, ^0}}, obj.Geometries[1].Arcs)
+
+		bs, err := json.Marshal(topo)
+		assert.NoError(t, err)
+		var decoded Topology
+		assert.NoError(t, json.Unmarshal(bs, &decoded))
+		assert.Equal(t, topo, decoded)
+
+		got, err := decoded.FeatureCollection("shapes")
+		assert.NoError(t, err)
+		assert.Equal(t, FeatureCollection{Features: []Feature{
+			Feature{ID: "a", Properties: map[string]any{"name": "A"}}.WithPolygon(
+				Polygon{{{1, 0}, {1, 1}, {0, 1}, {0, 0}, {1, 0}}},
+			),
+			Feature{ID: "b"}.WithPolygon(
+				Polygon{{{1, 0}, {2, 0}, {2, 1}, {1, 1}, {1, 0}}},
+			),
+			fc.Features[2],
+			fc.Features[3],
+			fc.Features[4],
+		}}, got)
+	})
+
+	t.Run("quantized", func(t *testing.T) {
+		topo := fc.Topology("shapes", TopologyOptions{Quantization: 3})
+		assert.Equal(t, &TopologyTransform{Scale: [2]float64{1, 1}, Translate: [2]float64{0, 0}}, topo.Transform)
+		// delta encoded
+		assert.Equal(t, []Position{{1, 0}, {0, 1}}, topo.Arcs[0])
+
+		got, err := topo.FeatureCollection("shapes")
+		assert.NoError(t, err)
+		pl, _ := got.Features[1].AsPolygon()
+		assert.Equal(t, Polygon{{{1, 0}, {2, 0}, {2, 1}, {1, 1}, {1, 0}}}, pl)
+		pt, _ := got.Features[3].AsPoint()
+		assert.Equal(t, Point{1, 1}, pt)
+	})
+
+	t.Run("quantization collapses small rings", func(t *testing.T) {
+		tiny := LinearRing{{50.5, 50.5}, {50.501, 50.5}, {50.5, 50.501}, {50.5, 50.5}}
+		small := FeatureCollection{Features: []Feature{
+			Feature{ID: "outer"}.WithPolygon(Polygon{{{0, 0}, {99, 0}, {99, 99}, {0, 99}, {0, 0}}, tiny}),
+			Feature{ID: "tiny"}.WithPolygon(Polygon{tiny}),
+			Feature{ID: "multi"}.WithMultiPolygon(MultiPolygon{{tiny}, {{{0, 0}, {1, 0}, {1, 1}, {0, 0}}}}),
+		}}
+		topo := small.Topology("shapes", TopologyOptions{Quantization: 100})
+		got, err := topo.FeatureCollection("shapes")
+		assert.NoError(t, err)
+		pl, _ := got.Features[0].AsPolygon()
+		assert.Equal(t, Polygon{{{0, 0}, {99, 0}, {99, 99}, {0, 99}, {0, 0}}}, pl)
+		assert.Equal(t, Feature{ID: "tiny"}, got.Features[1])
+		mp, _ := got.Features[2].AsMultiPolygon()
+		assert.Equal(t, MultiPolygon{{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}}}, mp)
+	})
+
+	t.Run("missing object", func(t *testing.T) {
+		_, err := Topology{}.FeatureCollection("nope")
+		assert.EqualError(t, err, `topology has no object "nope"`)
+	})
+}
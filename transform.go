@@ -0,0 +1,196 @@
+package joejson
+
+import "math"
+
+// AffineMatrix is a 2D affine transform [a, b, xoff, d, e, yoff] mapping
+// x, y to a*x + b*y + xoff, d*x + e*y + yoff.
+type AffineMatrix [6]float64
+
+// Apply transforms a Position, keeping any elevation unchanged.
+func (m AffineMatrix) Apply(p Position) Position {
+	x, y := p.Lon(), p.Lat()
+	return withXY(p, m[0]*x+m[1]*y+m[2], m[3]*x+m[4]*y+m[5])
+}
+
+// Then returns the transform applying m followed by n.
+func (m AffineMatrix) Then(n AffineMatrix) AffineMatrix {
+	return AffineMatrix{
+		n[0]*m[0] + n[1]*m[3], n[0]*m[1] + n[1]*m[4], n[0]*m[2] + n[1]*m[5] + n[2],
+		n[3]*m[0] + n[4]*m[3], n[3]*m[1] + n[4]*m[4], n[3]*m[2] + n[4]*m[5] + n[5],
+	}
+}
+
+func translateMatrix(dx, dy float64) AffineMatrix {
+	return AffineMatrix{1, 0, dx, 0, 1, dy}
+}
+
+func scaleMatrix(sx, sy float64, origin Position) AffineMatrix {
+	return AffineMatrix{sx, 0, origin.Lon() * (1 - sx), 0, sy, origin.Lat() * (1 - sy)}
+}
+
+// rotateMatrix rotates counterclockwise by angle degrees about origin.
+func rotateMatrix(angle float64, origin Position) AffineMatrix {
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	x, y := origin.Lon(), origin.Lat()
+	return AffineMatrix{cos, -sin, x - cos*x + sin*y, sin, cos, y - sin*x - cos*y}
+}
+
+// Transform applies an affine transform, returning a new Point.
+func (p Point) Transform(m AffineMatrix) Point {
+	return p.Project(m.Apply)
+}
+
+// Translate moves the Point by dx, dy.
+func (p Point) Translate(dx, dy float64) Point {
+	return p.Transform(translateMatrix(dx, dy))
+}
+
+// Scale scales the Point by sx, sy relative to origin.
+func (p Point) Scale(sx, sy float64, origin Position) Point {
+	return p.Transform(scaleMatrix(sx, sy, origin))
+}
+
+// Rotate rotates the Point counterclockwise by angle degrees about origin.
+func (p Point) Rotate(angle float64, origin Position) Point {
+	return p.Transform(rotateMatrix(angle, origin))
+}
+
+// Transform applies an affine transform, returning a new MultiPoint.
+func (g MultiPoint) Transform(m AffineMatrix) MultiPoint {
+	return g.Project(m.Apply)
+}
+
+// Translate moves the MultiPoint by dx, dy.
+func (g MultiPoint) Translate(dx, dy float64) MultiPoint {
+	return g.Transform(translateMatrix(dx, dy))
+}
+
+// Scale scales the MultiPoint by sx, sy relative to origin.
+func (g MultiPoint) Scale(sx, sy float64, origin Position) MultiPoint {
+	return g.Transform(scaleMatrix(sx, sy, origin))
+}
+
+// Rotate rotates the MultiPoint counterclockwise by angle degrees about origin.
+func (g MultiPoint) Rotate(angle float64, origin Position) MultiPoint {
+	return g.Transform(rotateMatrix(angle, origin))
+}
+
+// Transform applies an affine transform, returning a new LineString.
+func (g LineString) Transform(m AffineMatrix) LineString {
+	return g.Project(m.Apply)
+}
+
+// Translate moves the LineString by dx, dy.
+func (g LineString) Translate(dx, dy float64) LineString {
+	return g.Transform(translateMatrix(dx, dy))
+}
+
+// Scale scales the LineString by sx, sy relative to origin.
+func (g LineString) Scale(sx, sy float64, origin Position) LineString {
+	return g.Transform(scaleMatrix(sx, sy, origin))
+}
+
+// Rotate rotates the LineString counterclockwise by angle degrees about origin.
+func (g LineString) Rotate(angle float64, origin Position) LineString {
+	return g.Transform(rotateMatrix(angle, origin))
+}
+
+// Transform applies an affine transform, returning a new MultiLineString.
+func (g MultiLineString) Transform(m AffineMatrix) MultiLineString {
+	return g.Project(m.Apply)
+}
+
+// Translate moves the MultiLineString by dx, dy.
+func (g MultiLineString) Translate(dx, dy float64) MultiLineString {
+	return g.Transform(translateMatrix(dx, dy))
+}
+
+// Scale scales the MultiLineString by sx, sy relative to origin.
+func (g MultiLineString) Scale(sx, sy float64, origin Position) MultiLineString {
+	return g.Transform(scaleMatrix(sx, sy, origin))
+}
+
+// Rotate rotates the MultiLineString counterclockwise by angle degrees about origin.
+func (g MultiLineString) Rotate(angle float64, origin Position) MultiLineString {
+	return g.Transform(rotateMatrix(angle, origin))
+}
+
+// Transform applies an affine transform, returning a new Polygon.
+func (p Polygon) Transform(m AffineMatrix) Polygon {
+	return p.Project(m.Apply)
+}
+
+// Translate moves the Polygon by dx, dy.
+func (p Polygon) Translate(dx, dy float64) Polygon {
+	return p.Transform(translateMatrix(dx, dy))
+}
+
+// Scale scales the Polygon by sx, sy relative to origin.
+func (p Polygon) Scale(sx, sy float64, origin Position) Polygon {
+	return p.Transform(scaleMatrix(sx, sy, origin))
+}
+
+// Rotate rotates the Polygon counterclockwise by angle degrees about origin.
+func (p Polygon) Rotate(angle float64, origin Position) Polygon {
+	return p.Transform(rotateMatrix(angle, origin))
+}
+
+// Transform applies an affine transform, returning a new MultiPolygon.
+func (p MultiPolygon) Transform(m AffineMatrix) MultiPolygon {
+	return p.Project(m.Apply)
+}
+
+// Translate moves the MultiPolygon by dx, dy.
+func (p MultiPolygon) Translate(dx, dy float64) MultiPolygon {
+	return p.Transform(translateMatrix(dx, dy))
+}
+
+// Scale scales the MultiPolygon by sx, sy relative to origin.
+func (p MultiPolygon) Scale(sx, sy float64, origin Position) MultiPolygon {
+	return p.Transform(scaleMatrix(sx, sy, origin))
+}
+
+// Rotate rotates the MultiPolygon counterclockwise by angle degrees about origin.
+func (p MultiPolygon) Rotate(angle float64, origin Position) MultiPolygon {
+	return p.Transform(rotateMatrix(angle, origin))
+}
+
+// Transform applies an affine transform, returning a new GeometryCollection.
+func (g GeometryCollection) Transform(m AffineMatrix) GeometryCollection {
+	return g.Project(m.Apply)
+}
+
+// Translate moves the GeometryCollection by dx, dy.
+func (g GeometryCollection) Translate(dx, dy float64) GeometryCollection {
+	return g.Transform(translateMatrix(dx, dy))
+}
+
+// Scale scales the GeometryCollection by sx, sy relative to origin.
+func (g GeometryCollection) Scale(sx, sy float64, origin Position) GeometryCollection {
+	return g.Transform(scaleMatrix(sx, sy, origin))
+}
+
+// Rotate rotates the GeometryCollection counterclockwise by angle degrees about origin.
+func (g GeometryCollection) Rotate(angle float64, origin Position) GeometryCollection {
+	return g.Transform(rotateMatrix(angle, origin))
+}
+
+// Transform applies an affine transform to the Feature's Geometry and Bbox, returning a new Feature.
+func (f Feature) Transform(m AffineMatrix) Feature {
+	return f.Project(m.Apply)
+}
+
+// Translate moves the Feature's Geometry by dx, dy.
+func (f Feature) Translate(dx, dy float64) Feature {
+	return f.Transform(translateMatrix(dx, dy))
+}
+
+// Scale scales the Feature's Geometry by sx, sy relative to origin.
+func (f Feature) Scale(sx, sy float64, origin Position) Feature {
+	return f.Transform(scaleMatrix(sx, sy, origin))
+}
+
+// Rotate rotates the Feature's Geometry counterclockwise by angle degrees about origin.
+func (f Feature) Rotate(angle float64, origin Position) Feature {
+	return f.Transform(rotateMatrix(angle, origin))
+}
@@ -0,0 +1,52 @@
+package joejson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransform(t *testing.T) {
+	square := Polygon{{{0, 0, 5}, {2, 0, 5}, {2, 2, 5}, {0, 2, 5}, {0, 0, 5}}}
+
+	testCases := map[string]struct {
+		got  Polygon
+		want Polygon
+	}{
+		"Translate": {
+			got:  square.Translate(10, -1),
+			want: Polygon{{{10, -1, 5}, {12, -1, 5}, {12, 1, 5}, {10, 1, 5}, {10, -1, 5}}},
+		},
+		"Scale": {
+			got:  square.Scale(2, 3, Position{1, 1}),
+			want: Polygon{{{-1, -2, 5}, {3, -2, 5}, {3, 4, 5}, {-1, 4, 5}, {-1, -2, 5}}},
+		},
+		"Rotate": {
+			got:  square.Rotate(90, Position{1, 1}),
+			want: Polygon{{{2, 0, 5}, {2, 2, 5}, {0, 2, 5}, {0, 0, 5}, {2, 0, 5}}},
+		},
+		"Transform composed": {
+			got:  square.Transform(translateMatrix(-1, -1).Then(scaleMatrix(2, 2, nil)).Then(translateMatrix(1, 1))),
+			want: square.Scale(2, 2, Position{1, 1}),
+		},
+	}
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Len(t, tt.got, len(tt.want))
+			for i := range tt.want {
+				assert.Len(t, tt.got[i], len(tt.want[i]))
+				for j := range tt.want[i] {
+					assert.InDeltaSlice(t, tt.want[i][j], tt.got[i][j], 1e-12)
+				}
+			}
+		})
+	}
+
+	// the template is never modified.
+	assert.Equal(t, Polygon{{{0, 0, 5}, {2, 0, 5}, {2, 2, 5}, {0, 2, 5}, {0, 0, 5}}}, square)
+
+	ft := Feature{Bbox: []Position{{0, 0}, {2, 2}}}.WithPolygon(square).Translate(1, 1)
+	pl, _ := ft.AsPolygon()
+	assert.Equal(t, Position{1, 1, 5}, pl[0][0])
+	assert.Equal(t, []Position{{1, 1}, {3, 3}}, ft.Bbox)
+}
@@ -0,0 +1,528 @@
+package joejson
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// WKBMarshaler is implemented by every geometry type.
+type WKBMarshaler interface {
+	MarshalWKB() ([]byte, error)
+}
+
+const (
+	wkbPoint              = 1
+	wkbLineString         = 2
+	wkbPolygon            = 3
+	wkbMultiPoint         = 4
+	wkbMultiLineString    = 5
+	wkbMultiPolygon       = 6
+	wkbGeometryCollection = 7
+
+	// ewkbZ, ewkbM and ewkbSRID are the PostGIS EWKB type flags.
+	ewkbZ    = 0x80000000
+	ewkbM    = 0x40000000
+	ewkbSRID = 0x20000000
+
+	// wkbNaN is the quiet NaN written for the coordinates of an empty Point.
+	wkbNaN = 0x7ff8000000000000
+
+	// wkbMaxDepth bounds GeometryCollection nesting when decoding.
+	wkbMaxDepth = 32
+)
+
+var errWKBShort = errors.New("wkb: unexpected end of input")
+
+// MarshalWKB encodes the Point as little-endian ISO Well-Known Binary.
+func (p Point) MarshalWKB() ([]byte, error) {
+	return marshalWKB(p, 0, binary.LittleEndian, false)
+}
+
+// UnmarshalWKB decodes a Point from WKB or EWKB of either byte order, ignoring any SRID.
+func (p *Point) UnmarshalWKB(b []byte) error {
+	g, err := unmarshalWKBAs(b, GeometryTypePoint)
+	if err != nil {
+		return err
+	}
+	*p = g.(Point)
+	return nil
+}
+
+// MarshalWKB encodes the MultiPoint as little-endian ISO Well-Known Binary.
+func (g MultiPoint) MarshalWKB() ([]byte, error) {
+	return marshalWKB(g, 0, binary.LittleEndian, false)
+}
+
+// UnmarshalWKB decodes a MultiPoint from WKB or EWKB of either byte order, ignoring any SRID.
+func (g *MultiPoint) UnmarshalWKB(b []byte) error {
+	geom, err := unmarshalWKBAs(b, GeometryTypeMultiPoint)
+	if err != nil {
+		return err
+	}
+	*g = geom.(MultiPoint)
+	return nil
+}
+
+// MarshalWKB encodes the LineString as little-endian ISO Well-Known Binary.
+func (g LineString) MarshalWKB() ([]byte, error) {
+	return marshalWKB(g, 0, binary.LittleEndian, false)
+}
+
+// UnmarshalWKB decodes a LineString from WKB or EWKB of either byte order, ignoring any SRID.
+func (g *LineString) UnmarshalWKB(b []byte) error {
+	geom, err := unmarshalWKBAs(b, GeometryTypeLineString)
+	if err != nil {
+		return err
+	}
+	*g = geom.(LineString)
+	return nil
+}
+
+// MarshalWKB encodes the MultiLineString as little-endian ISO Well-Known Binary.
+func (g MultiLineString) MarshalWKB() ([]byte, error) {
+	return marshalWKB(g, 0, binary.LittleEndian, false)
+}
+
+// UnmarshalWKB decodes a MultiLineString from WKB or EWKB of either byte order, ignoring any SRID.
+func (g *MultiLineString) UnmarshalWKB(b []byte) error {
+	geom, err := unmarshalWKBAs(b, GeometryTypeMultiLineString)
+	if err != nil {
+		return err
+	}
+	*g = geom.(MultiLineString)
+	return nil
+}
+
+// MarshalWKB encodes the Polygon as little-endian ISO Well-Known Binary.
+func (p Polygon) MarshalWKB() ([]byte, error) {
+	return marshalWKB(p, 0, binary.LittleEndian, false)
+}
+
+// UnmarshalWKB decodes a Polygon from WKB or EWKB of either byte order, ignoring any SRID.
+func (p *Polygon) UnmarshalWKB(b []byte) error {
+	g, err := unmarshalWKBAs(b, GeometryTypePolygon)
+	if err != nil {
+		return err
+	}
+	*p = g.(Polygon)
+	return nil
+}
+
+// MarshalWKB encodes the MultiPolygon as little-endian ISO Well-Known Binary.
+func (p MultiPolygon) MarshalWKB() ([]byte, error) {
+	return marshalWKB(p, 0, binary.LittleEndian, false)
+}
+
+// UnmarshalWKB decodes a MultiPolygon from WKB or EWKB of either byte order, ignoring any SRID.
+func (p *MultiPolygon) UnmarshalWKB(b []byte) error {
+	g, err := unmarshalWKBAs(b, GeometryTypeMultiPolygon)
+	if err != nil {
+		return err
+	}
+	*p = g.(MultiPolygon)
+	return nil
+}
+
+// MarshalWKB encodes the GeometryCollection as little-endian ISO Well-Known Binary.
+func (g GeometryCollection) MarshalWKB() ([]byte, error) {
+	return marshalWKB(g, 0, binary.LittleEndian, false)
+}
+
+// UnmarshalWKB decodes a GeometryCollection from WKB or EWKB of either byte order, ignoring any SRID.
+func (g *GeometryCollection) UnmarshalWKB(b []byte) error {
+	geom, err := unmarshalWKBAs(b, GeometryTypeGeometryCollection)
+	if err != nil {
+		return err
+	}
+	*g = geom.(GeometryCollection)
+	return nil
+}
+
+// MarshalEWKB encodes a geometry as PostGIS Extended Well-Known Binary in the given byte order.
+// The SRID is omitted when srid is zero, in which case 2D geometries are also valid ISO WKB.
+func MarshalEWKB(g WKBMarshaler, srid int, order binary.ByteOrder) ([]byte, error) {
+	return marshalWKB(g, srid, order, true)
+}
+
+// UnmarshalEWKB decodes WKB or EWKB of any geometry type and either byte order.
+// srid is zero when there is none.
+func UnmarshalEWKB(b []byte) (g GeometryCollectionMember, srid int, err error) {
+	d := wkbDecoder{b: b}
+	geom, srid, err := d.geometry(0)
+	if err != nil {
+		return GeometryCollectionMember{}, 0, err
+	}
+	if d.pos != len(b) {
+		return GeometryCollectionMember{}, 0, fmt.Errorf("wkb: unexpected trailing data at offset %d", d.pos)
+	}
+	return GeometryCollectionMember{geom}, srid, nil
+}
+
+func unmarshalWKBAs(b []byte, typ string) (any, error) {
+	g, _, err := UnmarshalEWKB(b)
+	if err != nil {
+		return nil, err
+	}
+	if got := g.Type(); got != typ {
+		return nil, fmt.Errorf("invalid type %q, expected %q", got, typ)
+	}
+	return g.geometry, nil
+}
+
+// wkbEncoder writes ISO WKB, or EWKB when ewkb is set.
+type wkbEncoder struct {
+	buf   []byte
+	order binary.ByteOrder
+	ewkb  bool
+}
+
+func marshalWKB(g any, srid int, order binary.ByteOrder, ewkb bool) ([]byte, error) {
+	e := wkbEncoder{order: order, ewkb: ewkb}
+	if err := e.geometry(g, srid); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+func (e *wkbEncoder) uint32(v uint32) {
+	var tmp [4]byte
+	e.order.PutUint32(tmp[:], v)
+	e.buf = append(e.buf, tmp[:]...)
+}
+
+func (e *wkbEncoder) float64(v float64) {
+	var tmp [8]byte
+	e.order.PutUint64(tmp[:], math.Float64bits(v))
+	e.buf = append(e.buf, tmp[:]...)
+}
+
+func (e *wkbEncoder) geometry(g any, srid int) error {
+	var code uint32
+	switch g.(type) {
+	case Point:
+		code = wkbPoint
+	case LineString:
+		code = wkbLineString
+	case Polygon:
+		code = wkbPolygon
+	case MultiPoint:
+		code = wkbMultiPoint
+	case MultiLineString:
+		code = wkbMultiLineString
+	case MultiPolygon:
+		code = wkbMultiPolygon
+	case GeometryCollection:
+		code = wkbGeometryCollection
+	default:
+		return fmt.Errorf("wkb: unsupported geometry %T", g)
+	}
+
+	hasZ := false
+	if code != wkbGeometryCollection {
+		eachPosition(g, func(p Position) {
+			hasZ = hasZ || len(p) > 2
+		})
+	}
+	switch {
+	case hasZ && e.ewkb:
+		code |= ewkbZ
+	case hasZ:
+		code += 1000
+	}
+	if srid != 0 {
+		code |= ewkbSRID
+	}
+
+	if e.order == binary.BigEndian {
+		e.buf = append(e.buf, 0)
+	} else {
+		e.buf = append(e.buf, 1)
+	}
+	e.uint32(code)
+	if srid != 0 {
+		e.uint32(uint32(srid))
+	}
+
+	switch g := g.(type) {
+	case Point:
+		if len(g) == 0 {
+			nan := math.Float64frombits(wkbNaN)
+			e.position(Position{nan, nan, nan}, hasZ)
+			return nil
+		}
+		e.position(Position(g), hasZ)
+	case LineString:
+		e.positions(g, hasZ)
+	case Polygon:
+		e.polygon(g, hasZ)
+	case MultiPoint:
+		e.uint32(uint32(len(g)))
+		for _, p := range g {
+			if err := e.member(Point(p), hasZ); err != nil {
+				return err
+			}
+		}
+	case MultiLineString:
+		e.uint32(uint32(len(g)))
+		for _, ls := range g {
+			if err := e.member(ls, hasZ); err != nil {
+				return err
+			}
+		}
+	case MultiPolygon:
+		e.uint32(uint32(len(g)))
+		for _, pl := range g {
+			if err := e.member(pl, hasZ); err != nil {
+				return err
+			}
+		}
+	case GeometryCollection:
+		e.uint32(uint32(len(g)))
+		for _, m := range g {
+			if err := e.geometry(m.geometry, 0); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// member writes a part of a Multi geometry, keeping the parent's dimensions.
+func (e *wkbEncoder) member(g any, hasZ bool) error {
+	if !hasZ {
+		return e.geometry(g, 0)
+	}
+	// pad the member with an elevation so the parent's Z flag is honoured.
+	return e.geometry(mapPositions(g, func(p Position) Position {
+		if len(p) == 0 || len(p) > 2 {
+			return p
+		}
+		return Position{p.Lon(), p.Lat(), 0}
+	}), 0)
+}
+
+func (e *wkbEncoder) position(p Position, hasZ bool) {
+	e.float64(p.Lon())
+	e.float64(p.Lat())
+	if hasZ {
+		e.float64(p.Elevation())
+	}
+}
+
+func (e *wkbEncoder) positions(ps []Position, hasZ bool) {
+	e.uint32(uint32(len(ps)))
+	for _, p := range ps {
+		e.position(p, hasZ)
+	}
+}
+
+func (e *wkbEncoder) polygon(p Polygon, hasZ bool) {
+	e.uint32(uint32(len(p)))
+	for _, lr := range p {
+		e.positions(lr, hasZ)
+	}
+}
+
+// wkbDecoder reads ISO WKB and PostGIS EWKB. M coordinates are discarded
+// since Position has no measure.
+type wkbDecoder struct {
+	b     []byte
+	pos   int
+	order binary.ByteOrder
+}
+
+func (d *wkbDecoder) uint32() (uint32, error) {
+	if len(d.b)-d.pos < 4 {
+		return 0, errWKBShort
+	}
+	v := d.order.Uint32(d.b[d.pos:])
+	d.pos += 4
+	return v, nil
+}
+
+func (d *wkbDecoder) float64() (float64, error) {
+	if len(d.b)-d.pos < 8 {
+		return 0, errWKBShort
+	}
+	v := math.Float64frombits(d.order.Uint64(d.b[d.pos:]))
+	d.pos += 8
+	return v, nil
+}
+
+// count reads an element count, rejecting counts the remaining input could not hold.
+func (d *wkbDecoder) count(minSize int) (int, error) {
+	n, err := d.uint32()
+	if err != nil {
+		return 0, err
+	}
+	if int64(n)*int64(minSize) > int64(len(d.b)-d.pos) {
+		return 0, errWKBShort
+	}
+	return int(n), nil
+}
+
+func (d *wkbDecoder) geometry(depth int) (any, int, error) {
+	if depth > wkbMaxDepth {
+		return nil, 0, errors.New("wkb: geometry nested too deeply")
+	}
+	if d.pos >= len(d.b) {
+		return nil, 0, errWKBShort
+	}
+	switch d.b[d.pos] {
+	case 0:
+		d.order = binary.BigEndian
+	case 1:
+		d.order = binary.LittleEndian
+	default:
+		return nil, 0, fmt.Errorf("wkb: invalid byte order %d at offset %d", d.b[d.pos], d.pos)
+	}
+	d.pos++
+
+	code, err := d.uint32()
+	if err != nil {
+		return nil, 0, err
+	}
+	hasZ, hasM := code&ewkbZ != 0, code&ewkbM != 0
+	srid := 0
+	if code&ewkbSRID != 0 {
+		v, err := d.uint32()
+		if err != nil {
+			return nil, 0, err
+		}
+		srid = int(int32(v))
+	}
+	code &^= ewkbZ | ewkbM | ewkbSRID
+	switch code / 1000 {
+	case 1:
+		hasZ = true
+	case 2:
+		hasM = true
+	case 3:
+		hasZ, hasM = true, true
+	}
+	code %= 1000
+
+	dims := 2
+	if hasZ {
+		dims++
+	}
+	if hasM {
+		dims++
+	}
+
+	switch code {
+	case wkbPoint:
+		p, err := d.position(dims, hasZ)
+		if err != nil {
+			return nil, 0, err
+		}
+		if math.IsNaN(p.Lon()) && math.IsNaN(p.Lat()) {
+			return Point{}, srid, nil
+		}
+		return Point(p), srid, nil
+	case wkbLineString:
+		ps, err := d.positions(dims, hasZ)
+		return LineString(ps), srid, err
+	case wkbPolygon:
+		p, err := d.polygon(dims, hasZ)
+		return p, srid, err
+	case wkbMultiPoint, wkbMultiLineString, wkbMultiPolygon, wkbGeometryCollection:
+	default:
+		return nil, 0, fmt.Errorf("wkb: unknown geometry type %d", code)
+	}
+
+	n, err := d.count(1 + 4)
+	if err != nil {
+		return nil, 0, err
+	}
+	var out any
+	switch code {
+	case wkbMultiPoint:
+		out = make(MultiPoint, 0, n)
+	case wkbMultiLineString:
+		out = make(MultiLineString, 0, n)
+	case wkbMultiPolygon:
+		out = make(MultiPolygon, 0, n)
+	default:
+		out = make(GeometryCollection, 0, n)
+	}
+
+	for i := 0; i < n; i++ {
+		start := d.pos
+		g, _, err := d.geometry(depth + 1)
+		if err != nil {
+			return nil, 0, err
+		}
+		var ok bool
+		switch o := out.(type) {
+		case MultiPoint:
+			var p Point
+			if p, ok = g.(Point); ok {
+				out = append(o, Position(p))
+			}
+		case MultiLineString:
+			var ls LineString
+			if ls, ok = g.(LineString); ok {
+				out = append(o, ls)
+			}
+		case MultiPolygon:
+			var pl Polygon
+			if pl, ok = g.(Polygon); ok {
+				out = append(o, pl)
+			}
+		case GeometryCollection:
+			out, ok = append(o, GeometryCollectionMember{g}), true
+		}
+		if !ok {
+			return nil, 0, fmt.Errorf("wkb: unexpected %s member at offset %d", geometryType(g), start)
+		}
+	}
+	return out, srid, nil
+}
+
+func (d *wkbDecoder) position(dims int, hasZ bool) (Position, error) {
+	coords := make([]float64, dims)
+	for i := range coords {
+		v, err := d.float64()
+		if err != nil {
+			return nil, err
+		}
+		coords[i] = v
+	}
+	if hasZ {
+		return coords[:3], nil
+	}
+	return coords[:2], nil
+}
+
+func (d *wkbDecoder) positions(dims int, hasZ bool) ([]Position, error) {
+	n, err := d.count(dims * 8)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Position, n)
+	for i := range out {
+		if out[i], err = d.position(dims, hasZ); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func (d *wkbDecoder) polygon(dims int, hasZ bool) (Polygon, error) {
+	n, err := d.count(4)
+	if err != nil {
+		return nil, err
+	}
+	out := make(Polygon, n)
+	for i := range out {
+		ps, err := d.positions(dims, hasZ)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = ps
+	}
+	return out, nil
+}
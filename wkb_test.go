@@ -0,0 +1,156 @@
+package joejson
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWKB(t *testing.T) {
+	testCases := map[string]struct {
+		geometry WKBMarshaler
+		wkb      string
+	}{
+		"Point": {
+			geometry: Point{1, 2},
+			wkb:      "0101000000000000000000f03f0000000000000040",
+		},
+		"Point Z": {
+			geometry: Point{1, 2, 3},
+			wkb:      "01e9030000000000000000f03f00000000000000400000000000000840",
+		},
+		"Point EMPTY": {
+			geometry: Point{},
+			wkb:      "0101000000000000000000f87f000000000000f87f",
+		},
+		"LineString": {
+			geometry: LineString{{1, 2}, {3, 4}},
+			wkb:      "010200000002000000000000000000f03f000000000000004000000000000008400000000000001040",
+		},
+		"Polygon": {
+			geometry: Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}},
+			wkb: "01030000000100000004000000" +
+				"00000000000000000000000000000000" +
+				"000000000000f03f0000000000000000" +
+				"000000000000f03f000000000000f03f" +
+				"00000000000000000000000000000000",
+		},
+		"MultiPoint": {
+			geometry: MultiPoint{{1, 2}},
+			wkb:      "0104000000010000000101000000000000000000f03f0000000000000040",
+		},
+		"MultiLineString Z": {
+			geometry: MultiLineString{{{1, 2, 3}, {4, 5, 6}}},
+			wkb: "01ed03000001000000" +
+				"01ea03000002000000" +
+				"000000000000f03f00000000000000400000000000000840" +
+				"000000000000104000000000000014400000000000001840",
+		},
+		"MultiPolygon": {
+			geometry: MultiPolygon{{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}}},
+			wkb: "010600000001000000" +
+				"01030000000100000004000000" +
+				"00000000000000000000000000000000" +
+				"000000000000f03f0000000000000000" +
+				"000000000000f03f000000000000f03f" +
+				"00000000000000000000000000000000",
+		},
+		"GeometryCollection": {
+			geometry: GeometryCollection{}.AppendPoint(Point{1, 2}).AppendLineString(LineString{{1, 2}, {3, 4}}),
+			wkb: "010700000002000000" +
+				"0101000000000000000000f03f0000000000000040" +
+				"010200000002000000000000000000f03f000000000000004000000000000008400000000000001040",
+		},
+	}
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			bs, err := tt.geometry.MarshalWKB()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wkb, hex.EncodeToString(bs))
+
+			g, srid, err := UnmarshalEWKB(bs)
+			assert.NoError(t, err)
+			assert.Equal(t, 0, srid)
+			assert.Equal(t, tt.geometry, g.geometry)
+
+			for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+				bs, err = MarshalEWKB(tt.geometry, 4326, order)
+				assert.NoError(t, err)
+				g, srid, err = UnmarshalEWKB(bs)
+				assert.NoError(t, err)
+				assert.Equal(t, 4326, srid)
+				assert.Equal(t, tt.geometry, g.geometry)
+			}
+		})
+	}
+}
+
+func TestUnmarshalWKB(t *testing.T) {
+	testCases := map[string]struct {
+		wkb  string
+		want any
+		srid int
+		err  string
+	}{
+		"big endian": {
+			wkb:  "00000000013ff00000000000004000000000000000",
+			want: Point{1, 2},
+		},
+		"EWKB with SRID": {
+			wkb:  "0101000020e6100000000000000000f03f0000000000000040",
+			want: Point{1, 2},
+			srid: 4326,
+		},
+		"EWKB Z": {
+			wkb:  "0101000080000000000000f03f00000000000000400000000000000840",
+			want: Point{1, 2, 3},
+		},
+		"ISO ZM drops M": {
+			wkb:  "01b90b0000000000000000f03f000000000000004000000000000008400000000000001040",
+			want: Point{1, 2, 3},
+		},
+		"truncated": {
+			wkb: "0101000000000000000000f03f",
+			err: "wkb: unexpected end of input",
+		},
+		"huge count": {
+			wkb: "0102000000ffffffff",
+			err: "wkb: unexpected end of input",
+		},
+		"bad byte order": {
+			wkb: "02",
+			err: "wkb: invalid byte order 2 at offset 0",
+		},
+		"unknown type": {
+			wkb: "0109000000",
+			err: "wkb: unknown geometry type 9",
+		},
+		"wrong member": {
+			wkb: "010400000001000000010200000000000000",
+			err: `wkb: unexpected LineString member at offset 9`,
+		},
+	}
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			b, err := hex.DecodeString(tt.wkb)
+			assert.NoError(t, err)
+
+			g, srid, err := UnmarshalEWKB(b)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, g.geometry)
+			assert.Equal(t, tt.srid, srid)
+		})
+	}
+
+	t.Run("type mismatch", func(t *testing.T) {
+		b, _ := Point{1, 2}.MarshalWKB()
+		var ls LineString
+		assert.EqualError(t, ls.UnmarshalWKB(b), `invalid type "Point", expected "LineString"`)
+	})
+}
@@ -0,0 +1,700 @@
+package joejson
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// WKTMarshaler is implemented by every geometry type.
+type WKTMarshaler interface {
+	MarshalWKT() ([]byte, error)
+}
+
+// WKTSyntaxError describes malformed Well-Known Text.
+type WKTSyntaxError struct {
+	// Offset is the byte offset in the input at which the error was detected.
+	Offset int
+	msg    string
+}
+
+func (e *WKTSyntaxError) Error() string {
+	return fmt.Sprintf("wkt: %s at offset %d", e.msg, e.Offset)
+}
+
+var wktTypes = map[string]string{
+	"POINT":              GeometryTypePoint,
+	"MULTIPOINT":         GeometryTypeMultiPoint,
+	"LINESTRING":         GeometryTypeLineString,
+	"MULTILINESTRING":    GeometryTypeMultiLineString,
+	"POLYGON":            GeometryTypePolygon,
+	"MULTIPOLYGON":       GeometryTypeMultiPolygon,
+	"GEOMETRYCOLLECTION": GeometryTypeGeometryCollection,
+}
+
+// MarshalWKT encodes the Point as Well-Known Text.
+func (p Point) MarshalWKT() ([]byte, error) {
+	return marshalWKT(p)
+}
+
+// UnmarshalWKT decodes a Point from Well-Known Text, ignoring any EWKT SRID prefix.
+func (p *Point) UnmarshalWKT(b []byte) error {
+	g, err := unmarshalWKTAs(b, GeometryTypePoint)
+	if err != nil {
+		return err
+	}
+	*p = g.(Point)
+	return nil
+}
+
+// MarshalWKT encodes the MultiPoint as Well-Known Text.
+func (g MultiPoint) MarshalWKT() ([]byte, error) {
+	return marshalWKT(g)
+}
+
+// UnmarshalWKT decodes a MultiPoint from Well-Known Text, ignoring any EWKT SRID prefix.
+func (g *MultiPoint) UnmarshalWKT(b []byte) error {
+	geom, err := unmarshalWKTAs(b, GeometryTypeMultiPoint)
+	if err != nil {
+		return err
+	}
+	*g = geom.(MultiPoint)
+	return nil
+}
+
+// MarshalWKT encodes the LineString as Well-Known Text.
+func (g LineString) MarshalWKT() ([]byte, error) {
+	return marshalWKT(g)
+}
+
+// UnmarshalWKT decodes a LineString from Well-Known Text, ignoring any EWKT SRID prefix.
+func (g *LineString) UnmarshalWKT(b []byte) error {
+	geom, err := unmarshalWKTAs(b, GeometryTypeLineString)
+	if err != nil {
+		return err
+	}
+	*g = geom.(LineString)
+	return nil
+}
+
+// MarshalWKT encodes the MultiLineString as Well-Known Text.
+func (g MultiLineString) MarshalWKT() ([]byte, error) {
+	return marshalWKT(g)
+}
+
+// UnmarshalWKT decodes a MultiLineString from Well-Known Text, ignoring any EWKT SRID prefix.
+func (g *MultiLineString) UnmarshalWKT(b []byte) error {
+	geom, err := unmarshalWKTAs(b, GeometryTypeMultiLineString)
+	if err != nil {
+		return err
+	}
+	*g = geom.(MultiLineString)
+	return nil
+}
+
+// MarshalWKT encodes the Polygon as Well-Known Text.
+func (p Polygon) MarshalWKT() ([]byte, error) {
+	return marshalWKT(p)
+}
+
+// UnmarshalWKT decodes a Polygon from Well-Known Text, ignoring any EWKT SRID prefix.
+func (p *Polygon) UnmarshalWKT(b []byte) error {
+	g, err := unmarshalWKTAs(b, GeometryTypePolygon)
+	if err != nil {
+		return err
+	}
+	*p = g.(Polygon)
+	return nil
+}
+
+// MarshalWKT encodes the MultiPolygon as Well-Known Text.
+func (p MultiPolygon) MarshalWKT() ([]byte, error) {
+	return marshalWKT(p)
+}
+
+// UnmarshalWKT decodes a MultiPolygon from Well-Known Text, ignoring any EWKT SRID prefix.
+func (p *MultiPolygon) UnmarshalWKT(b []byte) error {
+	g, err := unmarshalWKTAs(b, GeometryTypeMultiPolygon)
+	if err != nil {
+		return err
+	}
+	*p = g.(MultiPolygon)
+	return nil
+}
+
+// MarshalWKT encodes the GeometryCollection as Well-Known Text.
+func (g GeometryCollection) MarshalWKT() ([]byte, error) {
+	return marshalWKT(g)
+}
+
+// UnmarshalWKT decodes a GeometryCollection from Well-Known Text, ignoring any EWKT SRID prefix.
+func (g *GeometryCollection) UnmarshalWKT(b []byte) error {
+	geom, err := unmarshalWKTAs(b, GeometryTypeGeometryCollection)
+	if err != nil {
+		return err
+	}
+	*g = geom.(GeometryCollection)
+	return nil
+}
+
+// MarshalEWKT encodes a geometry as Well-Known Text with a 'SRID=n;' prefix.
+func MarshalEWKT(g WKTMarshaler, srid int) ([]byte, error) {
+	b, err := g.MarshalWKT()
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(fmt.Sprintf("SRID=%d;", srid)), b...), nil
+}
+
+// UnmarshalEWKT decodes Well-Known Text of any geometry type, with an
+// optional 'SRID=n;' prefix. srid is zero when there is no prefix.
+func UnmarshalEWKT(b []byte) (g GeometryCollectionMember, srid int, err error) {
+	p := wktParser{b: b}
+	srid, geom, err := p.parse()
+	if err != nil {
+		return GeometryCollectionMember{}, 0, err
+	}
+	return GeometryCollectionMember{geom}, srid, nil
+}
+
+func unmarshalWKTAs(b []byte, typ string) (any, error) {
+	p := wktParser{b: b}
+	_, g, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	if got := geometryType(g); got != typ {
+		return nil, fmt.Errorf("invalid type %q, expected %q", got, typ)
+	}
+	return g, nil
+}
+
+var errWKTNonFinite = errors.New("wkt: cannot encode non-finite coordinate")
+
+func marshalWKT(g any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeWKT(&buf, g); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeWKT writes any geometry type. Positions with an elevation produce Z geometries.
+func writeWKT(buf *bytes.Buffer, g any) error {
+	typ := geometryType(g)
+	if typ == "" {
+		return fmt.Errorf("wkt: unsupported geometry %T", g)
+	}
+	buf.WriteString(strings.ToUpper(typ))
+
+	hasZ := false
+	if _, ok := g.(GeometryCollection); !ok {
+		eachPosition(g, func(p Position) {
+			hasZ = hasZ || len(p) > 2
+		})
+		if hasZ {
+			buf.WriteString(" Z")
+		}
+	}
+
+	if isEmptyGeometry(g) {
+		buf.WriteString(" EMPTY")
+		return nil
+	}
+	buf.WriteByte(' ')
+
+	switch g := g.(type) {
+	case Point:
+		buf.WriteByte('(')
+		if err := writeWKTPosition(buf, Position(g), hasZ); err != nil {
+			return err
+		}
+		buf.WriteByte(')')
+		return nil
+	case MultiPoint:
+		buf.WriteByte('(')
+		for i, p := range g {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			if len(p) == 0 {
+				buf.WriteString("EMPTY")
+				continue
+			}
+			buf.WriteByte('(')
+			if err := writeWKTPosition(buf, p, hasZ); err != nil {
+				return err
+			}
+			buf.WriteByte(')')
+		}
+		buf.WriteByte(')')
+		return nil
+	case LineString:
+		return writeWKTPositions(buf, g, hasZ)
+	case MultiLineString:
+		buf.WriteByte('(')
+		for i, ls := range g {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			if err := writeWKTPositions(buf, ls, hasZ); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(')')
+		return nil
+	case Polygon:
+		return writeWKTPolygon(buf, g, hasZ)
+	case MultiPolygon:
+		buf.WriteByte('(')
+		for i, pl := range g {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			if err := writeWKTPolygon(buf, pl, hasZ); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(')')
+		return nil
+	case GeometryCollection:
+		buf.WriteByte('(')
+		for i, m := range g {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			if err := writeWKT(buf, m.geometry); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(')')
+		return nil
+	}
+	return nil
+}
+
+func writeWKTPolygon(buf *bytes.Buffer, p Polygon, hasZ bool) error {
+	if len(p) == 0 {
+		buf.WriteString("EMPTY")
+		return nil
+	}
+	buf.WriteByte('(')
+	for i, lr := range p {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		if err := writeWKTPositions(buf, lr, hasZ); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte(')')
+	return nil
+}
+
+func writeWKTPositions(buf *bytes.Buffer, ps []Position, hasZ bool) error {
+	if len(ps) == 0 {
+		buf.WriteString("EMPTY")
+		return nil
+	}
+	buf.WriteByte('(')
+	for i, p := range ps {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		if err := writeWKTPosition(buf, p, hasZ); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte(')')
+	return nil
+}
+
+func writeWKTPosition(buf *bytes.Buffer, p Position, hasZ bool) error {
+	coords := []float64{p.Lon(), p.Lat()}
+	if hasZ {
+		coords = append(coords, p.Elevation())
+	}
+	for i, c := range coords {
+		if math.IsNaN(c) || math.IsInf(c, 0) {
+			return errWKTNonFinite
+		}
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(strconv.FormatFloat(c, 'f', -1, 64))
+	}
+	return nil
+}
+
+// isEmptyGeometry reports whether g has no members, as written by 'EMPTY'.
+func isEmptyGeometry(g any) bool {
+	switch g := g.(type) {
+	case Point:
+		return len(g) == 0
+	case MultiPoint:
+		return len(g) == 0
+	case LineString:
+		return len(g) == 0
+	case MultiLineString:
+		return len(g) == 0
+	case Polygon:
+		return len(g) == 0
+	case MultiPolygon:
+		return len(g) == 0
+	case GeometryCollection:
+		return len(g) == 0
+	}
+	return true
+}
+
+// wktParser is a recursive descent parser for WKT and EWKT.
+// M coordinates are discarded since Position has no measure.
+type wktParser struct {
+	b   []byte
+	pos int
+}
+
+// wktDims describes the coordinates expected in a geometry.
+type wktDims struct {
+	z, m bool
+	// tagged is set when dimensions were given explicitly with Z, M or ZM.
+	tagged bool
+}
+
+func (p *wktParser) errorf(format string, args ...any) error {
+	return &WKTSyntaxError{Offset: p.pos, msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *wktParser) parse() (int, any, error) {
+	srid := 0
+	p.skipSpace()
+	if p.pos+5 <= len(p.b) && strings.EqualFold(string(p.b[p.pos:p.pos+5]), "SRID=") {
+		p.pos += 5
+		start := p.pos
+		if p.pos < len(p.b) && (p.b[p.pos] == '-' || p.b[p.pos] == '+') {
+			p.pos++
+		}
+		for p.pos < len(p.b) && p.b[p.pos] >= '0' && p.b[p.pos] <= '9' {
+			p.pos++
+		}
+		n, err := strconv.Atoi(string(p.b[start:p.pos]))
+		if err != nil {
+			p.pos = start
+			return 0, nil, p.errorf("invalid SRID")
+		}
+		srid = n
+		p.skipSpace()
+		if err := p.expect(';'); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	g, err := p.geometry()
+	if err != nil {
+		return 0, nil, err
+	}
+	p.skipSpace()
+	if p.pos != len(p.b) {
+		return 0, nil, p.errorf("unexpected trailing data")
+	}
+	return srid, g, nil
+}
+
+func (p *wktParser) skipSpace() {
+	for p.pos < len(p.b) {
+		switch p.b[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// word reads a run of letters, upper cased.
+func (p *wktParser) word() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.b) && (p.b[p.pos]|0x20 >= 'a' && p.b[p.pos]|0x20 <= 'z') {
+		p.pos++
+	}
+	return strings.ToUpper(string(p.b[start:p.pos]))
+}
+
+// keyword consumes w if it is the next word.
+func (p *wktParser) keyword(w string) bool {
+	start := p.pos
+	if p.word() == w {
+		return true
+	}
+	p.pos = start
+	return false
+}
+
+func (p *wktParser) peek() byte {
+	p.skipSpace()
+	if p.pos >= len(p.b) {
+		return 0
+	}
+	return p.b[p.pos]
+}
+
+func (p *wktParser) expect(c byte) error {
+	if p.peek() != c {
+		if p.pos >= len(p.b) {
+			return p.errorf("expected %q, found end of input", c)
+		}
+		return p.errorf("expected %q, found %q", c, p.b[p.pos])
+	}
+	p.pos++
+	return nil
+}
+
+// geometryType reads a geometry tag such as 'POINT', 'POINT Z' or 'POINTZM'.
+func (p *wktParser) geometryType() (string, wktDims, error) {
+	start := p.pos
+	w := p.word()
+
+	var dims wktDims
+	typ, ok := wktTypes[w]
+	if !ok {
+		for _, suffix := range []string{"ZM", "Z", "M"} {
+			if t, found := wktTypes[strings.TrimSuffix(w, suffix)]; found && strings.HasSuffix(w, suffix) {
+				typ, ok, w = t, true, suffix
+				break
+			}
+		}
+		if !ok {
+			p.pos = start
+			p.skipSpace()
+			return "", dims, p.errorf("unknown geometry type %q", w)
+		}
+	} else {
+		mark := p.pos
+		switch w = p.word(); w {
+		case "Z", "M", "ZM":
+		default:
+			p.pos = mark
+		}
+	}
+
+	switch w {
+	case "Z":
+		dims = wktDims{z: true, tagged: true}
+	case "M":
+		dims = wktDims{m: true, tagged: true}
+	case "ZM":
+		dims = wktDims{z: true, m: true, tagged: true}
+	}
+	return typ, dims, nil
+}
+
+func (p *wktParser) geometry() (any, error) {
+	typ, dims, err := p.geometryType()
+	if err != nil {
+		return nil, err
+	}
+	empty := p.keyword("EMPTY")
+
+	switch typ {
+	case GeometryTypePoint:
+		if empty {
+			return Point{}, nil
+		}
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+		pos, err := p.position(dims)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		return Point(pos), nil
+	case GeometryTypeMultiPoint:
+		if empty {
+			return MultiPoint{}, nil
+		}
+		var out MultiPoint
+		err := p.list(func() error {
+			switch {
+			case p.keyword("EMPTY"):
+				out = append(out, Position{})
+			case p.peek() == '(':
+				p.pos++
+				pos, err := p.position(dims)
+				if err != nil {
+					return err
+				}
+				out = append(out, pos)
+				return p.expect(')')
+			default:
+				pos, err := p.position(dims)
+				if err != nil {
+					return err
+				}
+				out = append(out, pos)
+			}
+			return nil
+		})
+		return out, err
+	case GeometryTypeLineString:
+		if empty {
+			return LineString{}, nil
+		}
+		ps, err := p.positions(dims)
+		return LineString(ps), err
+	case GeometryTypeMultiLineString:
+		if empty {
+			return MultiLineString{}, nil
+		}
+		var out MultiLineString
+		err := p.list(func() error {
+			ps, err := p.optionalPositions(dims)
+			out = append(out, ps)
+			return err
+		})
+		return out, err
+	case GeometryTypePolygon:
+		if empty {
+			return Polygon{}, nil
+		}
+		return p.polygon(dims)
+	case GeometryTypeMultiPolygon:
+		if empty {
+			return MultiPolygon{}, nil
+		}
+		var out MultiPolygon
+		err := p.list(func() error {
+			if p.keyword("EMPTY") {
+				out = append(out, Polygon{})
+				return nil
+			}
+			pl, err := p.polygon(dims)
+			out = append(out, pl)
+			return err
+		})
+		return out, err
+	default:
+		if empty {
+			return GeometryCollection{}, nil
+		}
+		out := GeometryCollection{}
+		err := p.list(func() error {
+			g, err := p.geometry()
+			out = append(out, GeometryCollectionMember{g})
+			return err
+		})
+		return out, err
+	}
+}
+
+// list parses a parenthesised, comma separated list calling item for each element.
+func (p *wktParser) list(item func() error) error {
+	if err := p.expect('('); err != nil {
+		return err
+	}
+	for {
+		if err := item(); err != nil {
+			return err
+		}
+		if p.peek() != ',' {
+			return p.expect(')')
+		}
+		p.pos++
+	}
+}
+
+func (p *wktParser) polygon(dims wktDims) (Polygon, error) {
+	out := Polygon{}
+	err := p.list(func() error {
+		ps, err := p.optionalPositions(dims)
+		out = append(out, ps)
+		return err
+	})
+	return out, err
+}
+
+// optionalPositions parses either 'EMPTY' or a list of positions.
+func (p *wktParser) optionalPositions(dims wktDims) ([]Position, error) {
+	if p.keyword("EMPTY") {
+		return []Position{}, nil
+	}
+	return p.positions(dims)
+}
+
+func (p *wktParser) positions(dims wktDims) ([]Position, error) {
+	var out []Position
+	err := p.list(func() error {
+		pos, err := p.position(dims)
+		out = append(out, pos)
+		return err
+	})
+	return out, err
+}
+
+// position parses whitespace separated coordinates, keeping x, y and any z.
+func (p *wktParser) position(dims wktDims) (Position, error) {
+	start := p.pos
+	var coords []float64
+	for {
+		c := p.peek()
+		if c != '-' && c != '+' && c != '.' && (c < '0' || c > '9') && c|0x20 != 'n' && c|0x20 != 'i' {
+			break
+		}
+		n, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		coords = append(coords, n)
+	}
+
+	want := 2
+	if dims.z {
+		want++
+	}
+	if dims.m {
+		want++
+	}
+	switch {
+	case dims.tagged && len(coords) != want:
+		p.pos = start
+		p.skipSpace()
+		return nil, p.errorf("expected %d coordinates, found %d", want, len(coords))
+	case len(coords) < 2 || len(coords) > 4:
+		p.pos = start
+		p.skipSpace()
+		return nil, p.errorf("expected 2 to 4 coordinates, found %d", len(coords))
+	}
+
+	if dims.m && !dims.z {
+		return Position(coords[:2]), nil
+	}
+	if len(coords) == 4 {
+		coords = coords[:3]
+	}
+	return Position(coords), nil
+}
+
+func (p *wktParser) number() (float64, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.b) {
+		c := p.b[p.pos]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' || c == '(' || c == ')' {
+			break
+		}
+		p.pos++
+	}
+	tok := string(p.b[start:p.pos])
+	n, err := strconv.ParseFloat(tok, 64)
+	if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+		p.pos = start
+		return 0, p.errorf("invalid number %q", tok)
+	}
+	return n, nil
+}
@@ -0,0 +1,185 @@
+package joejson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWKT(t *testing.T) {
+	testCases := map[string]struct {
+		geometry WKTMarshaler
+		wkt      string
+	}{
+		"Point": {
+			geometry: Point{-170, 40.5},
+			wkt:      "POINT (-170 40.5)",
+		},
+		"Point Z": {
+			geometry: Point{1, 2, 3},
+			wkt:      "POINT Z (1 2 3)",
+		},
+		"Point EMPTY": {
+			geometry: Point{},
+			wkt:      "POINT EMPTY",
+		},
+		"MultiPoint": {
+			geometry: MultiPoint{{1, 2}, {3, 4}},
+			wkt:      "MULTIPOINT ((1 2), (3 4))",
+		},
+		"LineString": {
+			geometry: LineString{{1, 2}, {3, 4}},
+			wkt:      "LINESTRING (1 2, 3 4)",
+		},
+		"LineString EMPTY": {
+			geometry: LineString{},
+			wkt:      "LINESTRING EMPTY",
+		},
+		"MultiLineString": {
+			geometry: MultiLineString{{{1, 2}, {3, 4}}, {{5, 6}, {7, 8}}},
+			wkt:      "MULTILINESTRING ((1 2, 3 4), (5 6, 7 8))",
+		},
+		"Polygon": {
+			geometry: Polygon{{{0, 0}, {10, 0}, {10, 10}, {0, 0}}, {{1, 1}, {2, 1}, {2, 2}, {1, 1}}},
+			wkt:      "POLYGON ((0 0, 10 0, 10 10, 0 0), (1 1, 2 1, 2 2, 1 1))",
+		},
+		"MultiPolygon Z": {
+			geometry: MultiPolygon{{{{0, 0, 1}, {1, 0, 1}, {1, 1, 1}, {0, 0, 1}}}},
+			wkt:      "MULTIPOLYGON Z (((0 0 1, 1 0 1, 1 1 1, 0 0 1)))",
+		},
+		"GeometryCollection": {
+			geometry: GeometryCollection{}.AppendPoint(Point{1, 2}).AppendLineString(LineString{{1, 2, 3}, {4, 5, 6}}),
+			wkt:      "GEOMETRYCOLLECTION (POINT (1 2), LINESTRING Z (1 2 3, 4 5 6))",
+		},
+		"GeometryCollection EMPTY": {
+			geometry: GeometryCollection{},
+			wkt:      "GEOMETRYCOLLECTION EMPTY",
+		},
+	}
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			bs, err := tt.geometry.MarshalWKT()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wkt, string(bs))
+
+			g, srid, err := UnmarshalEWKT(bs)
+			assert.NoError(t, err)
+			assert.Equal(t, 0, srid)
+			assert.Equal(t, tt.geometry, g.geometry)
+
+			bs, err = MarshalEWKT(tt.geometry, 4326)
+			assert.NoError(t, err)
+			g, srid, err = UnmarshalEWKT(bs)
+			assert.NoError(t, err)
+			assert.Equal(t, 4326, srid)
+			assert.Equal(t, tt.geometry, g.geometry)
+		})
+	}
+}
+
+func TestUnmarshalWKT(t *testing.T) {
+	testCases := map[string]struct {
+		wkt  string
+		want any
+		err  string
+	}{
+		"lower case without spaces": {
+			wkt:  "linestring(1 2,3 4)",
+			want: LineString{{1, 2}, {3, 4}},
+		},
+		"MultiPoint without inner parentheses": {
+			wkt:  "MULTIPOINT (1 2, 3 4)",
+			want: MultiPoint{{1, 2}, {3, 4}},
+		},
+		"implicit Z": {
+			wkt:  "POINT (1 2 3)",
+			want: Point{1, 2, 3},
+		},
+		"attached ZM drops M": {
+			wkt:  "POINTZM (1 2 3 4)",
+			want: Point{1, 2, 3},
+		},
+		"M drops M": {
+			wkt:  "LINESTRING M (1 2 3, 4 5 6)",
+			want: LineString{{1, 2}, {4, 5}},
+		},
+		"EWKT": {
+			wkt:  "SRID=4326;POINT(1e2 -2.5)",
+			want: Point{100, -2.5},
+		},
+		"unknown type": {
+			wkt: "  CIRCLE (1 2)",
+			err: `wkt: unknown geometry type "CIRCLE" at offset 2`,
+		},
+		"missing parenthesis": {
+			wkt: "POLYGON ((0 0, 1 0, 1 1, 0 0)",
+			err: `wkt: expected ')', found end of input at offset 29`,
+		},
+		"bad number": {
+			wkt: "POINT (1 2x)",
+			err: `wkt: invalid number "2x" at offset 9`,
+		},
+		"wrong dimensions": {
+			wkt: "POINT Z (1 2)",
+			err: `wkt: expected 3 coordinates, found 2 at offset 9`,
+		},
+		"trailing data": {
+			wkt: "POINT (1 2) POINT",
+			err: `wkt: unexpected trailing data at offset 12`,
+		},
+		"bad SRID": {
+			wkt: "SRID=abc;POINT (1 2)",
+			err: `wkt: invalid SRID at offset 5`,
+		},
+	}
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			g, _, err := UnmarshalEWKT([]byte(tt.wkt))
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, g.geometry)
+		})
+	}
+
+	t.Run("type mismatch", func(t *testing.T) {
+		var p Polygon
+		assert.EqualError(t, p.UnmarshalWKT([]byte("POINT (1 2)")), `invalid type "Point", expected "Polygon"`)
+	})
+}
+
+func FuzzUnmarshalWKT(f *testing.F) {
+	for _, seed := range []string{
+		"POINT (1 2)",
+		"SRID=3857;MULTIPOINT Z ((1 2 3), EMPTY)",
+		"POLYGON ((0 0, 10 0, 10 10, 0 0), EMPTY)",
+		"MULTIPOLYGON (((0 0, 1 0, 1 1, 0 0)), EMPTY)",
+		"GEOMETRYCOLLECTION (POINT EMPTY, GEOMETRYCOLLECTION (LINESTRINGM (1 2 3, 4 5 6)))",
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		g, _, err := UnmarshalEWKT(b)
+		if err != nil {
+			return
+		}
+		encoded, err := marshalWKT(g.geometry)
+		if err != nil {
+			t.Fatalf("marshal %q: %v", b, err)
+		}
+		g2, _, err := UnmarshalEWKT(encoded)
+		if err != nil {
+			t.Fatalf("unmarshal %q: %v", encoded, err)
+		}
+		reencoded, err := marshalWKT(g2.geometry)
+		if err != nil {
+			t.Fatalf("marshal %q: %v", encoded, err)
+		}
+		if string(encoded) != string(reencoded) {
+			t.Fatalf("round trip mismatch: %q != %q", encoded, reencoded)
+		}
+	})
+}